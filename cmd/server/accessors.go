@@ -2,11 +2,14 @@ package main
 
 import (
 	"fmt"
+	"sort"
 
 	klog "k8s.io/klog/v2"
 )
 
-func NamespaceCachedListGet(locked bool) []string {
+// NamespaceCachedListGet returns a page of namespace names, and those of
+// them that are Terminating.
+func NamespaceCachedListGet(locked bool, filter *ListFilter, opts *ListOptions) ([]string, []string, ListPage, error) {
 	self := "NamespaceCachedListGet"
 	klog.Infof("%s: entry", self)
 	if !locked {
@@ -14,11 +17,87 @@ func NamespaceCachedListGet(locked bool) []string {
 		defer NamespacesLock.Unlock()
 		locked = true
 	}
-	keys := make([]string, 0, len(Namespaces))
-	for k := range Namespaces {
-		keys = append(keys, k)
+	c := cacheView()
+	namespaceList, err := c.NamespaceList()
+	if err != nil {
+		return nil, nil, ListPage{}, fmt.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceList", err)
 	}
-	return keys
+	keys := make([]string, 0, len(namespaceList))
+	terminating := make([]bool, 0, len(namespaceList))
+	sortKeys := make([]listKey, 0, len(namespaceList))
+	for _, namespace := range namespaceList {
+		if filter.MatchesNamespace(namespace) {
+			replicas := 0
+			if opts != nil && opts.SortBy == SortByReplicas {
+				deploymentList, err := c.DeploymentList(namespace.Name)
+				if err != nil {
+					return nil, nil, ListPage{}, fmt.Errorf("%s: call to %q failed: %#v", self, "(Cache).DeploymentList", err)
+				}
+				for _, d := range deploymentList {
+					replicas += d.Replicas
+				}
+			}
+			keys = append(keys, namespace.Name)
+			terminating = append(terminating, namespaceTerminating(namespace))
+			sortKeys = append(sortKeys, listKey{Namespace: namespace.Name, Replicas: replicas})
+		}
+	}
+	start, end, listPage := opts.page(sortKeys, func(i, j int) {
+		keys[i], keys[j] = keys[j], keys[i]
+		terminating[i], terminating[j] = terminating[j], terminating[i]
+	})
+	terminatingKeys := make([]string, 0)
+	for i := start; i < end; i++ {
+		if terminating[i] {
+			terminatingKeys = append(terminatingKeys, keys[i])
+		}
+	}
+	return keys[start:end], terminatingKeys, listPage, nil
+}
+
+// NamespaceCachedGet returns namespace nsName with its deployments.
+func NamespaceCachedGet(locked bool, nsName string) (NamespaceItem, error) {
+	self := "NamespaceCachedGet"
+	klog.Infof("%s: entry", self)
+	if !locked {
+		NamespacesLock.Lock()
+		defer NamespacesLock.Unlock()
+		locked = true
+	}
+	c := cacheView()
+	namespace, err := c.NamespaceGet(nsName)
+	if err != nil {
+		return NamespaceItem{}, fmt.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceGet", err)
+	}
+	if namespace == nil {
+		return NamespaceItem{}, fmt.Errorf("unknown %q arg value: %q", "nsName", nsName)
+	}
+	deploymentList, err := c.DeploymentList(nsName)
+	if err != nil {
+		return NamespaceItem{}, fmt.Errorf("%s: call to %q failed: %#v", self, "(Cache).DeploymentList", err)
+	}
+	ni := *namespace
+	ni.Deployments = make(DeploymentMap, len(deploymentList))
+	for _, deployment := range deploymentList {
+		ni.Deployments[deployment.Name] = deployment
+	}
+	return ni, nil
+}
+
+// NamespaceCachedTerminating reports whether namespace nsName is cached as
+// Terminating.
+func NamespaceCachedTerminating(locked bool, nsName string) bool {
+	self := "NamespaceCachedTerminating"
+	if !locked {
+		NamespacesLock.Lock()
+		defer NamespacesLock.Unlock()
+		locked = true
+	}
+	namespace, err := cacheView().NamespaceGet(nsName)
+	if err != nil {
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceGet", err)
+	}
+	return namespace != nil && namespaceTerminating(namespace)
 }
 
 func NamespaceCachedExists(locked bool, nsName string) bool {
@@ -29,8 +108,11 @@ func NamespaceCachedExists(locked bool, nsName string) bool {
 		defer NamespacesLock.Unlock()
 		locked = true
 	}
-	_, ok := Namespaces[nsName]
-	return ok
+	namespace, err := cacheView().NamespaceGet(nsName)
+	if err != nil {
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceGet", err)
+	}
+	return namespace != nil
 }
 
 func DeploymentCachedExists(locked bool, nsName string, dName string) bool {
@@ -41,15 +123,14 @@ func DeploymentCachedExists(locked bool, nsName string, dName string) bool {
 		defer NamespacesLock.Unlock()
 		locked = true
 	}
-	_, ok := Namespaces[nsName]
-	if !ok {
-		return false
+	deployment, err := cacheView().DeploymentGet(nsName, dName)
+	if err != nil {
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).DeploymentGet", err)
 	}
-	_, ok = Namespaces[nsName].Deployments[dName]
-	return ok
+	return deployment != nil
 }
 
-func DeploymentCachedListGet(locked bool, nsName string) ([]DeploymentItem, error) {
+func DeploymentCachedListGet(locked bool, nsName string, filter *ListFilter, opts *ListOptions) ([]DeploymentItem, ListPage, error) {
 	self := "DeploymentCachedListGet"
 	klog.Infof("%s: entry", self)
 	if !locked {
@@ -58,19 +139,30 @@ func DeploymentCachedListGet(locked bool, nsName string) ([]DeploymentItem, erro
 		locked = true
 	}
 	if !NamespaceCachedExists(locked, nsName) {
-		return nil, fmt.Errorf("unknown %q arg value: %q", "nsName", nsName)
+		return nil, ListPage{}, fmt.Errorf("unknown %q arg value: %q", "nsName", nsName)
+	}
+	namespaceDeployments, err := cacheView().DeploymentList(nsName)
+	if err != nil {
+		return nil, ListPage{}, fmt.Errorf("%s: call to %q failed: %#v", self, "(Cache).DeploymentList", err)
 	}
-	namespace := Namespaces[nsName]
 	deploymentList := make([]DeploymentItem, 0)
-	if len(namespace.Deployments) != 0 {
-		for _, d := range namespace.Deployments {
-			deploymentList = append(deploymentList, DeploymentItem{d.Name, d.Replicas})
+	sortKeys := make([]listKey, 0)
+	for _, d := range namespaceDeployments {
+		if filter.MatchesDeployment(nsName, d) {
+			deploymentList = append(deploymentList, DeploymentItem{d.Name, d.Replicas, d.Labels})
+			sortKeys = append(sortKeys, listKey{Namespace: nsName, Name: d.Name, Replicas: d.Replicas})
 		}
 	}
-	return deploymentList, nil
+	start, end, listPage := opts.page(sortKeys, func(i, j int) {
+		deploymentList[i], deploymentList[j] = deploymentList[j], deploymentList[i]
+	})
+	return deploymentList[start:end], listPage, nil
 }
 
-func DeploymentCachedListAllGet(locked bool) ([]NamespaceListItem, error) {
+// DeploymentCachedListAllGet pages through the deployments of all namespaces
+// as one list, then groups the deployments of the page by namespace into
+// NamespaceListItems sorted by namespace, each keeping the requested order.
+func DeploymentCachedListAllGet(locked bool, filter *ListFilter, opts *ListOptions) ([]NamespaceListItem, ListPage, error) {
 	self := "DeploymentCachedListAllGet"
 	klog.Infof("%s: entry", self)
 	if !locked {
@@ -78,14 +170,41 @@ func DeploymentCachedListAllGet(locked bool) ([]NamespaceListItem, error) {
 		defer NamespacesLock.Unlock()
 		locked = true
 	}
-	namespaceList := make([]NamespaceListItem, 0)
-	for _, namespace := range Namespaces {
-		if len(namespace.Deployments) != 0 {
-			deploymentList, _ := DeploymentCachedListGet(locked, namespace.Name)
-			namespaceList = append(namespaceList, NamespaceListItem{namespace.Name, deploymentList})
+	c := cacheView()
+	namespaceList, err := c.NamespaceList()
+	if err != nil {
+		return nil, ListPage{}, fmt.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceList", err)
+	}
+	deploymentList := make([]DeploymentItem, 0)
+	sortKeys := make([]listKey, 0)
+	for _, namespace := range namespaceList {
+		namespaceDeployments, err := c.DeploymentList(namespace.Name)
+		if err != nil {
+			return nil, ListPage{}, fmt.Errorf("%s: call to %q failed: %#v", self, "(Cache).DeploymentList", err)
+		}
+		for _, d := range namespaceDeployments {
+			if filter.MatchesDeployment(namespace.Name, d) {
+				deploymentList = append(deploymentList, DeploymentItem{d.Name, d.Replicas, d.Labels})
+				sortKeys = append(sortKeys, listKey{Namespace: namespace.Name, Name: d.Name, Replicas: d.Replicas})
+			}
+		}
+	}
+	start, end, listPage := opts.page(sortKeys, func(i, j int) {
+		deploymentList[i], deploymentList[j] = deploymentList[j], deploymentList[i]
+	})
+	groupedList := make([]NamespaceListItem, 0)
+	groupIndex := make(map[string]int)
+	for i := start; i < end; i++ {
+		g, ok := groupIndex[sortKeys[i].Namespace]
+		if !ok {
+			g = len(groupedList)
+			groupIndex[sortKeys[i].Namespace] = g
+			groupedList = append(groupedList, NamespaceListItem{Name: sortKeys[i].Namespace})
 		}
+		groupedList[g].Deployments = append(groupedList[g].Deployments, deploymentList[i])
 	}
-	return namespaceList, nil
+	sort.SliceStable(groupedList, func(i, j int) bool { return groupedList[i].Name < groupedList[j].Name })
+	return groupedList, listPage, nil
 }
 
 func ReplicasCachedListGet(locked bool, nsName string, dName string) (DeploymentItem, error) {
@@ -99,13 +218,17 @@ func ReplicasCachedListGet(locked bool, nsName string, dName string) (Deployment
 	if !NamespaceCachedExists(locked, nsName) {
 		return DeploymentItem{}, fmt.Errorf("unknown %q arg value: %q", "nsName", nsName)
 	}
-	if !DeploymentCachedExists(locked, nsName, dName) {
+	deployment, err := cacheView().DeploymentGet(nsName, dName)
+	if err != nil {
+		return DeploymentItem{}, fmt.Errorf("%s: call to %q failed: %#v", self, "(Cache).DeploymentGet", err)
+	}
+	if deployment == nil {
 		return DeploymentItem{}, fmt.Errorf("unknown %q arg value: %q", "dName", dName)
 	}
-	return *Namespaces[nsName].Deployments[dName], nil
+	return *deployment, nil
 }
 
-func ReplicasCachedListAllGet(locked bool, nsName string) ([]DeploymentItem, error) {
+func ReplicasCachedListAllGet(locked bool, nsName string, filter *ListFilter, opts *ListOptions) ([]DeploymentItem, ListPage, error) {
 	self := "ReplicasCachedListAllGet"
 	klog.Infof("%s: entry", self)
 	if !locked {
@@ -113,10 +236,244 @@ func ReplicasCachedListAllGet(locked bool, nsName string) ([]DeploymentItem, err
 		defer NamespacesLock.Unlock()
 		locked = true
 	}
+	namespaceDeployments, err := cacheView().DeploymentList(nsName)
+	if err != nil {
+		return nil, ListPage{}, fmt.Errorf("%s: call to %q failed: %#v", self, "(Cache).DeploymentList", err)
+	}
 	deploymentList := make([]DeploymentItem, 0)
-	for _, deployment := range Namespaces[nsName].Deployments {
-		deploymentList = append(deploymentList, *deployment)
+	sortKeys := make([]listKey, 0)
+	for _, deployment := range namespaceDeployments {
+		if filter.MatchesDeployment(nsName, deployment) {
+			deploymentList = append(deploymentList, *deployment)
+			sortKeys = append(sortKeys, listKey{Namespace: nsName, Name: deployment.Name, Replicas: deployment.Replicas})
+		}
+	}
+	start, end, listPage := opts.page(sortKeys, func(i, j int) {
+		deploymentList[i], deploymentList[j] = deploymentList[j], deploymentList[i]
+	})
+	return deploymentList[start:end], listPage, nil
+}
+
+func StatefulSetCachedExists(locked bool, nsName string, sName string) bool {
+	self := "StatefulSetCachedExists"
+	klog.Infof("%s: entry", self)
+	if !locked {
+		NamespacesLock.Lock()
+		defer NamespacesLock.Unlock()
+		locked = true
+	}
+	statefulSet, err := cacheView().StatefulSetGet(nsName, sName)
+	if err != nil {
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).StatefulSetGet", err)
+	}
+	return statefulSet != nil
+}
+
+func StatefulSetCachedListGet(locked bool, nsName string, filter *ListFilter, opts *ListOptions) ([]StatefulSetItem, ListPage, error) {
+	self := "StatefulSetCachedListGet"
+	klog.Infof("%s: entry", self)
+	if !locked {
+		NamespacesLock.Lock()
+		defer NamespacesLock.Unlock()
+		locked = true
+	}
+	if !NamespaceCachedExists(locked, nsName) {
+		return nil, ListPage{}, fmt.Errorf("unknown %q arg value: %q", "nsName", nsName)
 	}
+	namespaceStatefulSets, err := cacheView().StatefulSetList(nsName)
+	if err != nil {
+		return nil, ListPage{}, fmt.Errorf("%s: call to %q failed: %#v", self, "(Cache).StatefulSetList", err)
+	}
+	statefulSetList := make([]StatefulSetItem, 0)
+	sortKeys := make([]listKey, 0)
+	for _, statefulSet := range namespaceStatefulSets {
+		if filter.MatchesStatefulSet(nsName, statefulSet) {
+			statefulSetList = append(statefulSetList, *statefulSet)
+			sortKeys = append(sortKeys, listKey{Namespace: nsName, Name: statefulSet.Name, Replicas: statefulSet.Replicas})
+		}
+	}
+	start, end, listPage := opts.page(sortKeys, func(i, j int) {
+		statefulSetList[i], statefulSetList[j] = statefulSetList[j], statefulSetList[i]
+	})
+	return statefulSetList[start:end], listPage, nil
+}
 
-	return deploymentList, nil
+// StatefulSetCachedListAllGet is DeploymentCachedListAllGet for
+// statefulsets.
+func StatefulSetCachedListAllGet(locked bool, filter *ListFilter, opts *ListOptions) ([]NamespaceStatefulSetListItem, ListPage, error) {
+	self := "StatefulSetCachedListAllGet"
+	klog.Infof("%s: entry", self)
+	if !locked {
+		NamespacesLock.Lock()
+		defer NamespacesLock.Unlock()
+		locked = true
+	}
+	c := cacheView()
+	namespaceList, err := c.NamespaceList()
+	if err != nil {
+		return nil, ListPage{}, fmt.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceList", err)
+	}
+	statefulSetList := make([]StatefulSetItem, 0)
+	sortKeys := make([]listKey, 0)
+	for _, namespace := range namespaceList {
+		namespaceStatefulSets, err := c.StatefulSetList(namespace.Name)
+		if err != nil {
+			return nil, ListPage{}, fmt.Errorf("%s: call to %q failed: %#v", self, "(Cache).StatefulSetList", err)
+		}
+		for _, statefulSet := range namespaceStatefulSets {
+			if filter.MatchesStatefulSet(namespace.Name, statefulSet) {
+				statefulSetList = append(statefulSetList, *statefulSet)
+				sortKeys = append(sortKeys, listKey{Namespace: namespace.Name, Name: statefulSet.Name, Replicas: statefulSet.Replicas})
+			}
+		}
+	}
+	start, end, listPage := opts.page(sortKeys, func(i, j int) {
+		statefulSetList[i], statefulSetList[j] = statefulSetList[j], statefulSetList[i]
+	})
+	groupedList := make([]NamespaceStatefulSetListItem, 0)
+	groupIndex := make(map[string]int)
+	for i := start; i < end; i++ {
+		g, ok := groupIndex[sortKeys[i].Namespace]
+		if !ok {
+			g = len(groupedList)
+			groupIndex[sortKeys[i].Namespace] = g
+			groupedList = append(groupedList, NamespaceStatefulSetListItem{Name: sortKeys[i].Namespace})
+		}
+		groupedList[g].StatefulSets = append(groupedList[g].StatefulSets, statefulSetList[i])
+	}
+	sort.SliceStable(groupedList, func(i, j int) bool { return groupedList[i].Name < groupedList[j].Name })
+	return groupedList, listPage, nil
+}
+
+func StatefulSetReplicasCachedGet(locked bool, nsName string, sName string) (StatefulSetItem, error) {
+	self := "StatefulSetReplicasCachedGet"
+	klog.Infof("%s: entry", self)
+	if !locked {
+		NamespacesLock.Lock()
+		defer NamespacesLock.Unlock()
+		locked = true
+	}
+	if !NamespaceCachedExists(locked, nsName) {
+		return StatefulSetItem{}, fmt.Errorf("unknown %q arg value: %q", "nsName", nsName)
+	}
+	statefulSet, err := cacheView().StatefulSetGet(nsName, sName)
+	if err != nil {
+		return StatefulSetItem{}, fmt.Errorf("%s: call to %q failed: %#v", self, "(Cache).StatefulSetGet", err)
+	}
+	if statefulSet == nil {
+		return StatefulSetItem{}, fmt.Errorf("unknown %q arg value: %q", "sName", sName)
+	}
+	return *statefulSet, nil
+}
+
+func DaemonSetCachedExists(locked bool, nsName string, dsName string) bool {
+	self := "DaemonSetCachedExists"
+	klog.Infof("%s: entry", self)
+	if !locked {
+		NamespacesLock.Lock()
+		defer NamespacesLock.Unlock()
+		locked = true
+	}
+	daemonSet, err := cacheView().DaemonSetGet(nsName, dsName)
+	if err != nil {
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).DaemonSetGet", err)
+	}
+	return daemonSet != nil
+}
+
+func DaemonSetCachedListGet(locked bool, nsName string, filter *ListFilter, opts *ListOptions) ([]DaemonSetItem, ListPage, error) {
+	self := "DaemonSetCachedListGet"
+	klog.Infof("%s: entry", self)
+	if !locked {
+		NamespacesLock.Lock()
+		defer NamespacesLock.Unlock()
+		locked = true
+	}
+	if !NamespaceCachedExists(locked, nsName) {
+		return nil, ListPage{}, fmt.Errorf("unknown %q arg value: %q", "nsName", nsName)
+	}
+	namespaceDaemonSets, err := cacheView().DaemonSetList(nsName)
+	if err != nil {
+		return nil, ListPage{}, fmt.Errorf("%s: call to %q failed: %#v", self, "(Cache).DaemonSetList", err)
+	}
+	daemonSetList := make([]DaemonSetItem, 0)
+	sortKeys := make([]listKey, 0)
+	for _, daemonSet := range namespaceDaemonSets {
+		if filter.MatchesDaemonSet(nsName, daemonSet) {
+			daemonSetList = append(daemonSetList, *daemonSet)
+			sortKeys = append(sortKeys, listKey{Namespace: nsName, Name: daemonSet.Name, Replicas: daemonSet.Desired})
+		}
+	}
+	start, end, listPage := opts.page(sortKeys, func(i, j int) {
+		daemonSetList[i], daemonSetList[j] = daemonSetList[j], daemonSetList[i]
+	})
+	return daemonSetList[start:end], listPage, nil
+}
+
+// DaemonSetCachedListAllGet is DeploymentCachedListAllGet for
+// daemonsets.
+func DaemonSetCachedListAllGet(locked bool, filter *ListFilter, opts *ListOptions) ([]NamespaceDaemonSetListItem, ListPage, error) {
+	self := "DaemonSetCachedListAllGet"
+	klog.Infof("%s: entry", self)
+	if !locked {
+		NamespacesLock.Lock()
+		defer NamespacesLock.Unlock()
+		locked = true
+	}
+	c := cacheView()
+	namespaceList, err := c.NamespaceList()
+	if err != nil {
+		return nil, ListPage{}, fmt.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceList", err)
+	}
+	daemonSetList := make([]DaemonSetItem, 0)
+	sortKeys := make([]listKey, 0)
+	for _, namespace := range namespaceList {
+		namespaceDaemonSets, err := c.DaemonSetList(namespace.Name)
+		if err != nil {
+			return nil, ListPage{}, fmt.Errorf("%s: call to %q failed: %#v", self, "(Cache).DaemonSetList", err)
+		}
+		for _, daemonSet := range namespaceDaemonSets {
+			if filter.MatchesDaemonSet(namespace.Name, daemonSet) {
+				daemonSetList = append(daemonSetList, *daemonSet)
+				sortKeys = append(sortKeys, listKey{Namespace: namespace.Name, Name: daemonSet.Name, Replicas: daemonSet.Desired})
+			}
+		}
+	}
+	start, end, listPage := opts.page(sortKeys, func(i, j int) {
+		daemonSetList[i], daemonSetList[j] = daemonSetList[j], daemonSetList[i]
+	})
+	groupedList := make([]NamespaceDaemonSetListItem, 0)
+	groupIndex := make(map[string]int)
+	for i := start; i < end; i++ {
+		g, ok := groupIndex[sortKeys[i].Namespace]
+		if !ok {
+			g = len(groupedList)
+			groupIndex[sortKeys[i].Namespace] = g
+			groupedList = append(groupedList, NamespaceDaemonSetListItem{Name: sortKeys[i].Namespace})
+		}
+		groupedList[g].DaemonSets = append(groupedList[g].DaemonSets, daemonSetList[i])
+	}
+	sort.SliceStable(groupedList, func(i, j int) bool { return groupedList[i].Name < groupedList[j].Name })
+	return groupedList, listPage, nil
+}
+
+func DaemonSetCachedGet(locked bool, nsName string, dsName string) (DaemonSetItem, error) {
+	self := "DaemonSetCachedGet"
+	klog.Infof("%s: entry", self)
+	if !locked {
+		NamespacesLock.Lock()
+		defer NamespacesLock.Unlock()
+		locked = true
+	}
+	if !NamespaceCachedExists(locked, nsName) {
+		return DaemonSetItem{}, fmt.Errorf("unknown %q arg value: %q", "nsName", nsName)
+	}
+	daemonSet, err := cacheView().DaemonSetGet(nsName, dsName)
+	if err != nil {
+		return DaemonSetItem{}, fmt.Errorf("%s: call to %q failed: %#v", self, "(Cache).DaemonSetGet", err)
+	}
+	if daemonSet == nil {
+		return DaemonSetItem{}, fmt.Errorf("unknown %q arg value: %q", "dsName", dsName)
+	}
+	return *daemonSet, nil
 }
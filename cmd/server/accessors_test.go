@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDeploymentCachedListAllGetGroupsByNamespace(t *testing.T) {
+	saved := LiveCache
+	defer func() { LiveCache = saved }()
+	c := newMemoryCache()
+	for _, nsName := range []string{"alpha", "beta"} {
+		if err := c.NamespacePut(&NamespaceItem{Name: nsName}); err != nil {
+			t.Fatalf("NamespacePut(%q) failed: %v", nsName, err)
+		}
+	}
+	deployments := map[string][]*DeploymentItem{
+		"alpha": {{Name: "api", Replicas: 3}, {Name: "web", Replicas: 1}},
+		"beta":  {{Name: "cron", Replicas: 2}, {Name: "db", Replicas: 4}},
+	}
+	for nsName, items := range deployments {
+		for _, di := range items {
+			if err := c.DeploymentPut(nsName, di); err != nil {
+				t.Fatalf("DeploymentPut(%q, %q) failed: %v", nsName, di.Name, err)
+			}
+		}
+	}
+	LiveCache = c
+	tests := []struct {
+		query string
+		want  map[string][]string
+	}{
+		{query: "", want: map[string][]string{"alpha": {"api", "web"}, "beta": {"cron", "db"}}},
+		{query: "sortBy=replicas", want: map[string][]string{"alpha": {"web", "api"}, "beta": {"cron", "db"}}},
+		{query: "sortBy=replicas&limit=3", want: map[string][]string{"alpha": {"web", "api"}, "beta": {"cron"}}},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/namespaces/ANY/deployments?"+tt.query, nil)
+		filter, err := listFilterFromRequest(r, workloadFields)
+		if err != nil {
+			t.Fatalf("listFilterFromRequest(%q) failed: %v", tt.query, err)
+		}
+		opts, err := listOptionsFromRequest(r)
+		if err != nil {
+			t.Fatalf("listOptionsFromRequest(%q) failed: %v", tt.query, err)
+		}
+		groupedList, _, err := DeploymentCachedListAllGet(false, filter, opts)
+		if err != nil {
+			t.Fatalf("DeploymentCachedListAllGet(%q) failed: %v", tt.query, err)
+		}
+		if len(groupedList) != len(tt.want) {
+			t.Fatalf("%q: got %d namespaces, want %d: %+v", tt.query, len(groupedList), len(tt.want), groupedList)
+		}
+		for i, nli := range groupedList {
+			if i > 0 && groupedList[i-1].Name >= nli.Name {
+				t.Errorf("%q: namespaces out of order: %q before %q", tt.query, groupedList[i-1].Name, nli.Name)
+			}
+			want := tt.want[nli.Name]
+			if len(nli.Deployments) != len(want) {
+				t.Errorf("%q: namespace %q has %d deployments, want %v", tt.query, nli.Name, len(nli.Deployments), want)
+				continue
+			}
+			for j, di := range nli.Deployments {
+				if di.Name != want[j] {
+					t.Errorf("%q: namespace %q deployment %d is %q, want %q", tt.query, nli.Name, j, di.Name, want[j])
+				}
+			}
+		}
+	}
+}
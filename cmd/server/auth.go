@@ -0,0 +1,46 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	klog "k8s.io/klog/v2"
+)
+
+var (
+	AdminToken string
+)
+
+// RequestIsAdmin reports whether r carries the admin bearer token.  With no
+// --admin-token-file, no request is admin.
+func RequestIsAdmin(r *http.Request) bool {
+	if AdminToken == "" {
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(AdminToken)) == 1
+}
+
+func initAdmin(App *AppX) error {
+	self := "initAdmin"
+	klog.Infof("%s: entry", self)
+	if App.AdminTokenFile == "" {
+		klog.Infof("%s: no admin token; admin endpoints disabled", self)
+		return nil
+	}
+	data, err := os.ReadFile(App.AdminTokenFile)
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "os.ReadFile", err)
+	}
+	AdminToken = strings.TrimSpace(string(data))
+	if AdminToken == "" {
+		return fmt.Errorf("%s: admin token file is empty: %q", self, App.AdminTokenFile)
+	}
+	return nil
+}
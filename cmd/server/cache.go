@@ -0,0 +1,482 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	klog "k8s.io/klog/v2"
+)
+
+const (
+	RoleAll     = "all"
+	RoleWatcher = "watcher"
+	RoleAPI     = "api"
+
+	CacheBackendMemory = "memory"
+	CacheBackendRedis  = "redis"
+)
+
+// Cache holds the namespaces and workloads that the informers maintain
+// and that the read endpoints are served from.  NamespaceItems are
+// returned without their Deployments, StatefulSets and DaemonSets; those
+// are read with DeploymentList, StatefulSetList and DaemonSetList.  Returned
+// items may be shared with the cache and must not be modified.  Callers
+// hold NamespacesLock, so implementations need not lock for themselves.
+type Cache interface {
+	NamespaceGet(nsName string) (*NamespaceItem, error)
+	NamespaceList() ([]*NamespaceItem, error)
+	NamespacePut(ni *NamespaceItem) error
+	NamespaceDelete(nsName string) error
+	DeploymentGet(nsName string, dName string) (*DeploymentItem, error)
+	DeploymentList(nsName string) ([]*DeploymentItem, error)
+	DeploymentPut(nsName string, di *DeploymentItem) error
+	DeploymentDelete(nsName string, dName string) error
+	StatefulSetGet(nsName string, sName string) (*StatefulSetItem, error)
+	StatefulSetList(nsName string) ([]*StatefulSetItem, error)
+	StatefulSetPut(nsName string, si *StatefulSetItem) error
+	StatefulSetDelete(nsName string, sName string) error
+	DaemonSetGet(nsName string, dsName string) (*DaemonSetItem, error)
+	DaemonSetList(nsName string) ([]*DaemonSetItem, error)
+	DaemonSetPut(nsName string, dsi *DaemonSetItem) error
+	DaemonSetDelete(nsName string, dsName string) error
+	SyncedGet() (bool, error)
+	SyncedSet(synced bool) error
+}
+
+var (
+	LiveCache   Cache = newMemoryCache()
+	StaleCache  Cache
+	CacheSynced bool
+)
+
+// cacheView returns the cache that reads are served from: the snapshot
+// loaded at startup until the live cache has synced, the live cache from
+// then on.  The caller must hold NamespacesLock.
+func cacheView() Cache {
+	if !CacheSynced && StaleCache != nil {
+		return StaleCache
+	}
+	return LiveCache
+}
+
+// CacheStateGet reports whether reads can be served at all, and whether
+// they are being served from a snapshot.  In role api the live cache is
+// synced once a watcher has marked the shared store as synced.
+func CacheStateGet() (ready bool, stale bool) {
+	self := "CacheStateGet"
+	NamespacesLock.Lock()
+	defer NamespacesLock.Unlock()
+	if !CacheSynced && HttpSavedApp != nil && HttpSavedApp.Role == RoleAPI {
+		synced, err := LiveCache.SyncedGet()
+		if err != nil {
+			klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).SyncedGet", err)
+		}
+		CacheSynced = synced
+	}
+	stale = !CacheSynced && StaleCache != nil
+	return CacheSynced || stale, stale
+}
+
+func cacheSyncedSet() error {
+	self := "cacheSyncedSet"
+	NamespacesLock.Lock()
+	defer NamespacesLock.Unlock()
+	CacheSynced, StaleCache = true, nil
+	err := LiveCache.SyncedSet(true)
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "(Cache).SyncedSet", err)
+	}
+	return nil
+}
+
+// cacheNamespaceMap reads a whole cache into a NamespaceMap, as used for
+// snapshots and drift checks.
+func cacheNamespaceMap(c Cache) (NamespaceMap, error) {
+	namespaceList, err := c.NamespaceList()
+	if err != nil {
+		return nil, err
+	}
+	namespaces := make(NamespaceMap, len(namespaceList))
+	for _, namespace := range namespaceList {
+		deploymentList, err := c.DeploymentList(namespace.Name)
+		if err != nil {
+			return nil, err
+		}
+		statefulSetList, err := c.StatefulSetList(namespace.Name)
+		if err != nil {
+			return nil, err
+		}
+		daemonSetList, err := c.DaemonSetList(namespace.Name)
+		if err != nil {
+			return nil, err
+		}
+		ni := *namespace
+		ni.Deployments = make(DeploymentMap, len(deploymentList))
+		for _, deployment := range deploymentList {
+			ni.Deployments[deployment.Name] = deployment
+		}
+		ni.StatefulSets = make(StatefulSetMap, len(statefulSetList))
+		for _, statefulSet := range statefulSetList {
+			ni.StatefulSets[statefulSet.Name] = statefulSet
+		}
+		ni.DaemonSets = make(DaemonSetMap, len(daemonSetList))
+		for _, daemonSet := range daemonSetList {
+			ni.DaemonSets[daemonSet.Name] = daemonSet
+		}
+		namespaces[ni.Name] = &ni
+	}
+	return namespaces, nil
+}
+
+// memoryCache is the per-pod in-memory cache.
+type memoryCache struct {
+	namespaces NamespaceMap
+	synced     bool
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{namespaces: make(NamespaceMap)}
+}
+
+// newMemoryCacheFrom adopts namespaces, e.g. as loaded from a snapshot.
+func newMemoryCacheFrom(namespaces NamespaceMap) *memoryCache {
+	for _, namespace := range namespaces {
+		if namespace.Deployments == nil {
+			namespace.Deployments = make(DeploymentMap)
+		}
+		if namespace.StatefulSets == nil {
+			namespace.StatefulSets = make(StatefulSetMap)
+		}
+		if namespace.DaemonSets == nil {
+			namespace.DaemonSets = make(DaemonSetMap)
+		}
+	}
+	return &memoryCache{namespaces: namespaces}
+}
+
+func (c *memoryCache) NamespaceGet(nsName string) (*NamespaceItem, error) {
+	namespace, ok := c.namespaces[nsName]
+	if !ok {
+		return nil, nil
+	}
+	ni := *namespace
+	ni.Deployments, ni.StatefulSets, ni.DaemonSets = nil, nil, nil
+	return &ni, nil
+}
+
+func (c *memoryCache) NamespaceList() ([]*NamespaceItem, error) {
+	namespaceList := make([]*NamespaceItem, 0, len(c.namespaces))
+	for _, namespace := range c.namespaces {
+		ni := *namespace
+		ni.Deployments, ni.StatefulSets, ni.DaemonSets = nil, nil, nil
+		namespaceList = append(namespaceList, &ni)
+	}
+	sort.Slice(namespaceList, func(i, j int) bool { return namespaceList[i].Name < namespaceList[j].Name })
+	return namespaceList, nil
+}
+
+func (c *memoryCache) NamespacePut(ni *NamespaceItem) error {
+	namespace := *ni
+	namespace.Deployments, namespace.StatefulSets, namespace.DaemonSets = make(DeploymentMap), make(StatefulSetMap), make(DaemonSetMap)
+	if existing, ok := c.namespaces[ni.Name]; ok {
+		namespace.Deployments, namespace.StatefulSets, namespace.DaemonSets = existing.Deployments, existing.StatefulSets, existing.DaemonSets
+	}
+	c.namespaces[ni.Name] = &namespace
+	return nil
+}
+
+func (c *memoryCache) NamespaceDelete(nsName string) error {
+	delete(c.namespaces, nsName)
+	return nil
+}
+
+func (c *memoryCache) DeploymentGet(nsName string, dName string) (*DeploymentItem, error) {
+	namespace, ok := c.namespaces[nsName]
+	if !ok {
+		return nil, nil
+	}
+	return namespace.Deployments[dName], nil
+}
+
+func (c *memoryCache) DeploymentList(nsName string) ([]*DeploymentItem, error) {
+	namespace, ok := c.namespaces[nsName]
+	if !ok {
+		return nil, nil
+	}
+	deploymentList := make([]*DeploymentItem, 0, len(namespace.Deployments))
+	for _, deployment := range namespace.Deployments {
+		deploymentList = append(deploymentList, deployment)
+	}
+	return deploymentList, nil
+}
+
+func (c *memoryCache) DeploymentPut(nsName string, di *DeploymentItem) error {
+	namespace, ok := c.namespaces[nsName]
+	if !ok {
+		return fmt.Errorf("unknown %q arg value: %q", "nsName", nsName)
+	}
+	deployment := *di
+	namespace.Deployments[di.Name] = &deployment
+	return nil
+}
+
+func (c *memoryCache) DeploymentDelete(nsName string, dName string) error {
+	if namespace, ok := c.namespaces[nsName]; ok {
+		delete(namespace.Deployments, dName)
+	}
+	return nil
+}
+
+func (c *memoryCache) StatefulSetGet(nsName string, sName string) (*StatefulSetItem, error) {
+	namespace, ok := c.namespaces[nsName]
+	if !ok {
+		return nil, nil
+	}
+	return namespace.StatefulSets[sName], nil
+}
+
+func (c *memoryCache) StatefulSetList(nsName string) ([]*StatefulSetItem, error) {
+	namespace, ok := c.namespaces[nsName]
+	if !ok {
+		return nil, nil
+	}
+	statefulSetList := make([]*StatefulSetItem, 0, len(namespace.StatefulSets))
+	for _, statefulSet := range namespace.StatefulSets {
+		statefulSetList = append(statefulSetList, statefulSet)
+	}
+	return statefulSetList, nil
+}
+
+func (c *memoryCache) StatefulSetPut(nsName string, si *StatefulSetItem) error {
+	namespace, ok := c.namespaces[nsName]
+	if !ok {
+		return fmt.Errorf("unknown %q arg value: %q", "nsName", nsName)
+	}
+	statefulSet := *si
+	namespace.StatefulSets[si.Name] = &statefulSet
+	return nil
+}
+
+func (c *memoryCache) StatefulSetDelete(nsName string, sName string) error {
+	if namespace, ok := c.namespaces[nsName]; ok {
+		delete(namespace.StatefulSets, sName)
+	}
+	return nil
+}
+
+func (c *memoryCache) DaemonSetGet(nsName string, dsName string) (*DaemonSetItem, error) {
+	namespace, ok := c.namespaces[nsName]
+	if !ok {
+		return nil, nil
+	}
+	return namespace.DaemonSets[dsName], nil
+}
+
+func (c *memoryCache) DaemonSetList(nsName string) ([]*DaemonSetItem, error) {
+	namespace, ok := c.namespaces[nsName]
+	if !ok {
+		return nil, nil
+	}
+	daemonSetList := make([]*DaemonSetItem, 0, len(namespace.DaemonSets))
+	for _, daemonSet := range namespace.DaemonSets {
+		daemonSetList = append(daemonSetList, daemonSet)
+	}
+	return daemonSetList, nil
+}
+
+func (c *memoryCache) DaemonSetPut(nsName string, dsi *DaemonSetItem) error {
+	namespace, ok := c.namespaces[nsName]
+	if !ok {
+		return fmt.Errorf("unknown %q arg value: %q", "nsName", nsName)
+	}
+	daemonSet := *dsi
+	namespace.DaemonSets[dsi.Name] = &daemonSet
+	return nil
+}
+
+func (c *memoryCache) DaemonSetDelete(nsName string, dsName string) error {
+	if namespace, ok := c.namespaces[nsName]; ok {
+		delete(namespace.DaemonSets, dsName)
+	}
+	return nil
+}
+
+func (c *memoryCache) SyncedGet() (bool, error) {
+	return c.synced, nil
+}
+
+func (c *memoryCache) SyncedSet(synced bool) error {
+	c.synced = synced
+	return nil
+}
+
+func initCache(App *AppX) error {
+	self := "initCache"
+	klog.Infof("%s: entry", self)
+	switch App.Role {
+	case RoleAll, RoleWatcher, RoleAPI:
+	default:
+		return fmt.Errorf("%s: unknown %q flag value: %q", self, "role", App.Role)
+	}
+	switch App.CacheBackend {
+	case CacheBackendMemory:
+		if App.Role != RoleAll {
+			return fmt.Errorf("%s: role %q needs a shared cache backend", self, App.Role)
+		}
+		LiveCache = newMemoryCache()
+	case CacheBackendRedis:
+		LiveCache = newRedisCache(newRedisConn(App.RedisAddr), App.RedisPrefix)
+	default:
+		return fmt.Errorf("%s: unknown %q flag value: %q", self, "cache-backend", App.CacheBackend)
+	}
+	klog.Infof("%s: role=%q;  cache backend=%q", self, App.Role, App.CacheBackend)
+	return nil
+}
+
+// cacheNamespaceRename moves namespace oldName, with its workloads, to
+// ni.Name.
+func cacheNamespaceRename(c Cache, ni *NamespaceItem, oldName string) error {
+	deploymentList, err := c.DeploymentList(oldName)
+	if err != nil {
+		return err
+	}
+	statefulSetList, err := c.StatefulSetList(oldName)
+	if err != nil {
+		return err
+	}
+	daemonSetList, err := c.DaemonSetList(oldName)
+	if err != nil {
+		return err
+	}
+	err = c.NamespacePut(ni)
+	if err != nil {
+		return err
+	}
+	for _, deployment := range deploymentList {
+		err = c.DeploymentPut(ni.Name, deployment)
+		if err != nil {
+			return err
+		}
+	}
+	for _, statefulSet := range statefulSetList {
+		err = c.StatefulSetPut(ni.Name, statefulSet)
+		if err != nil {
+			return err
+		}
+	}
+	for _, daemonSet := range daemonSetList {
+		err = c.DaemonSetPut(ni.Name, daemonSet)
+		if err != nil {
+			return err
+		}
+	}
+	return c.NamespaceDelete(oldName)
+}
+
+// cachePrune removes the entries that the informers, having just synced,
+// don't hold.  Only a shared cache left behind by an earlier watcher can
+// have any.
+func cachePrune(App *AppX) {
+	self := "cachePrune"
+	NamespacesLock.Lock()
+	defer NamespacesLock.Unlock()
+	namespaceList, err := LiveCache.NamespaceList()
+	if err != nil {
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceList", err)
+		return
+	}
+	namespaceInformer, haveNamespaceInformer := informerLookup(App, "namespaces")
+	for _, namespace := range namespaceList {
+		exists := NamespaceWatched(namespace.Name)
+		if exists && haveNamespaceInformer {
+			_, exists, _ = namespaceInformer.GetStore().GetByKey(namespace.Name)
+		}
+		if !exists {
+			if err = LiveCache.NamespaceDelete(namespace.Name); err != nil {
+				klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceDelete", err)
+				continue
+			}
+			changeNamespaceRecord(ChangeDelete, namespace, namespace.Name)
+			klog.Infof("%s: deleted: %q", self, namespace.Name)
+			continue
+		}
+		factoryNamespace := metav1.NamespaceAll
+		if WatchedNamespaces != nil {
+			factoryNamespace = namespace.Name
+		}
+		if informer, ok := informerLookup(App, informerName("deployments", factoryNamespace)); ok {
+			cachePruneDeployments(informer.GetStore(), namespace.Name)
+		}
+		if informer, ok := informerLookup(App, informerName("statefulsets", factoryNamespace)); ok {
+			cachePruneStatefulSets(informer.GetStore(), namespace.Name)
+		}
+		if informer, ok := informerLookup(App, informerName("daemonsets", factoryNamespace)); ok {
+			cachePruneDaemonSets(informer.GetStore(), namespace.Name)
+		}
+	}
+}
+
+func cachePruneDeployments(store cache.Store, nsName string) {
+	self := "cachePruneDeployments"
+	deploymentList, err := LiveCache.DeploymentList(nsName)
+	if err != nil {
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).DeploymentList", err)
+		return
+	}
+	for _, deployment := range deploymentList {
+		_, exists, _ := store.GetByKey(nsName + "/" + deployment.Name)
+		if exists {
+			continue
+		}
+		if err = LiveCache.DeploymentDelete(nsName, deployment.Name); err != nil {
+			klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).DeploymentDelete", err)
+			continue
+		}
+		changeDeploymentRecord(ChangeDelete, nsName, deployment, deployment.Name)
+		klog.Infof("%s: deleted: \"%s/%s\"", self, nsName, deployment.Name)
+	}
+}
+
+func cachePruneStatefulSets(store cache.Store, nsName string) {
+	self := "cachePruneStatefulSets"
+	statefulSetList, err := LiveCache.StatefulSetList(nsName)
+	if err != nil {
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).StatefulSetList", err)
+		return
+	}
+	for _, statefulSet := range statefulSetList {
+		_, exists, _ := store.GetByKey(nsName + "/" + statefulSet.Name)
+		if exists {
+			continue
+		}
+		if err = LiveCache.StatefulSetDelete(nsName, statefulSet.Name); err != nil {
+			klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).StatefulSetDelete", err)
+			continue
+		}
+		changeStatefulSetRecord(ChangeDelete, nsName, statefulSet)
+		klog.Infof("%s: deleted: \"%s/%s\"", self, nsName, statefulSet.Name)
+	}
+}
+
+func cachePruneDaemonSets(store cache.Store, nsName string) {
+	self := "cachePruneDaemonSets"
+	daemonSetList, err := LiveCache.DaemonSetList(nsName)
+	if err != nil {
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).DaemonSetList", err)
+		return
+	}
+	for _, daemonSet := range daemonSetList {
+		_, exists, _ := store.GetByKey(nsName + "/" + daemonSet.Name)
+		if exists {
+			continue
+		}
+		if err = LiveCache.DaemonSetDelete(nsName, daemonSet.Name); err != nil {
+			klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).DaemonSetDelete", err)
+			continue
+		}
+		changeDaemonSetRecord(ChangeDelete, nsName, daemonSet)
+		klog.Infof("%s: deleted: \"%s/%s\"", self, nsName, daemonSet.Name)
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	redisNamespaceField   = "_namespace"
+	redisDeploymentField  = "d:"
+	redisStatefulSetField = "s:"
+	redisDaemonSetField   = "ds:"
+)
+
+// redisCache keeps the cache in Redis, shared by the watcher and api pods:
+//
+//	<prefix>namespaces       set of namespace names
+//	<prefix>ns:<namespace>   hash: "_namespace" -> NamespaceItem JSON,
+//	                               "d:<deployment>" -> DeploymentItem JSON,
+//	                               "s:<statefulset>" -> StatefulSetItem JSON,
+//	                               "ds:<daemonset>" -> DaemonSetItem JSON
+//	<prefix>synced           "1" once a watcher has synced its informers
+//
+// Only one watcher may write to a given prefix at a time.
+type redisCache struct {
+	conn   *redisConn
+	prefix string
+}
+
+func newRedisCache(conn *redisConn, prefix string) *redisCache {
+	return &redisCache{conn: conn, prefix: prefix}
+}
+
+func (c *redisCache) namespacesKey() string {
+	return c.prefix + "namespaces"
+}
+
+func (c *redisCache) namespaceKey(nsName string) string {
+	return c.prefix + "ns:" + nsName
+}
+
+func (c *redisCache) syncedKey() string {
+	return c.prefix + "synced"
+}
+
+func (c *redisCache) NamespaceGet(nsName string) (*NamespaceItem, error) {
+	reply, err := c.conn.Do("HGET", c.namespaceKey(nsName), redisNamespaceField)
+	if err != nil || reply == nil {
+		return nil, err
+	}
+	return redisNamespaceDecode(reply)
+}
+
+func (c *redisCache) NamespaceList() ([]*NamespaceItem, error) {
+	reply, err := c.conn.Do("SMEMBERS", c.namespacesKey())
+	if err != nil {
+		return nil, err
+	}
+	members, _ := reply.([]interface{})
+	cmds := make([][]string, 0, len(members))
+	for _, member := range members {
+		nsName, _ := member.(string)
+		cmds = append(cmds, []string{"HGET", c.namespaceKey(nsName), redisNamespaceField})
+	}
+	namespaceList := make([]*NamespaceItem, 0, len(members))
+	if len(cmds) == 0 {
+		return namespaceList, nil
+	}
+	replies, err := c.conn.Pipeline(cmds...)
+	if err != nil {
+		return nil, err
+	}
+	for _, reply := range replies {
+		if reply == nil {
+			continue
+		}
+		ni, err := redisNamespaceDecode(reply)
+		if err != nil {
+			return nil, err
+		}
+		namespaceList = append(namespaceList, ni)
+	}
+	sort.Slice(namespaceList, func(i, j int) bool { return namespaceList[i].Name < namespaceList[j].Name })
+	return namespaceList, nil
+}
+
+func (c *redisCache) NamespacePut(ni *NamespaceItem) error {
+	namespace := *ni
+	namespace.Deployments, namespace.StatefulSets, namespace.DaemonSets = nil, nil, nil
+	data, err := json.Marshal(namespace)
+	if err != nil {
+		return err
+	}
+	_, err = c.conn.Pipeline(
+		[]string{"HSET", c.namespaceKey(ni.Name), redisNamespaceField, string(data)},
+		[]string{"SADD", c.namespacesKey(), ni.Name},
+	)
+	return err
+}
+
+func (c *redisCache) NamespaceDelete(nsName string) error {
+	_, err := c.conn.Pipeline(
+		[]string{"SREM", c.namespacesKey(), nsName},
+		[]string{"DEL", c.namespaceKey(nsName)},
+	)
+	return err
+}
+
+func (c *redisCache) DeploymentGet(nsName string, dName string) (*DeploymentItem, error) {
+	reply, err := c.conn.Do("HGET", c.namespaceKey(nsName), redisDeploymentField+dName)
+	if err != nil || reply == nil {
+		return nil, err
+	}
+	return redisDeploymentDecode(reply)
+}
+
+func (c *redisCache) DeploymentList(nsName string) ([]*DeploymentItem, error) {
+	reply, err := c.conn.Do("HGETALL", c.namespaceKey(nsName))
+	if err != nil {
+		return nil, err
+	}
+	fields, _ := reply.([]interface{})
+	deploymentList := make([]*DeploymentItem, 0, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		field, _ := fields[i].(string)
+		if !strings.HasPrefix(field, redisDeploymentField) {
+			continue
+		}
+		di, err := redisDeploymentDecode(fields[i+1])
+		if err != nil {
+			return nil, err
+		}
+		deploymentList = append(deploymentList, di)
+	}
+	return deploymentList, nil
+}
+
+func (c *redisCache) DeploymentPut(nsName string, di *DeploymentItem) error {
+	data, err := json.Marshal(di)
+	if err != nil {
+		return err
+	}
+	_, err = c.conn.Do("HSET", c.namespaceKey(nsName), redisDeploymentField+di.Name, string(data))
+	return err
+}
+
+func (c *redisCache) DeploymentDelete(nsName string, dName string) error {
+	_, err := c.conn.Do("HDEL", c.namespaceKey(nsName), redisDeploymentField+dName)
+	return err
+}
+
+func (c *redisCache) StatefulSetGet(nsName string, sName string) (*StatefulSetItem, error) {
+	reply, err := c.conn.Do("HGET", c.namespaceKey(nsName), redisStatefulSetField+sName)
+	if err != nil || reply == nil {
+		return nil, err
+	}
+	return redisStatefulSetDecode(reply)
+}
+
+func (c *redisCache) StatefulSetList(nsName string) ([]*StatefulSetItem, error) {
+	reply, err := c.conn.Do("HGETALL", c.namespaceKey(nsName))
+	if err != nil {
+		return nil, err
+	}
+	fields, _ := reply.([]interface{})
+	statefulSetList := make([]*StatefulSetItem, 0)
+	for i := 0; i+1 < len(fields); i += 2 {
+		field, _ := fields[i].(string)
+		if !strings.HasPrefix(field, redisStatefulSetField) {
+			continue
+		}
+		si, err := redisStatefulSetDecode(fields[i+1])
+		if err != nil {
+			return nil, err
+		}
+		statefulSetList = append(statefulSetList, si)
+	}
+	return statefulSetList, nil
+}
+
+func (c *redisCache) StatefulSetPut(nsName string, si *StatefulSetItem) error {
+	data, err := json.Marshal(si)
+	if err != nil {
+		return err
+	}
+	_, err = c.conn.Do("HSET", c.namespaceKey(nsName), redisStatefulSetField+si.Name, string(data))
+	return err
+}
+
+func (c *redisCache) StatefulSetDelete(nsName string, sName string) error {
+	_, err := c.conn.Do("HDEL", c.namespaceKey(nsName), redisStatefulSetField+sName)
+	return err
+}
+
+func (c *redisCache) DaemonSetGet(nsName string, dsName string) (*DaemonSetItem, error) {
+	reply, err := c.conn.Do("HGET", c.namespaceKey(nsName), redisDaemonSetField+dsName)
+	if err != nil || reply == nil {
+		return nil, err
+	}
+	return redisDaemonSetDecode(reply)
+}
+
+func (c *redisCache) DaemonSetList(nsName string) ([]*DaemonSetItem, error) {
+	reply, err := c.conn.Do("HGETALL", c.namespaceKey(nsName))
+	if err != nil {
+		return nil, err
+	}
+	fields, _ := reply.([]interface{})
+	daemonSetList := make([]*DaemonSetItem, 0)
+	for i := 0; i+1 < len(fields); i += 2 {
+		field, _ := fields[i].(string)
+		if !strings.HasPrefix(field, redisDaemonSetField) {
+			continue
+		}
+		dsi, err := redisDaemonSetDecode(fields[i+1])
+		if err != nil {
+			return nil, err
+		}
+		daemonSetList = append(daemonSetList, dsi)
+	}
+	return daemonSetList, nil
+}
+
+func (c *redisCache) DaemonSetPut(nsName string, dsi *DaemonSetItem) error {
+	data, err := json.Marshal(dsi)
+	if err != nil {
+		return err
+	}
+	_, err = c.conn.Do("HSET", c.namespaceKey(nsName), redisDaemonSetField+dsi.Name, string(data))
+	return err
+}
+
+func (c *redisCache) DaemonSetDelete(nsName string, dsName string) error {
+	_, err := c.conn.Do("HDEL", c.namespaceKey(nsName), redisDaemonSetField+dsName)
+	return err
+}
+
+func (c *redisCache) SyncedGet() (bool, error) {
+	reply, err := c.conn.Do("GET", c.syncedKey())
+	if err != nil {
+		return false, err
+	}
+	return reply == "1", nil
+}
+
+func (c *redisCache) SyncedSet(synced bool) error {
+	var err error
+	if synced {
+		_, err = c.conn.Do("SET", c.syncedKey(), "1")
+	} else {
+		_, err = c.conn.Do("DEL", c.syncedKey())
+	}
+	return err
+}
+
+func redisNamespaceDecode(reply interface{}) (*NamespaceItem, error) {
+	data, ok := reply.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected redis reply: %#v", reply)
+	}
+	ni := new(NamespaceItem)
+	if err := json.Unmarshal([]byte(data), ni); err != nil {
+		return nil, err
+	}
+	return ni, nil
+}
+
+func redisDeploymentDecode(reply interface{}) (*DeploymentItem, error) {
+	data, ok := reply.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected redis reply: %#v", reply)
+	}
+	di := new(DeploymentItem)
+	if err := json.Unmarshal([]byte(data), di); err != nil {
+		return nil, err
+	}
+	return di, nil
+}
+
+func redisStatefulSetDecode(reply interface{}) (*StatefulSetItem, error) {
+	data, ok := reply.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected redis reply: %#v", reply)
+	}
+	si := new(StatefulSetItem)
+	if err := json.Unmarshal([]byte(data), si); err != nil {
+		return nil, err
+	}
+	return si, nil
+}
+
+func redisDaemonSetDecode(reply interface{}) (*DaemonSetItem, error) {
+	data, ok := reply.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected redis reply: %#v", reply)
+	}
+	dsi := new(DaemonSetItem)
+	if err := json.Unmarshal([]byte(data), dsi); err != nil {
+		return nil, err
+	}
+	return dsi, nil
+}
@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCache runs the same put, get, list and delete checks against any
+// Cache, so that redisCache is held to the behavior of memoryCache.
+func testCache(t *testing.T, c Cache) {
+	if synced, err := c.SyncedGet(); err != nil || synced {
+		t.Fatalf("SyncedGet() = %t, %v; want false", synced, err)
+	}
+	if err := c.SyncedSet(true); err != nil {
+		t.Fatalf("SyncedSet(true) failed: %v", err)
+	}
+	if synced, err := c.SyncedGet(); err != nil || !synced {
+		t.Errorf("SyncedGet() = %t, %v; want true", synced, err)
+	}
+	if err := c.SyncedSet(false); err != nil {
+		t.Fatalf("SyncedSet(false) failed: %v", err)
+	}
+	if synced, err := c.SyncedGet(); err != nil || synced {
+		t.Errorf("SyncedGet() = %t, %v; want false after SyncedSet(false)", synced, err)
+	}
+
+	if ni, err := c.NamespaceGet("personal"); err != nil || ni != nil {
+		t.Fatalf("NamespaceGet() of unknown namespace = %+v, %v; want nil", ni, err)
+	}
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, ni := range []*NamespaceItem{
+		{Name: "personal", Labels: map[string]string{"team": "a"}, CreationTime: created},
+		{Name: "kube-system", Annotations: map[string]string{"note": "x"}},
+	} {
+		if err := c.NamespacePut(ni); err != nil {
+			t.Fatalf("NamespacePut(%q) failed: %v", ni.Name, err)
+		}
+	}
+	ni, err := c.NamespaceGet("personal")
+	if err != nil || ni == nil {
+		t.Fatalf("NamespaceGet() = %+v, %v; want the namespace", ni, err)
+	}
+	if ni.Labels["team"] != "a" || !ni.CreationTime.Equal(created) {
+		t.Errorf("NamespaceGet() = %+v; want the labels and creation time put", ni)
+	}
+
+	// Deployments, statefulsets and daemonsets share the hash of their
+	// namespace in redis; each list must return its own kind only.
+	deployment := &DeploymentItem{Name: "web", Replicas: 2, Labels: map[string]string{"app": "web"}}
+	if err := c.DeploymentPut("personal", deployment); err != nil {
+		t.Fatalf("DeploymentPut() failed: %v", err)
+	}
+	if err := c.DeploymentPut("personal", &DeploymentItem{Name: "api", Replicas: 1}); err != nil {
+		t.Fatalf("DeploymentPut() failed: %v", err)
+	}
+	if err := c.StatefulSetPut("personal", &StatefulSetItem{Name: "db", Replicas: 3}); err != nil {
+		t.Fatalf("StatefulSetPut() failed: %v", err)
+	}
+	if err := c.DaemonSetPut("personal", &DaemonSetItem{Name: "agent"}); err != nil {
+		t.Fatalf("DaemonSetPut() failed: %v", err)
+	}
+	if err := c.DaemonSetPut("personal", &DaemonSetItem{Name: "d:web"}); err != nil {
+		t.Fatalf("DaemonSetPut() failed: %v", err)
+	}
+	// Putting the namespace again must keep its workloads.
+	if err := c.NamespacePut(&NamespaceItem{Name: "personal", Labels: map[string]string{"team": "b"}}); err != nil {
+		t.Fatalf("NamespacePut() failed: %v", err)
+	}
+
+	di, err := c.DeploymentGet("personal", "web")
+	if err != nil || di == nil || !reflect.DeepEqual(*di, *deployment) {
+		t.Errorf("DeploymentGet() = %+v, %v; want %+v", di, err, deployment)
+	}
+	if di, err := c.DeploymentGet("personal", "db"); err != nil || di != nil {
+		t.Errorf("DeploymentGet() of a statefulset name = %+v, %v; want nil", di, err)
+	}
+	deploymentList, err := c.DeploymentList("personal")
+	if err != nil {
+		t.Fatalf("DeploymentList() failed: %v", err)
+	}
+	dNames := make([]string, 0)
+	for _, di := range deploymentList {
+		dNames = append(dNames, di.Name)
+	}
+	sort.Strings(dNames)
+	if !reflect.DeepEqual(dNames, []string{"api", "web"}) {
+		t.Errorf("DeploymentList() names = %v, want [api web]", dNames)
+	}
+	statefulSetList, err := c.StatefulSetList("personal")
+	if err != nil || len(statefulSetList) != 1 || statefulSetList[0].Name != "db" || statefulSetList[0].Replicas != 3 {
+		t.Errorf("StatefulSetList() = %+v, %v; want [db]", statefulSetList, err)
+	}
+	daemonSetList, err := c.DaemonSetList("personal")
+	if err != nil || len(daemonSetList) != 2 {
+		t.Errorf("DaemonSetList() = %+v, %v; want [agent d:web]", daemonSetList, err)
+	}
+	if si, err := c.StatefulSetGet("personal", "db"); err != nil || si == nil || si.Replicas != 3 {
+		t.Errorf("StatefulSetGet() = %+v, %v; want db", si, err)
+	}
+	if dsi, err := c.DaemonSetGet("personal", "agent"); err != nil || dsi == nil {
+		t.Errorf("DaemonSetGet() = %+v, %v; want agent", dsi, err)
+	}
+	if deploymentList, err := c.DeploymentList("kube-system"); err != nil || len(deploymentList) != 0 {
+		t.Errorf("DeploymentList() of an empty namespace = %+v, %v; want none", deploymentList, err)
+	}
+
+	namespaceList, err := c.NamespaceList()
+	if err != nil || len(namespaceList) != 2 || namespaceList[0].Name != "kube-system" || namespaceList[1].Name != "personal" {
+		t.Fatalf("NamespaceList() = %+v, %v; want [kube-system personal]", namespaceList, err)
+	}
+	if namespaceList[1].Labels["team"] != "b" || len(namespaceList[1].Deployments) != 0 {
+		t.Errorf("NamespaceList() item = %+v; want the latest labels and no workloads", namespaceList[1])
+	}
+
+	for _, err := range []error{
+		c.DeploymentDelete("personal", "api"),
+		c.StatefulSetDelete("personal", "db"),
+		c.DaemonSetDelete("personal", "agent"),
+		c.DeploymentDelete("personal", "missing"),
+	} {
+		if err != nil {
+			t.Fatalf("delete failed: %v", err)
+		}
+	}
+	if di, err := c.DeploymentGet("personal", "api"); err != nil || di != nil {
+		t.Errorf("DeploymentGet() after DeploymentDelete() = %+v, %v; want nil", di, err)
+	}
+	if deploymentList, err := c.DeploymentList("personal"); err != nil || len(deploymentList) != 1 {
+		t.Errorf("DeploymentList() after DeploymentDelete() = %+v, %v; want [web]", deploymentList, err)
+	}
+	if statefulSetList, err := c.StatefulSetList("personal"); err != nil || len(statefulSetList) != 0 {
+		t.Errorf("StatefulSetList() after StatefulSetDelete() = %+v, %v; want none", statefulSetList, err)
+	}
+	if daemonSetList, err := c.DaemonSetList("personal"); err != nil || len(daemonSetList) != 1 {
+		t.Errorf("DaemonSetList() after DaemonSetDelete() = %+v, %v; want [d:web]", daemonSetList, err)
+	}
+
+	if err := c.NamespaceDelete("personal"); err != nil {
+		t.Fatalf("NamespaceDelete() failed: %v", err)
+	}
+	if ni, err := c.NamespaceGet("personal"); err != nil || ni != nil {
+		t.Errorf("NamespaceGet() after NamespaceDelete() = %+v, %v; want nil", ni, err)
+	}
+	if di, err := c.DeploymentGet("personal", "web"); err != nil || di != nil {
+		t.Errorf("DeploymentGet() after NamespaceDelete() = %+v, %v; want nil", di, err)
+	}
+	if namespaceList, err := c.NamespaceList(); err != nil || len(namespaceList) != 1 {
+		t.Errorf("NamespaceList() after NamespaceDelete() = %+v, %v; want [kube-system]", namespaceList, err)
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	testCache(t, newMemoryCache())
+}
+
+func TestRedisCache(t *testing.T) {
+	testCache(t, newRedisCache(newRedisConn(startFakeRedis(t)), "pp:"))
+}
+
+func TestRedisCachePrefix(t *testing.T) {
+	addr := startFakeRedis(t)
+	a, b := newRedisCache(newRedisConn(addr), "a:"), newRedisCache(newRedisConn(addr), "b:")
+	if err := a.NamespacePut(&NamespaceItem{Name: "personal"}); err != nil {
+		t.Fatalf("NamespacePut() failed: %v", err)
+	}
+	if err := a.SyncedSet(true); err != nil {
+		t.Fatalf("SyncedSet() failed: %v", err)
+	}
+	if namespaceList, err := b.NamespaceList(); err != nil || len(namespaceList) != 0 {
+		t.Errorf("NamespaceList() under another prefix = %+v, %v; want none", namespaceList, err)
+	}
+	if synced, err := b.SyncedGet(); err != nil || synced {
+		t.Errorf("SyncedGet() under another prefix = %t, %v; want false", synced, err)
+	}
+}
+
+func TestRedisReadReply(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    interface{}
+		wantErr bool
+	}{
+		{input: "+OK\r\n", want: "OK"},
+		{input: ":42\r\n", want: int64(42)},
+		{input: ":-1\r\n", want: int64(-1)},
+		{input: "$5\r\nhello\r\n", want: "hello"},
+		{input: "$0\r\n\r\n", want: ""},
+		{input: "$7\r\na\r\nb\r\nc\r\n", want: "a\r\nb\r\nc"},
+		{input: "$-1\r\n", want: nil},
+		{input: "*0\r\n", want: []interface{}{}},
+		{input: "*-1\r\n", want: nil},
+		{input: "*3\r\n$1\r\na\r\n:2\r\n$-1\r\n", want: []interface{}{"a", int64(2), nil}},
+		{input: "*2\r\n*1\r\n+x\r\n$1\r\ny\r\n", want: []interface{}{[]interface{}{"x"}, "y"}},
+		{input: "-ERR wrong type\r\n", wantErr: true},
+		{input: "+OK\n", wantErr: true},
+		{input: "\r\n", wantErr: true},
+		{input: "?what\r\n", wantErr: true},
+		{input: ":x\r\n", wantErr: true},
+		{input: "$5\r\nhel", wantErr: true},
+		{input: "*2\r\n+a\r\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := redisReadReply(bufio.NewReader(strings.NewReader(tt.input)))
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("redisReadReply(%q) = %#v, want an error", tt.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("redisReadReply(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("redisReadReply(%q) = %#v, want %#v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRedisReadReplyErrorKeepsStream(t *testing.T) {
+	reader := bufio.NewReader(strings.NewReader("-ERR first\r\n+second\r\n"))
+	_, err := redisReadReply(reader)
+	if _, ok := err.(redisError); !ok || err.Error() != "ERR first" {
+		t.Fatalf("redisReadReply() error = %#v, want redisError(%q)", err, "ERR first")
+	}
+	if got, err := redisReadReply(reader); err != nil || got != "second" {
+		t.Errorf("redisReadReply() after an error reply = %#v, %v; want %q", got, err, "second")
+	}
+}
+
+func TestRedisWriteCommand(t *testing.T) {
+	var sb strings.Builder
+	writer := bufio.NewWriter(&sb)
+	redisWriteCommand(writer, []string{"HSET", "k", "a\r\nb"})
+	writer.Flush()
+	want := "*3\r\n$4\r\nHSET\r\n$1\r\nk\r\n$4\r\na\r\nb\r\n"
+	if sb.String() != want {
+		t.Errorf("redisWriteCommand() wrote %q, want %q", sb.String(), want)
+	}
+}
+
+func TestRedisConnPipeline(t *testing.T) {
+	conn := newRedisConn(startFakeRedis(t))
+	replies, err := conn.Pipeline(
+		[]string{"SET", "k", "v"},
+		[]string{"GET", "k"},
+		[]string{"GET", "missing"},
+		[]string{"SADD", "s", "a", "b", "a"},
+		[]string{"SMEMBERS", "s"},
+		[]string{"PING"},
+	)
+	if err != nil {
+		t.Fatalf("Pipeline() failed: %v", err)
+	}
+	members, _ := replies[4].([]interface{})
+	sort.Slice(members, func(i, j int) bool { return members[i].(string) < members[j].(string) })
+	want := []interface{}{"OK", "v", nil, int64(2), []interface{}{"a", "b"}, "PONG"}
+	if !reflect.DeepEqual(replies, want) {
+		t.Errorf("Pipeline() = %#v, want %#v", replies, want)
+	}
+
+	// A failing command fails the pipeline, after the others have run, and
+	// leaves the connection usable.
+	_, err = conn.Pipeline(
+		[]string{"SET", "k", "w"},
+		[]string{"NOSUCH"},
+		[]string{"SET", "j", "x"},
+	)
+	if err == nil {
+		t.Fatalf("Pipeline() with an unknown command succeeded, want an error")
+	}
+	replies, err = conn.Pipeline([]string{"GET", "k"}, []string{"GET", "j"})
+	if err != nil {
+		t.Fatalf("Pipeline() after a failed command failed: %v", err)
+	}
+	if !reflect.DeepEqual(replies, []interface{}{"w", "x"}) {
+		t.Errorf("Pipeline() after a failed command = %#v, want [w x]", replies)
+	}
+	if reply, err := conn.Do("GET", "k"); err != nil || reply != "w" {
+		t.Errorf("Do() = %#v, %v; want %q", reply, err, "w")
+	}
+}
+
+func TestRedisConnRedials(t *testing.T) {
+	conn := newRedisConn(startFakeRedis(t))
+	if _, err := conn.Do("SET", "k", "v"); err != nil {
+		t.Fatalf("Do() failed: %v", err)
+	}
+	conn.conn.Close()
+	if _, err := conn.Do("GET", "k"); err == nil {
+		t.Fatalf("Do() on a closed connection succeeded, want an error")
+	}
+	if reply, err := conn.Do("GET", "k"); err != nil || reply != "v" {
+		t.Errorf("Do() after the failure = %#v, %v; want a redial and %q", reply, err, "v")
+	}
+}
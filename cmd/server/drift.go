@@ -0,0 +1,380 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klog "k8s.io/klog/v2"
+)
+
+const (
+	DriftMissing  = "missing"
+	DriftStale    = "stale"
+	DriftReplicas = "replicas"
+	DriftLabels   = "labels"
+)
+
+// DriftItem is one disagreement between the cache and the API server.
+// Missing means live but not cached; stale means cached but not live.
+// Kind is namespace, deployment, statefulset or daemonset.
+type DriftItem struct {
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name,omitempty"`
+	Problem   string `json:"problem"`
+	Cached    string `json:"cached,omitempty"`
+	Live      string `json:"live,omitempty"`
+	Confirmed bool   `json:"confirmed"`
+	Repaired  bool   `json:"repaired"`
+
+	// The live object, converted as the informer handlers convert it, that
+	// a repair writes to the cache.
+	liveNamespace   *NamespaceItem
+	liveDeployment  *DeploymentItem
+	liveStatefulSet *StatefulSetItem
+	liveDaemonSet   *DaemonSetItem
+}
+
+type DriftReport struct {
+	CheckedAt         time.Time   `json:"checked_at"`
+	Duration          string      `json:"duration"`
+	NamespacesChecked bool        `json:"namespaces_checked"`
+	NamespaceCount    int         `json:"namespace_count"`
+	DeploymentCount   int         `json:"deployment_count"`
+	StatefulSetCount  int         `json:"statefulset_count"`
+	DaemonSetCount    int         `json:"daemonset_count"`
+	DriftCount        int         `json:"drift_count"`
+	RepairEnabled     bool        `json:"repair_enabled"`
+	Items             []DriftItem `json:"items"`
+	Error             string      `json:"error,omitempty"`
+
+	itemKeys map[string]bool
+}
+
+var (
+	DriftReportLast *DriftReport
+	DriftReportLock sync.Mutex
+)
+
+func (item *DriftItem) key() string {
+	return fmt.Sprintf("%s/%s/%s/%s", item.Kind, item.Namespace, item.Name, item.Problem)
+}
+
+// workloadDrift compares a cached workload with its live counterpart and
+// returns the disagreement, or nil.  DaemonSets pass zero replicas.
+func workloadDrift(kind string, nsName string, name string, cachedReplicas int, liveReplicas int,
+	cachedLabels map[string]string, liveLabels map[string]string) *DriftItem {
+	switch {
+	case cachedReplicas != liveReplicas:
+		return &DriftItem{Kind: kind, Namespace: nsName, Name: name, Problem: DriftReplicas,
+			Cached: strconv.Itoa(cachedReplicas), Live: strconv.Itoa(liveReplicas)}
+	case !labelsEqual(cachedLabels, liveLabels):
+		return &DriftItem{Kind: kind, Namespace: nsName, Name: name, Problem: DriftLabels,
+			Cached: fmt.Sprintf("%v", cachedLabels), Live: fmt.Sprintf("%v", liveLabels)}
+	}
+	return nil
+}
+
+// driftCheck lists namespaces, deployments, statefulsets and daemonsets
+// straight from the API server and compares them with the cache.  Kinds
+// whose informer was skipped in a namespace are not checked there.  An
+// item is confirmed when the previous check reported it too; only
+// confirmed items are repaired, so that events still in flight during a
+// check are not "repaired" backwards.
+func driftCheck(App *AppX, previous *DriftReport) *DriftReport {
+	self := "driftCheck"
+	klog.Infof("%s: entry", self)
+	start := time.Now()
+	report := &DriftReport{CheckedAt: start, RepairEnabled: App.DriftRepair, Items: []DriftItem{}}
+	liveNamespaces := make(map[string]*NamespaceItem)
+	namespaceList, err := App.Clientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+	switch {
+	case apierrors.IsForbidden(err):
+		klog.Infof("%s: namespace list not permitted; checking workloads only", self)
+	case err != nil:
+		report.Error = fmt.Sprintf("%s: call to %q failed: %v", self, "(clientset).CoreV1().Namespaces().List()", err)
+		return report
+	default:
+		report.NamespacesChecked = true
+		for _, namespace := range namespaceList.Items {
+			if NamespaceWatched(namespace.Name) {
+				liveNamespaces[namespace.Name] = namespaceItemFrom(&metav1.PartialObjectMetadata{ObjectMeta: namespace.ObjectMeta})
+			}
+		}
+	}
+	liveDeployments := make(map[string]DeploymentMap)
+	liveStatefulSets := make(map[string]StatefulSetMap)
+	liveDaemonSets := make(map[string]DaemonSetMap)
+	for nsName := range App.Factories {
+		if !InformerSkipped(App, "deployments", nsName) {
+			deploymentList, err := App.Clientset.AppsV1().Deployments(nsName).List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				report.Error = fmt.Sprintf("%s: call to %q failed: %v", self, "(clientset).AppsV1().Deployments().List()", err)
+				return report
+			}
+			for i := range deploymentList.Items {
+				deployment := &deploymentList.Items[i]
+				if liveDeployments[deployment.Namespace] == nil {
+					liveDeployments[deployment.Namespace] = make(DeploymentMap)
+				}
+				liveDeployments[deployment.Namespace][deployment.Name] = deploymentItemFrom(deployment)
+				report.DeploymentCount++
+			}
+		}
+		if !InformerSkipped(App, "statefulsets", nsName) {
+			statefulSetList, err := App.Clientset.AppsV1().StatefulSets(nsName).List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				report.Error = fmt.Sprintf("%s: call to %q failed: %v", self, "(clientset).AppsV1().StatefulSets().List()", err)
+				return report
+			}
+			for i := range statefulSetList.Items {
+				statefulSet := &statefulSetList.Items[i]
+				if liveStatefulSets[statefulSet.Namespace] == nil {
+					liveStatefulSets[statefulSet.Namespace] = make(StatefulSetMap)
+				}
+				liveStatefulSets[statefulSet.Namespace][statefulSet.Name] = statefulSetItemFrom(statefulSet)
+				report.StatefulSetCount++
+			}
+		}
+		if !InformerSkipped(App, "daemonsets", nsName) {
+			daemonSetList, err := App.Clientset.AppsV1().DaemonSets(nsName).List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				report.Error = fmt.Sprintf("%s: call to %q failed: %v", self, "(clientset).AppsV1().DaemonSets().List()", err)
+				return report
+			}
+			for i := range daemonSetList.Items {
+				daemonSet := &daemonSetList.Items[i]
+				if liveDaemonSets[daemonSet.Namespace] == nil {
+					liveDaemonSets[daemonSet.Namespace] = make(DaemonSetMap)
+				}
+				liveDaemonSets[daemonSet.Namespace][daemonSet.Name] = daemonSetItemFrom(daemonSet)
+				report.DaemonSetCount++
+			}
+		}
+	}
+	report.NamespaceCount = len(liveNamespaces)
+
+	NamespacesLock.Lock()
+	defer NamespacesLock.Unlock()
+	cached, err := cacheNamespaceMap(LiveCache)
+	if err != nil {
+		report.Error = fmt.Sprintf("%s: call to %q failed: %v", self, "cacheNamespaceMap", err)
+		return report
+	}
+	if report.NamespacesChecked {
+		for nsName, live := range liveNamespaces {
+			namespace, ok := cached[nsName]
+			switch {
+			case !ok:
+				report.Items = append(report.Items, DriftItem{Kind: "namespace", Namespace: nsName,
+					Problem: DriftMissing, liveNamespace: live})
+			case !labelsEqual(namespace.Labels, live.Labels):
+				report.Items = append(report.Items, DriftItem{Kind: "namespace", Namespace: nsName, Problem: DriftLabels,
+					Cached: fmt.Sprintf("%v", namespace.Labels), Live: fmt.Sprintf("%v", live.Labels), liveNamespace: live})
+			}
+		}
+		for nsName := range cached {
+			if _, ok := liveNamespaces[nsName]; !ok {
+				report.Items = append(report.Items, DriftItem{Kind: "namespace", Namespace: nsName, Problem: DriftStale})
+			}
+		}
+	}
+	for nsName, namespace := range cached {
+		for dName, live := range liveDeployments[nsName] {
+			item := &DriftItem{Kind: "deployment", Namespace: nsName, Name: dName, Problem: DriftMissing}
+			if deployment, ok := namespace.Deployments[dName]; ok {
+				item = workloadDrift("deployment", nsName, dName, deployment.Replicas, live.Replicas,
+					deployment.Labels, live.Labels)
+			}
+			if item != nil {
+				item.liveDeployment = live
+				report.Items = append(report.Items, *item)
+			}
+		}
+		for sName, live := range liveStatefulSets[nsName] {
+			item := &DriftItem{Kind: "statefulset", Namespace: nsName, Name: sName, Problem: DriftMissing}
+			if statefulSet, ok := namespace.StatefulSets[sName]; ok {
+				item = workloadDrift("statefulset", nsName, sName, statefulSet.Replicas, live.Replicas,
+					statefulSet.Labels, live.Labels)
+			}
+			if item != nil {
+				item.liveStatefulSet = live
+				report.Items = append(report.Items, *item)
+			}
+		}
+		for dsName, live := range liveDaemonSets[nsName] {
+			item := &DriftItem{Kind: "daemonset", Namespace: nsName, Name: dsName, Problem: DriftMissing}
+			if daemonSet, ok := namespace.DaemonSets[dsName]; ok {
+				item = workloadDrift("daemonset", nsName, dsName, 0, 0, daemonSet.Labels, live.Labels)
+			}
+			if item != nil {
+				item.liveDaemonSet = live
+				report.Items = append(report.Items, *item)
+			}
+		}
+		for dName := range namespace.Deployments {
+			if _, ok := liveDeployments[nsName][dName]; !ok && !InformerSkipped(App, "deployments", nsName) {
+				report.Items = append(report.Items, DriftItem{Kind: "deployment", Namespace: nsName, Name: dName, Problem: DriftStale})
+			}
+		}
+		for sName := range namespace.StatefulSets {
+			if _, ok := liveStatefulSets[nsName][sName]; !ok && !InformerSkipped(App, "statefulsets", nsName) {
+				report.Items = append(report.Items, DriftItem{Kind: "statefulset", Namespace: nsName, Name: sName, Problem: DriftStale})
+			}
+		}
+		for dsName := range namespace.DaemonSets {
+			if _, ok := liveDaemonSets[nsName][dsName]; !ok && !InformerSkipped(App, "daemonsets", nsName) {
+				report.Items = append(report.Items, DriftItem{Kind: "daemonset", Namespace: nsName, Name: dsName, Problem: DriftStale})
+			}
+		}
+	}
+	report.itemKeys = make(map[string]bool)
+	for i := range report.Items {
+		item := &report.Items[i]
+		item.Confirmed = previous != nil && previous.itemKeys[item.key()]
+		report.itemKeys[item.key()] = true
+		if item.Confirmed && App.DriftRepair {
+			item.Repaired = driftRepair(true, item)
+		}
+	}
+	report.DriftCount = len(report.Items)
+	report.Duration = time.Since(start).String()
+	return report
+}
+
+// driftRepair brings the cache in line with the live object recorded in
+// item: stale entries are deleted, and the others are written as listed.
+// A namespace keeps its cached workloads.
+func driftRepair(locked bool, item *DriftItem) bool {
+	self := "driftRepair"
+	if !locked {
+		NamespacesLock.Lock()
+		defer NamespacesLock.Unlock()
+		locked = true
+	}
+	op := ChangeUpdate
+	switch item.Problem {
+	case DriftMissing:
+		op = ChangeAdd
+	case DriftStale:
+		op = ChangeDelete
+	}
+	var err error
+	var record func()
+	switch {
+	case item.Kind == "namespace" && op == ChangeDelete:
+		ni := &NamespaceItem{Name: item.Namespace}
+		err = LiveCache.NamespaceDelete(item.Namespace)
+		record = func() { changeNamespaceRecord(op, ni, ni.Name) }
+	case item.Kind == "namespace":
+		ni := item.liveNamespace
+		if cached, getErr := LiveCache.NamespaceGet(item.Namespace); getErr == nil && cached != nil {
+			updated := *cached
+			updated.Labels, updated.Annotations = ni.Labels, ni.Annotations
+			updated.Phase, updated.CreationTime = ni.Phase, ni.CreationTime
+			ni = &updated
+		}
+		err = LiveCache.NamespacePut(ni)
+		record = func() { changeNamespaceRecord(op, ni, ni.Name) }
+	case !NamespaceCachedExists(locked, item.Namespace):
+		return false
+	case item.Kind == "deployment" && op == ChangeDelete:
+		di := &DeploymentItem{Name: item.Name}
+		err = LiveCache.DeploymentDelete(item.Namespace, item.Name)
+		record = func() { changeDeploymentRecord(op, item.Namespace, di, di.Name) }
+	case item.Kind == "deployment":
+		di := item.liveDeployment
+		err = LiveCache.DeploymentPut(item.Namespace, di)
+		record = func() { changeDeploymentRecord(op, item.Namespace, di, di.Name) }
+	case item.Kind == "statefulset" && op == ChangeDelete:
+		si := &StatefulSetItem{Name: item.Name}
+		err = LiveCache.StatefulSetDelete(item.Namespace, item.Name)
+		record = func() { changeStatefulSetRecord(op, item.Namespace, si) }
+	case item.Kind == "statefulset":
+		si := item.liveStatefulSet
+		err = LiveCache.StatefulSetPut(item.Namespace, si)
+		record = func() { changeStatefulSetRecord(op, item.Namespace, si) }
+	case item.Kind == "daemonset" && op == ChangeDelete:
+		dsi := &DaemonSetItem{Name: item.Name}
+		err = LiveCache.DaemonSetDelete(item.Namespace, item.Name)
+		record = func() { changeDaemonSetRecord(op, item.Namespace, dsi) }
+	case item.Kind == "daemonset":
+		dsi := item.liveDaemonSet
+		err = LiveCache.DaemonSetPut(item.Namespace, dsi)
+		record = func() { changeDaemonSetRecord(op, item.Namespace, dsi) }
+	default:
+		return false
+	}
+	if err != nil {
+		klog.Errorf("%s: repair of %s failed: %#v", self, item.key(), err)
+		return false
+	}
+	record()
+	metricCacheDriftRepairs.Inc()
+	klog.Infof("%s: repaired: %s", self, item.key())
+	return true
+}
+
+func labelsEqual(a, b map[string]string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func DriftReportGet() *DriftReport {
+	DriftReportLock.Lock()
+	defer DriftReportLock.Unlock()
+	return DriftReportLast
+}
+
+func runDriftChecker(App *AppX) {
+	self := "runDriftChecker"
+	ticker := time.NewTicker(App.DriftCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-App.Stop:
+			return
+		case <-ticker.C:
+		}
+		report := driftCheck(App, DriftReportGet())
+		switch {
+		case report.Error != "":
+			klog.Errorf("%s: %s", self, report.Error)
+			metricCacheDriftChecks.WithLabelValues("error").Inc()
+		case report.DriftCount != 0:
+			klog.Errorf("%s: cache drift detected: %d item(s)", self, report.DriftCount)
+			metricCacheDriftChecks.WithLabelValues("drift").Inc()
+		default:
+			metricCacheDriftChecks.WithLabelValues("ok").Inc()
+		}
+		if report.Error == "" {
+			metricCacheDriftCount.Set(float64(report.DriftCount))
+		}
+		DriftReportLock.Lock()
+		DriftReportLast = report
+		DriftReportLock.Unlock()
+	}
+}
+
+func initDriftChecker(App *AppX) error {
+	self := "initDriftChecker"
+	klog.Infof("%s: entry", self)
+	if App.DriftCheckInterval <= 0 {
+		klog.Infof("%s: drift checker disabled", self)
+		return nil
+	}
+	if App.Role == RoleAPI {
+		klog.Infof("%s: role %q leaves drift checks to the watcher", self, App.Role)
+		return nil
+	}
+	go runDriftChecker(App)
+	return nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	klog "k8s.io/klog/v2"
+)
+
+const (
+	involvedObjectIndex = "involvedObject"
+)
+
+// EventItem is a Kubernetes event about a deployment or one of its
+// replicasets or pods.  Count is how often the event was seen between
+// FirstTime and LastTime.
+type EventItem struct {
+	Type      string    `json:"type"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message"`
+	Kind      string    `json:"kind"`
+	Object    string    `json:"object"`
+	Count     int       `json:"count"`
+	FirstTime time.Time `json:"first_time"`
+	LastTime  time.Time `json:"last_time"`
+	Source    string    `json:"source,omitempty"`
+}
+
+// involvedObjectIndexFunc indexes events by the UID of the object they
+// are about.
+func involvedObjectIndexFunc(obj interface{}) ([]string, error) {
+	event, ok := obj.(*corev1.Event)
+	if !ok || event.InvolvedObject.UID == "" {
+		return []string{}, nil
+	}
+	return []string{string(event.InvolvedObject.UID)}, nil
+}
+
+func eventInformerAdd(App *AppX, nsName string, factory informers.SharedInformerFactory) error {
+	self := "eventInformerAdd"
+	klog.Infof("%s: entry", self)
+	err := auxInformerAdd(App, informerName("events", nsName), factory.Core().V1().Events().Informer(),
+		eventTransform, cache.Indexers{involvedObjectIndex: involvedObjectIndexFunc})
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "auxInformerAdd", err)
+	}
+	return nil
+}
+
+// DeploymentEventsGet returns the events about deployment nsName/dName,
+// its replicasets and their pods last seen at or after since, oldest
+// first.  Events about pods that no longer exist are not found.
+func DeploymentEventsGet(nsName string, dName string, since time.Time) ([]EventItem, error) {
+	self := "DeploymentEventsGet"
+	klog.Infof("%s: entry", self)
+	eventInformer := informerGet("events", nsName)
+	if eventInformer == nil {
+		return nil, fmt.Errorf("%s: informers not available for namespace: %q", self, nsName)
+	}
+	deployment, replicaSets, pods, err := deploymentObjectsGet(nsName, dName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "deploymentObjectsGet", err)
+	}
+	uids := []string{string(deployment.UID)}
+	for _, replicaSet := range replicaSets {
+		uids = append(uids, string(replicaSet.UID))
+	}
+	for _, pod := range pods {
+		uids = append(uids, string(pod.UID))
+	}
+	eventList := make([]EventItem, 0)
+	for _, uid := range uids {
+		objs, err := eventInformer.GetIndexer().ByIndex(involvedObjectIndex, uid)
+		if err != nil {
+			return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Indexer).ByIndex", err)
+		}
+		for _, obj := range objs {
+			ei := eventItemFrom(obj.(*corev1.Event))
+			if ei.LastTime.Before(since) {
+				continue
+			}
+			eventList = append(eventList, ei)
+		}
+	}
+	sort.Slice(eventList, func(i, j int) bool {
+		if !eventList[i].LastTime.Equal(eventList[j].LastTime) {
+			return eventList[i].LastTime.Before(eventList[j].LastTime)
+		}
+		if eventList[i].Object != eventList[j].Object {
+			return eventList[i].Object < eventList[j].Object
+		}
+		return eventList[i].Reason < eventList[j].Reason
+	})
+	return eventList, nil
+}
+
+// eventItemFrom reads event's times and count from whichever of the
+// core/v1 and events.k8s.io/v1 fields its reporter set.
+func eventItemFrom(event *corev1.Event) EventItem {
+	ei := EventItem{Type: event.Type, Reason: event.Reason, Message: event.Message, Kind: event.InvolvedObject.Kind,
+		Object: event.InvolvedObject.Name, Count: int(event.Count), Source: event.Source.Component}
+	if ei.Source == "" {
+		ei.Source = event.ReportingController
+	}
+	switch {
+	case !event.FirstTimestamp.IsZero():
+		ei.FirstTime = event.FirstTimestamp.UTC()
+	case !event.EventTime.IsZero():
+		ei.FirstTime = event.EventTime.UTC()
+	default:
+		ei.FirstTime = event.CreationTimestamp.UTC()
+	}
+	switch {
+	case !event.LastTimestamp.IsZero():
+		ei.LastTime = event.LastTimestamp.UTC()
+	case event.Series != nil && !event.Series.LastObservedTime.IsZero():
+		ei.LastTime = event.Series.LastObservedTime.UTC()
+	default:
+		ei.LastTime = ei.FirstTime
+	}
+	if event.Series != nil && int(event.Series.Count) > ei.Count {
+		ei.Count = int(event.Series.Count)
+	}
+	if ei.Count == 0 {
+		ei.Count = 1
+	}
+	return ei
+}
+
+// eventsSinceFromRequest reads the since query parameter, either a time
+// in RFC 3339 form or a duration before now such as "15m".  Without it,
+// all events are wanted.
+func eventsSinceFromRequest(r *http.Request, now time.Time) (time.Time, error) {
+	self := "eventsSinceFromRequest"
+	value := r.URL.Query().Get("since")
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if since, err := time.Parse(time.RFC3339, value); err == nil {
+		return since, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return time.Time{}, fmt.Errorf("%s: invalid since value: %q", self, value)
+	}
+	return now.Add(-d), nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	klog "k8s.io/klog/v2"
+)
+
+const (
+	hpaTargetIndex = "target"
+)
+
+// HPAItem is an autoscaling/v2 HorizontalPodAutoscaler.  Utilizations are
+// CPU percentages of the pods' requests, left out when the HPA has no CPU
+// utilization target or has not measured one yet.
+type HPAItem struct {
+	Name                  string `json:"hpa"`
+	TargetKind            string `json:"target_kind"`
+	TargetName            string `json:"target_name"`
+	MinReplicas           int    `json:"min_replicas"`
+	MaxReplicas           int    `json:"max_replicas"`
+	TargetCPUUtilization  *int   `json:"target_cpu_utilization,omitempty"`
+	CurrentCPUUtilization *int   `json:"current_cpu_utilization,omitempty"`
+	CurrentReplicas       int    `json:"current_replicas"`
+	DesiredReplicas       int    `json:"desired_replicas"`
+}
+
+// HPAPatch is the body of a PATCH to a deployment's HPA.  Fields left out
+// are unchanged.
+type HPAPatch struct {
+	MinReplicas          *int `json:"min_replicas"`
+	MaxReplicas          *int `json:"max_replicas"`
+	TargetCPUUtilization *int `json:"target_cpu_utilization"`
+}
+
+// hpaTargetIndexFunc indexes HPAs by namespace and scale target, e.g.
+// "personal/Deployment/nginx".
+func hpaTargetIndexFunc(obj interface{}) ([]string, error) {
+	hpa, ok := obj.(*autoscalingv2.HorizontalPodAutoscaler)
+	if !ok {
+		return []string{}, nil
+	}
+	return []string{hpaTargetKey(hpa.Namespace, hpa.Spec.ScaleTargetRef.Kind, hpa.Spec.ScaleTargetRef.Name)}, nil
+}
+
+func hpaTargetKey(nsName string, kind string, name string) string {
+	return nsName + "/" + kind + "/" + name
+}
+
+func hpaInformerAdd(App *AppX, nsName string, factory informers.SharedInformerFactory) error {
+	self := "hpaInformerAdd"
+	klog.Infof("%s: entry", self)
+	err := auxInformerAdd(App, informerName("hpas", nsName), factory.Autoscaling().V2().HorizontalPodAutoscalers().Informer(),
+		metadataTrimTransform, cache.Indexers{hpaTargetIndex: hpaTargetIndexFunc})
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "auxInformerAdd", err)
+	}
+	return nil
+}
+
+func hpaItemFrom(hpa *autoscalingv2.HorizontalPodAutoscaler) HPAItem {
+	hi := HPAItem{Name: hpa.Name, TargetKind: hpa.Spec.ScaleTargetRef.Kind, TargetName: hpa.Spec.ScaleTargetRef.Name,
+		MinReplicas: 1, MaxReplicas: int(hpa.Spec.MaxReplicas),
+		CurrentReplicas: int(hpa.Status.CurrentReplicas), DesiredReplicas: int(hpa.Status.DesiredReplicas)}
+	if hpa.Spec.MinReplicas != nil {
+		hi.MinReplicas = int(*hpa.Spec.MinReplicas)
+	}
+	if metric := hpaCPUMetric(hpa.Spec.Metrics); metric != nil && metric.Resource.Target.AverageUtilization != nil {
+		utilization := int(*metric.Resource.Target.AverageUtilization)
+		hi.TargetCPUUtilization = &utilization
+	}
+	for _, metric := range hpa.Status.CurrentMetrics {
+		if metric.Type == autoscalingv2.ResourceMetricSourceType && metric.Resource != nil &&
+			metric.Resource.Name == corev1.ResourceCPU && metric.Resource.Current.AverageUtilization != nil {
+			utilization := int(*metric.Resource.Current.AverageUtilization)
+			hi.CurrentCPUUtilization = &utilization
+		}
+	}
+	return hi
+}
+
+// hpaCPUMetric returns the CPU utilization metric of metrics, if any.
+func hpaCPUMetric(metrics []autoscalingv2.MetricSpec) *autoscalingv2.MetricSpec {
+	for i := range metrics {
+		metric := &metrics[i]
+		if metric.Type == autoscalingv2.ResourceMetricSourceType && metric.Resource != nil &&
+			metric.Resource.Name == corev1.ResourceCPU && metric.Resource.Target.Type == autoscalingv2.UtilizationMetricType {
+			return metric
+		}
+	}
+	return nil
+}
+
+func HPAListGet(nsName string) ([]HPAItem, error) {
+	self := "HPAListGet"
+	klog.Infof("%s: entry", self)
+	informer := informerGet("hpas", nsName)
+	if informer == nil {
+		return nil, fmt.Errorf("%s: informers not available for namespace: %q", self, nsName)
+	}
+	objs, err := informer.GetIndexer().ByIndex(cache.NamespaceIndex, nsName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Indexer).ByIndex", err)
+	}
+	hpaList := make([]HPAItem, 0, len(objs))
+	for _, obj := range objs {
+		hpaList = append(hpaList, hpaItemFrom(obj.(*autoscalingv2.HorizontalPodAutoscaler)))
+	}
+	sort.Slice(hpaList, func(i, j int) bool { return hpaList[i].Name < hpaList[j].Name })
+	return hpaList, nil
+}
+
+// DeploymentHPAGet returns the HPA scaling deployment nsName/dName, or nil
+// if there is none.  Should several HPAs target the deployment, which the
+// HPA controller refuses to act on, the first by name is returned.
+func DeploymentHPAGet(nsName string, dName string) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	self := "DeploymentHPAGet"
+	klog.Infof("%s: entry", self)
+	informer := informerGet("hpas", nsName)
+	if informer == nil {
+		return nil, fmt.Errorf("%s: informers not available for namespace: %q", self, nsName)
+	}
+	objs, err := informer.GetIndexer().ByIndex(hpaTargetIndex, hpaTargetKey(nsName, "Deployment", dName))
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Indexer).ByIndex", err)
+	}
+	var hpa *autoscalingv2.HorizontalPodAutoscaler
+	for _, obj := range objs {
+		candidate := obj.(*autoscalingv2.HorizontalPodAutoscaler)
+		if hpa == nil || candidate.Name < hpa.Name {
+			hpa = candidate
+		}
+	}
+	return hpa, nil
+}
+
+// hpaPatchFromRequest reads and checks an HPAPatch.  Limits are checked
+// against current, the HPA being patched, so that a patch of either
+// limit alone cannot leave min above max.
+func hpaPatchFromRequest(r io.Reader, current HPAItem) (*HPAPatch, error) {
+	self := "hpaPatchFromRequest"
+	patch := new(HPAPatch)
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(patch); err != nil {
+		return nil, fmt.Errorf("%s: invalid body: %v", self, err)
+	}
+	if patch.MinReplicas == nil && patch.MaxReplicas == nil && patch.TargetCPUUtilization == nil {
+		return nil, fmt.Errorf("%s: empty patch", self)
+	}
+	minReplicas, maxReplicas := current.MinReplicas, current.MaxReplicas
+	if patch.MinReplicas != nil {
+		minReplicas = *patch.MinReplicas
+	}
+	if patch.MaxReplicas != nil {
+		maxReplicas = *patch.MaxReplicas
+	}
+	if minReplicas < 1 || maxReplicas < minReplicas {
+		return nil, fmt.Errorf("%s: invalid replica limits: min_replicas=%d;  max_replicas=%d", self, minReplicas, maxReplicas)
+	}
+	if patch.TargetCPUUtilization != nil && *patch.TargetCPUUtilization < 1 {
+		return nil, fmt.Errorf("%s: invalid target_cpu_utilization value: %d", self, *patch.TargetCPUUtilization)
+	}
+	return patch, nil
+}
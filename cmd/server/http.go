@@ -2,11 +2,20 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
 	"strconv"
+	"strings"
+	"time"
 
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/component-base/metrics/legacyregistry"
 	klog "k8s.io/klog/v2"
 )
 
@@ -16,19 +25,120 @@ var (
 	reLivez                   = regexp.MustCompile(`^\/livez[\/]?$`)
 	reReadyz                  = regexp.MustCompile(`^\/readyz[\/]?$`)
 	reNamespaces              = regexp.MustCompile(`^\/namespaces[\/]?$`)
+	reNamespaceOne            = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)[\/]?$`)
+	reNamespaceScoped         = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/.+$`)
 	reNamespaceOneDeployments = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/deployments[\/]?$`)
 	reNamespaceAllDeployments = regexp.MustCompile(`^\/namespaces\/ANY\/deployments[\/]?$`)
 	reDeploymentOneReplicas   = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/deployments[\/]([-a-z0-9]+)\/replica_count[\/]?$`)
 	reDeploymentAllReplicas   = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/deployments[\/]ANY\/replica_count[\/]?$`)
 	reDeploymentSetReplicas   = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/deployments[\/]([-a-z0-9]+)\/replica_count\/(\d+)[\/]?$`)
+	reMetrics                 = regexp.MustCompile(`^\/metrics[\/]?$`)
+	reDebugDrift              = regexp.MustCompile(`^\/debug\/drift[\/]?$`)
+	reDebugCache              = regexp.MustCompile(`^\/debug\/cache[\/]?$`)
+	reChanges                 = regexp.MustCompile(`^\/changes[\/]?$`)
+
+	reNamespaceOneStatefulSets = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/statefulsets[\/]?$`)
+	reNamespaceAllStatefulSets = regexp.MustCompile(`^\/namespaces\/ANY\/statefulsets[\/]?$`)
+	reStatefulSetOneReplicas   = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/statefulsets[\/]([-a-z0-9]+)\/replica_count[\/]?$`)
+	reStatefulSetAllReplicas   = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/statefulsets[\/]ANY\/replica_count[\/]?$`)
+	reStatefulSetSetReplicas   = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/statefulsets[\/]([-a-z0-9]+)\/replica_count\/(\d+)[\/]?$`)
+
+	reNamespaceOneDaemonSets = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/daemonsets[\/]?$`)
+	reNamespaceAllDaemonSets = regexp.MustCompile(`^\/namespaces\/ANY\/daemonsets[\/]?$`)
+	reDaemonSetOne           = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/daemonsets[\/]([-a-z0-9]+)[\/]?$`)
+
+	reDeploymentPods = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/deployments[\/]([-a-z0-9]+)\/pods[\/]?$`)
+	reNamespaceHPAs  = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/hpas[\/]?$`)
+	reDeploymentHPA  = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/deployments[\/]([-a-z0-9]+)\/hpa[\/]?$`)
+
+	reDeploymentServices = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/deployments[\/]([-a-z0-9]+)\/services[\/]?$`)
+	reNamespaceServices  = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/services[\/]?$`)
+
+	reNamespaceJobs     = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/jobs[\/]?$`)
+	reNamespaceCronJobs = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/cronjobs[\/]?$`)
+	reCronJobSuspend    = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/cronjobs[\/]([-a-z0-9]+)\/(suspend|resume)[\/]?$`)
+	reCronJobRun        = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/cronjobs[\/]([-a-z0-9]+)\/run[\/]?$`)
+
+	reNodes            = regexp.MustCompile(`^\/nodes[\/]?$`)
+	reNodeOne          = regexp.MustCompile(`^\/nodes\/([-a-z0-9.]+)[\/]?$`)
+	reNodeCordon       = regexp.MustCompile(`^\/nodes\/([-a-z0-9.]+)\/(cordon|uncordon)[\/]?$`)
+	reNodeDrainPreview = regexp.MustCompile(`^\/nodes\/([-a-z0-9.]+)\/drain-preview[\/]?$`)
+
+	reDeploymentEvents = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/deployments[\/]([-a-z0-9]+)\/events[\/]?$`)
+	reNamespaceQuota   = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/quota[\/]?$`)
+
+	reNamespaceIngresses = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/ingresses[\/]?$`)
+	reDeploymentHosts    = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/deployments[\/]([-a-z0-9]+)\/hosts[\/]?$`)
+	reDeploymentLogs     = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/deployments[\/]([-a-z0-9]+)\/logs[\/]?$`)
+	reDeploymentPodEvict = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/deployments[\/]([-a-z0-9]+)\/pods\/([-a-z0-9.]+)\/evict[\/]?$`)
+
+	reResourceOneReplicas = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/([a-z0-9][-a-z0-9.]*)\/([-a-z0-9.]+)\/replica_count[\/]?$`)
+	reResourceSetReplicas = regexp.MustCompile(`^\/namespaces\/([-a-z0-9]+)\/([a-z0-9][-a-z0-9.]*)\/([-a-z0-9.]+)\/replica_count\/(\d+)[\/]?$`)
+
+	// reInformerReads are the endpoints served from auxiliary informers
+	// rather than the cache.  Watchers serve them; role api does not.
+	reInformerReads = []*regexp.Regexp{reDeploymentPods, reNamespaceHPAs, reDeploymentHPA, reDeploymentServices,
+		reNamespaceServices, reNamespaceJobs, reNamespaceCronJobs, reNodes, reNodeOne, reNodeDrainPreview,
+		reDeploymentEvents, reNamespaceQuota, reNamespaceIngresses, reDeploymentHosts,
+		reDeploymentLogs}
+
+	// informerEndpoints names the informers each endpoint reads.  When one
+	// of them was skipped for want of list and watch permission the
+	// endpoint answers 403.  The ANY lists leave such namespaces out.
+	informerEndpoints = []struct {
+		re        *regexp.Regexp
+		resources []string
+	}{
+		{reNamespaceOneDeployments, []string{"deployments"}},
+		{reDeploymentOneReplicas, []string{"deployments"}},
+		{reDeploymentAllReplicas, []string{"deployments"}},
+		{reDeploymentSetReplicas, []string{"deployments"}},
+		{reNamespaceOneStatefulSets, []string{"statefulsets"}},
+		{reStatefulSetOneReplicas, []string{"statefulsets"}},
+		{reStatefulSetAllReplicas, []string{"statefulsets"}},
+		{reStatefulSetSetReplicas, []string{"statefulsets"}},
+		{reNamespaceOneDaemonSets, []string{"daemonsets"}},
+		{reDaemonSetOne, []string{"daemonsets"}},
+		{reDeploymentPods, []string{"deployments", "replicasets", "pods"}},
+		{reDeploymentLogs, []string{"deployments", "replicasets", "pods"}},
+		{reDeploymentEvents, []string{"deployments", "replicasets", "pods", "events"}},
+		{reNamespaceHPAs, []string{"hpas"}},
+		{reDeploymentHPA, []string{"hpas"}},
+		{reNamespaceServices, []string{"services", "endpointslices"}},
+		{reDeploymentServices, []string{"deployments", "services", "endpointslices"}},
+		{reNamespaceJobs, []string{"jobs"}},
+		{reNamespaceCronJobs, []string{"cronjobs"}},
+		{reNamespaceQuota, []string{"resourcequotas", "limitranges"}},
+		{reNamespaceIngresses, []string{"ingresses", "services", "deployments"}},
+		{reDeploymentHosts, []string{"ingresses", "services", "deployments"}},
+		{reNodes, []string{"nodes"}},
+		{reNodeOne, []string{"nodes"}},
+		{reNodeDrainPreview, []string{"nodes"}},
+	}
 )
 
 type handler struct {
 	// needed solely to contain the serveHTTP method
 }
+type NamespaceList struct {
+	Namespaces  StringList `json:"namespaces"`
+	Terminating StringList `json:"terminating,omitempty"`
+	*ListPage
+}
+type NamespaceDetail struct {
+	Namespace       string            `json:"namespace"`
+	Labels          map[string]string `json:"labels"`
+	Annotations     map[string]string `json:"annotations"`
+	Phase           string            `json:"phase"`
+	CreationTime    *time.Time        `json:"creation_time,omitempty"`
+	DeploymentCount int               `json:"deployment_count"`
+	Replicas        int               `json:"replica_count"`
+	DeleteToken     string            `json:"delete_token,omitempty"`
+}
 type NamespaceDeployments struct {
 	Namespace   string   `json:"namespace"`
 	Deployments []string `json:"deployments"`
+	*ListPage
 }
 type NamespaceDeploymentReplica struct {
 	Namespace  string `json:"namespace"`
@@ -36,11 +146,121 @@ type NamespaceDeploymentReplica struct {
 	Replicas   int    `json:"replica_count"`
 }
 
+// NamespaceResourceReplica is NamespaceDeploymentReplica for an object of
+// any scalable resource; Deployment holds the object's name.
+type NamespaceResourceReplica struct {
+	Resource string `json:"resource"`
+	NamespaceDeploymentReplica
+}
+type NamespaceStatefulSets struct {
+	Namespace    string   `json:"namespace"`
+	StatefulSets []string `json:"statefulsets"`
+	*ListPage
+}
+type NamespaceStatefulSetReplica struct {
+	Namespace       string `json:"namespace"`
+	StatefulSet     string `json:"statefulset"`
+	Replicas        int    `json:"replica_count"`
+	ReadyReplicas   int    `json:"ready_replicas"`
+	CurrentRevision string `json:"current_revision,omitempty"`
+	UpdateRevision  string `json:"update_revision,omitempty"`
+}
+type NamespaceDaemonSet struct {
+	Namespace    string `json:"namespace"`
+	DaemonSet    string `json:"daemonset"`
+	Desired      int    `json:"desired"`
+	Current      int    `json:"current"`
+	Ready        int    `json:"ready"`
+	Updated      int    `json:"updated"`
+	Misscheduled int    `json:"misscheduled"`
+}
+type NamespaceHPAs struct {
+	Namespace string    `json:"namespace"`
+	HPAs      []HPAItem `json:"hpas"`
+}
+type NamespaceDeploymentHPA struct {
+	Namespace  string `json:"namespace"`
+	Deployment string `json:"deployment"`
+	Replicas   int    `json:"replica_count"`
+	HPAItem
+}
+type NamespaceServices struct {
+	Namespace string        `json:"namespace"`
+	Services  []ServiceItem `json:"services"`
+}
+type NamespaceDeploymentServices struct {
+	Namespace  string        `json:"namespace"`
+	Deployment string        `json:"deployment"`
+	Services   []ServiceItem `json:"services"`
+}
+type NamespaceJobs struct {
+	Namespace string    `json:"namespace"`
+	Jobs      []JobItem `json:"jobs"`
+}
+type NamespaceCronJobs struct {
+	Namespace string        `json:"namespace"`
+	CronJobs  []CronJobItem `json:"cronjobs"`
+}
+type NamespaceCronJob struct {
+	Namespace string `json:"namespace"`
+	CronJobItem
+}
+type NamespaceCronJobRun struct {
+	Namespace string `json:"namespace"`
+	CronJob   string `json:"cronjob"`
+	Job       string `json:"job"`
+}
+type NamespaceDeploymentEvents struct {
+	Namespace  string      `json:"namespace"`
+	Deployment string      `json:"deployment"`
+	Events     []EventItem `json:"events"`
+}
+type NamespaceQuotas struct {
+	Namespace string      `json:"namespace"`
+	Quotas    []QuotaItem `json:"quotas"`
+}
+type NamespaceIngresses struct {
+	Namespace string        `json:"namespace"`
+	Ingresses []IngressItem `json:"ingresses"`
+}
+type NamespaceDeploymentHosts struct {
+	Namespace  string               `json:"namespace"`
+	Deployment string               `json:"deployment"`
+	Hosts      []DeploymentHostItem `json:"hosts"`
+}
+type NamespaceDeploymentPodEvicted struct {
+	Namespace  string `json:"namespace"`
+	Deployment string `json:"deployment"`
+	Pod        string `json:"pod"`
+	Action     string `json:"action"`
+}
+type Nodes struct {
+	Nodes []NodeItem `json:"nodes"`
+}
+type NodeCordon struct {
+	Node          string `json:"node"`
+	Unschedulable bool   `json:"unschedulable"`
+}
+type NamespaceDeploymentPods struct {
+	Namespace  string    `json:"namespace"`
+	Deployment string    `json:"deployment"`
+	Pods       []PodItem `json:"pods"`
+}
+
 func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	self := "ServeHTTP"
 	klog.Infof("%s: entry", self)
 	klog.Infof("%s: r.URL.Path=%q", self, r.URL.Path)
 	w.Header().Set("content-type", "application/json")
+	ready, stale := CacheStateGet()
+	if stale {
+		w.Header().Set("X-Cache-Stale", "true")
+	}
+	if HttpSavedApp.Role != RoleAPI {
+		epoch, latest := ChangeJournal.Latest()
+		w.Header().Set("X-Change-Epoch", epoch)
+		w.Header().Set("X-Change-Seq", strconv.FormatUint(latest, 10))
+	}
 	switch {
 	case r.Method == http.MethodGet && reLivez.MatchString(r.URL.Path):
 		serveLiveness(w, r)
@@ -48,9 +268,43 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	case r.Method == http.MethodGet && reReadyz.MatchString(r.URL.Path):
 		serveReadiness(w, r)
 		return
+	case HttpSavedApp.Role == RoleWatcher && !reMetrics.MatchString(r.URL.Path) && !reDebugDrift.MatchString(r.URL.Path) &&
+		!reDebugCache.MatchString(r.URL.Path) && !reChanges.MatchString(r.URL.Path) &&
+		!(r.Method == http.MethodGet && matchesAny(reInformerReads, r.URL.Path)):
+		respondWithNotFound(w, r, "not served in role watcher", r.URL.Path)
+		return
+	case HttpSavedApp.Role == RoleAPI && (reChanges.MatchString(r.URL.Path) || matchesAny(reInformerReads, r.URL.Path)):
+		respondWithNotFound(w, r, "not served in role api", r.URL.Path)
+		return
+	case r.Method == http.MethodGet && reDebugDrift.MatchString(r.URL.Path):
+		serveDebugDrift(w, r)
+		return
+	case r.Method == http.MethodGet && reDebugCache.MatchString(r.URL.Path):
+		serveDebugCache(w, r)
+		return
+	case !ready && !reMetrics.MatchString(r.URL.Path):
+		respondWithServiceUnavailable(w, r, "cache not yet synced", r.URL.Path)
+		return
+	case HttpSavedApp.Role != RoleAPI && requestInformerSkipped(r) != "":
+		respondWithForbidden(w, r, "list/watch of "+requestInformerSkipped(r)+" not permitted", r.URL.Path)
+		return
+	case requestIsWrite(r) && requestNamespaceTerminating(r):
+		nsName := reNamespaceScoped.FindStringSubmatch(r.URL.Path)[1]
+		respondWithConflict(w, r, "namespace is terminating", nsName,
+			fmt.Errorf("namespace %q is being deleted; writes into it are refused", nsName))
+		return
 	case r.Method == http.MethodGet && reNamespaces.MatchString(r.URL.Path):
 		serveNamespacesGet(w, r)
 		return
+	case r.Method == http.MethodGet && reNamespaceOne.MatchString(r.URL.Path):
+		serveNamespaceGet(w, r)
+		return
+	case r.Method == http.MethodPost && reNamespaces.MatchString(r.URL.Path):
+		serveNamespaceCreate(w, r)
+		return
+	case r.Method == http.MethodDelete && reNamespaceOne.MatchString(r.URL.Path):
+		serveNamespaceDelete(w, r)
+		return
 	case r.Method == http.MethodGet && reNamespaceOneDeployments.MatchString(r.URL.Path):
 		serveDeploymentsGet(w, r)
 		return
@@ -66,12 +320,158 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	case (r.Method == http.MethodGet || r.Method == http.MethodPut) && reDeploymentSetReplicas.MatchString(r.URL.Path):
 		serveReplicasSet(w, r)
 		return
+	case r.Method == http.MethodGet && reNamespaceOneStatefulSets.MatchString(r.URL.Path):
+		serveStatefulSetsGet(w, r)
+		return
+	case r.Method == http.MethodGet && reNamespaceAllStatefulSets.MatchString(r.URL.Path):
+		serveStatefulSetsAllGet(w, r)
+		return
+	case r.Method == http.MethodGet && reStatefulSetOneReplicas.MatchString(r.URL.Path):
+		serveStatefulSetReplicasGet(w, r)
+		return
+	case r.Method == http.MethodGet && reStatefulSetAllReplicas.MatchString(r.URL.Path):
+		serveStatefulSetReplicasAllGet(w, r)
+		return
+	case (r.Method == http.MethodGet || r.Method == http.MethodPut) && reStatefulSetSetReplicas.MatchString(r.URL.Path):
+		serveStatefulSetReplicasSet(w, r)
+		return
+	case r.Method == http.MethodGet && reNamespaceOneDaemonSets.MatchString(r.URL.Path):
+		serveDaemonSetsGet(w, r)
+		return
+	case r.Method == http.MethodGet && reNamespaceAllDaemonSets.MatchString(r.URL.Path):
+		serveDaemonSetsAllGet(w, r)
+		return
+	case r.Method == http.MethodGet && reDaemonSetOne.MatchString(r.URL.Path):
+		serveDaemonSetGet(w, r)
+		return
+	case r.Method == http.MethodGet && reDeploymentPods.MatchString(r.URL.Path):
+		serveDeploymentPodsGet(w, r)
+		return
+	case r.Method == http.MethodGet && reNamespaceHPAs.MatchString(r.URL.Path):
+		serveHPAsGet(w, r)
+		return
+	case r.Method == http.MethodGet && reDeploymentHPA.MatchString(r.URL.Path):
+		serveDeploymentHPAGet(w, r)
+		return
+	case r.Method == http.MethodPatch && reDeploymentHPA.MatchString(r.URL.Path):
+		serveDeploymentHPASet(w, r)
+		return
+	case r.Method == http.MethodGet && reDeploymentServices.MatchString(r.URL.Path):
+		serveDeploymentServicesGet(w, r)
+		return
+	case r.Method == http.MethodGet && reNamespaceServices.MatchString(r.URL.Path):
+		serveServicesGet(w, r)
+		return
+	case r.Method == http.MethodGet && reNamespaceJobs.MatchString(r.URL.Path):
+		serveJobsGet(w, r)
+		return
+	case r.Method == http.MethodGet && reNamespaceCronJobs.MatchString(r.URL.Path):
+		serveCronJobsGet(w, r)
+		return
+	case r.Method == http.MethodPost && reCronJobSuspend.MatchString(r.URL.Path):
+		serveCronJobSuspendSet(w, r)
+		return
+	case r.Method == http.MethodPost && reCronJobRun.MatchString(r.URL.Path):
+		serveCronJobRun(w, r)
+		return
+	case r.Method == http.MethodGet && reNodes.MatchString(r.URL.Path):
+		serveNodesGet(w, r)
+		return
+	case r.Method == http.MethodGet && reNodeOne.MatchString(r.URL.Path):
+		serveNodeGet(w, r)
+		return
+	case r.Method == http.MethodPost && reNodeCordon.MatchString(r.URL.Path):
+		serveNodeCordonSet(w, r)
+		return
+	case r.Method == http.MethodGet && reNodeDrainPreview.MatchString(r.URL.Path):
+		serveNodeDrainPreviewGet(w, r)
+		return
+	case r.Method == http.MethodGet && reDeploymentEvents.MatchString(r.URL.Path):
+		serveDeploymentEventsGet(w, r)
+		return
+	case r.Method == http.MethodGet && reNamespaceQuota.MatchString(r.URL.Path):
+		serveQuotaGet(w, r)
+		return
+	case r.Method == http.MethodGet && reNamespaceIngresses.MatchString(r.URL.Path):
+		serveIngressesGet(w, r)
+		return
+	case r.Method == http.MethodGet && reDeploymentHosts.MatchString(r.URL.Path):
+		serveDeploymentHostsGet(w, r)
+		return
+	case r.Method == http.MethodGet && reDeploymentLogs.MatchString(r.URL.Path):
+		serveDeploymentLogsGet(w, r)
+		return
+	case r.Method == http.MethodPost && reDeploymentPodEvict.MatchString(r.URL.Path):
+		servePodEvict(w, r)
+		return
+	case r.Method == http.MethodGet && reResourceOneReplicas.MatchString(r.URL.Path):
+		serveResourceReplicasGet(w, r)
+		return
+	case r.Method == http.MethodPut && reResourceSetReplicas.MatchString(r.URL.Path):
+		serveResourceReplicasSet(w, r)
+		return
+	case r.Method == http.MethodGet && reMetrics.MatchString(r.URL.Path):
+		serveMetrics(w, r)
+		return
+	case r.Method == http.MethodGet && reChanges.MatchString(r.URL.Path):
+		serveChangesGet(w, r)
+		return
 	default:
 		respondWithNotFound(w, r, "unknown url path", r.URL.Path)
 		return
 	}
 }
 
+// requestIsWrite reports whether r changes anything.  Endpoints 4 and 13
+// also set replica counts on GET.
+func requestIsWrite(r *http.Request) bool {
+	return r.Method != http.MethodGet ||
+		reDeploymentSetReplicas.MatchString(r.URL.Path) || reStatefulSetSetReplicas.MatchString(r.URL.Path)
+}
+
+// requestNamespaceTerminating reports whether r is about something in a
+// namespace that is cached as Terminating.
+func requestNamespaceTerminating(r *http.Request) bool {
+	matches := reNamespaceScoped.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 2 {
+		return false
+	}
+	return NamespaceCachedTerminating(false, matches[1])
+}
+
+// requestInformerSkipped returns a resource whose informer r would read
+// but that was skipped, or "" when there is none.
+func requestInformerSkipped(r *http.Request) string {
+	if InformersSavedApp == nil {
+		return ""
+	}
+	nsName := metav1.NamespaceAll
+	if match := reNamespaceScoped.FindStringSubmatch(r.URL.Path); match != nil {
+		nsName = match[1]
+	}
+	for _, endpoint := range informerEndpoints {
+		if !endpoint.re.MatchString(r.URL.Path) {
+			continue
+		}
+		for _, resource := range endpoint.resources {
+			if InformerSkipped(InformersSavedApp, resource, nsName) {
+				return resource
+			}
+		}
+		return ""
+	}
+	return ""
+}
+
+func matchesAny(res []*regexp.Regexp, path string) bool {
+	for _, re := range res {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
 func respondWithNotFound(w http.ResponseWriter, r *http.Request, msg string, elt string) {
 	self := "respondWithNotFound"
 	klog.Infof("%s: entry", self)
@@ -85,6 +485,97 @@ func respondWithNotFound(w http.ResponseWriter, r *http.Request, msg string, elt
 	w.Write(jsonResp)
 }
 
+func respondWithForbidden(w http.ResponseWriter, r *http.Request, msg string, elt string) {
+	self := "respondWithForbidden"
+	klog.Infof("%s: entry", self)
+	resp := make(map[string]string)
+	resp["message"], resp["element"] = msg, elt
+	jsonResp, err := json.Marshal(resp)
+	if err != nil {
+		klog.Fatalf("call to json.Marshal() failed: %#v", err)
+	}
+	w.WriteHeader(http.StatusForbidden)
+	w.Write(jsonResp)
+}
+
+func respondWithBadRequest(w http.ResponseWriter, r *http.Request, msg string, elt string, err error) {
+	self := "respondWithBadRequest"
+	klog.Infof("%s: entry", self)
+	resp := make(map[string]string)
+	resp["message"], resp["element"], resp["error"] = msg, elt, err.Error()
+	jsonResp, err := json.Marshal(resp)
+	if err != nil {
+		klog.Fatalf("call to json.Marshal() failed: %#v", err)
+	}
+	w.WriteHeader(http.StatusBadRequest)
+	w.Write(jsonResp)
+}
+
+func respondWithConflict(w http.ResponseWriter, r *http.Request, msg string, elt string, err error) {
+	self := "respondWithConflict"
+	klog.Infof("%s: entry", self)
+	resp := make(map[string]string)
+	resp["message"], resp["element"], resp["error"] = msg, elt, err.Error()
+	jsonResp, err := json.Marshal(resp)
+	if err != nil {
+		klog.Fatalf("call to json.Marshal() failed: %#v", err)
+	}
+	w.WriteHeader(http.StatusConflict)
+	w.Write(jsonResp)
+}
+
+func respondWithPreconditionFailed(w http.ResponseWriter, r *http.Request, msg string, elt string, err error) {
+	self := "respondWithPreconditionFailed"
+	klog.Infof("%s: entry", self)
+	resp := make(map[string]string)
+	resp["message"], resp["element"], resp["error"] = msg, elt, err.Error()
+	jsonResp, err := json.Marshal(resp)
+	if err != nil {
+		klog.Fatalf("call to json.Marshal() failed: %#v", err)
+	}
+	w.WriteHeader(http.StatusPreconditionFailed)
+	w.Write(jsonResp)
+}
+
+func respondWithTooManyRequests(w http.ResponseWriter, r *http.Request, msg string, elt string) {
+	self := "respondWithTooManyRequests"
+	klog.Infof("%s: entry", self)
+	resp := make(map[string]string)
+	resp["message"], resp["element"] = msg, elt
+	jsonResp, err := json.Marshal(resp)
+	if err != nil {
+		klog.Fatalf("call to json.Marshal() failed: %#v", err)
+	}
+	w.WriteHeader(http.StatusTooManyRequests)
+	w.Write(jsonResp)
+}
+
+func respondWithServiceUnavailable(w http.ResponseWriter, r *http.Request, msg string, elt string) {
+	self := "respondWithServiceUnavailable"
+	klog.Infof("%s: entry", self)
+	resp := make(map[string]string)
+	resp["message"], resp["element"] = msg, elt
+	jsonResp, err := json.Marshal(resp)
+	if err != nil {
+		klog.Fatalf("call to json.Marshal() failed: %#v", err)
+	}
+	w.WriteHeader(http.StatusServiceUnavailable)
+	w.Write(jsonResp)
+}
+
+func respondWithGone(w http.ResponseWriter, r *http.Request, msg string, elt string) {
+	self := "respondWithGone"
+	klog.Infof("%s: entry", self)
+	resp := make(map[string]string)
+	resp["message"], resp["element"] = msg, elt
+	jsonResp, err := json.Marshal(resp)
+	if err != nil {
+		klog.Fatalf("call to json.Marshal() failed: %#v", err)
+	}
+	w.WriteHeader(http.StatusGone)
+	w.Write(jsonResp)
+}
+
 func respondWithInternalServerError(w http.ResponseWriter, r *http.Request, msg string, elt string, err error) {
 	self := "respondWithInternalServerError"
 	klog.Infof("%s: entry", self)
@@ -105,12 +596,27 @@ func respondWithInternalServerError(w http.ResponseWriter, r *http.Request, msg
 func serveNamespacesGet(w http.ResponseWriter, r *http.Request) {
 	self := "serveNamespacesGet"
 	klog.Infof("%s: entry", self)
-	namespaces := StringList(NamespaceCachedListGet(false))
+	filter, err := listFilterFromRequest(r, namespaceFields)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid selector", r.URL.RawQuery, err)
+		return
+	}
+	opts, err := listOptionsFromRequest(r)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid list options", r.URL.RawQuery, err)
+		return
+	}
+	namespaces, terminating, listPage, err := NamespaceCachedListGet(false, filter, opts)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "NamespaceCachedListGet", err)
+		return
+	}
 	klog.Infof("%s: namespaces=%#v", self, namespaces)
-	namespacesMap := make(MapStringList)
-	namespacesMap["namespaces"] = namespaces
+	namespaceList := NamespaceList{Namespaces: StringList(namespaces), Terminating: StringList(terminating),
+		ListPage: &listPage}
+	setListPageHeaders(w, listPage)
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(namespacesMap)
+	json.NewEncoder(w).Encode(namespaceList)
 }
 
 // Endpoint #2
@@ -124,11 +630,25 @@ func serveDeploymentsGet(w http.ResponseWriter, r *http.Request) {
 	}
 	nsName := matches[1]
 	klog.Infof("%s: nsName=%q", self, nsName)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
 	if !NamespaceCachedExists(false, nsName) {
 		respondWithNotFound(w, r, "namespace not found", nsName)
 		return
 	}
-	deploymentList, err := DeploymentCachedListGet(false, nsName)
+	filter, err := listFilterFromRequest(r, workloadFields)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid selector", r.URL.RawQuery, err)
+		return
+	}
+	opts, err := listOptionsFromRequest(r)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid list options", r.URL.RawQuery, err)
+		return
+	}
+	deploymentList, listPage, err := DeploymentCachedListGet(false, nsName, filter, opts)
 	if err != nil {
 		respondWithInternalServerError(w, r, "", "DeploymentCachedListGet", err)
 		return
@@ -138,7 +658,8 @@ func serveDeploymentsGet(w http.ResponseWriter, r *http.Request) {
 	for _, deployment := range deploymentList {
 		stringList = append(stringList, deployment.Name)
 	}
-	namespaceDeployments := NamespaceDeployments{Namespace: nsName, Deployments: stringList}
+	namespaceDeployments := NamespaceDeployments{Namespace: nsName, Deployments: stringList, ListPage: &listPage}
+	setListPageHeaders(w, listPage)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(namespaceDeployments)
 }
@@ -147,7 +668,17 @@ func serveDeploymentsGet(w http.ResponseWriter, r *http.Request) {
 func serveDeploymentsAllGet(w http.ResponseWriter, r *http.Request) {
 	self := "serveDeploymentsAllGet"
 	klog.Infof("%s: entry", self)
-	namespaceList, err := DeploymentCachedListAllGet(false)
+	filter, err := listFilterFromRequest(r, workloadFields)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid selector", r.URL.RawQuery, err)
+		return
+	}
+	opts, err := listOptionsFromRequest(r)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid list options", r.URL.RawQuery, err)
+		return
+	}
+	namespaceList, listPage, err := DeploymentCachedListAllGet(false, filter, opts)
 	if err != nil {
 		respondWithInternalServerError(w, r, "", "DeploymentCachedListAllGet", err)
 		return
@@ -164,8 +695,7 @@ func serveDeploymentsAllGet(w http.ResponseWriter, r *http.Request) {
 				NamespaceDeployments{Namespace: namespace.Name, Deployments: stringList})
 		}
 	}
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(namespaceDeployments)
+	respondWithList(w, opts, namespaceDeployments, listPage)
 }
 
 // Endpoint #3
@@ -179,6 +709,10 @@ func serveReplicasGet(w http.ResponseWriter, r *http.Request) {
 	}
 	nsName, dName := matches[1], matches[2]
 	klog.Infof("%s: nsName=%q;  dName=%q", self, nsName, dName)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
 	if !NamespaceCachedExists(false, nsName) {
 		respondWithNotFound(w, r, "namespace not found", nsName)
 		return
@@ -208,16 +742,31 @@ func serveReplicasAllGet(w http.ResponseWriter, r *http.Request) {
 	}
 	nsName := matches[1]
 	klog.Infof("%s: nsName=%q", self, nsName)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
 	if !NamespaceCachedExists(false, nsName) {
 		respondWithNotFound(w, r, "namespace not found", nsName)
 		return
 	}
-	deploymentList, err := ReplicasCachedListAllGet(false, nsName)
+	filter, err := listFilterFromRequest(r, workloadFields)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid selector", r.URL.RawQuery, err)
+		return
+	}
+	opts, err := listOptionsFromRequest(r)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid list options", r.URL.RawQuery, err)
+		return
+	}
+	deploymentList, listPage, err := ReplicasCachedListAllGet(false, nsName, filter, opts)
 	if err != nil {
 		respondWithInternalServerError(w, r, "", "ReplicasCachedListAllGet", err)
 		return
 	}
-	namespaceListItem := NamespaceListItem{Name: nsName, Deployments: deploymentList}
+	namespaceListItem := NamespaceListItem{Name: nsName, Deployments: deploymentList, ListPage: &listPage}
+	setListPageHeaders(w, listPage)
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(namespaceListItem)
 }
@@ -233,6 +782,10 @@ func serveReplicasSet(w http.ResponseWriter, r *http.Request) {
 	}
 	nsName, dName, replicas := matches[1], matches[2], matches[3]
 	klog.Infof("%s: nsName=%q;  dName=%q;  replicas=%v", self, nsName, dName, replicas)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
 	if !NamespaceCachedExists(false, nsName) {
 		respondWithNotFound(w, r, "namespace not found", nsName)
 		return
@@ -241,8 +794,27 @@ func serveReplicasSet(w http.ResponseWriter, r *http.Request) {
 		respondWithNotFound(w, r, "deployment not found", fmt.Sprintf("%s/%s", nsName, dName))
 		return
 	}
+	force, err := forceFromRequest(r)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid query", r.URL.RawQuery, err)
+		return
+	}
+	if force && !RequestIsAdmin(r) {
+		respondWithForbidden(w, r, "admin permission required for force", r.URL.Path)
+		return
+	}
 	replicasI, _ := strconv.Atoi(replicas)
-	err := ReplicasSet(HttpSavedApp, nsName, dName, replicasI)
+	err = ReplicasSet(HttpSavedApp, nsName, dName, replicasI, force)
+	var violation *PDBViolation
+	if errors.As(err, &violation) {
+		respondWithConflict(w, r, "scale-down blocked by PodDisruptionBudget", violation.PDB, err)
+		return
+	}
+	var quotaViolation *QuotaViolation
+	if errors.As(err, &quotaViolation) {
+		respondWithConflict(w, r, "scale-up exceeds ResourceQuota", quotaViolation.Quota, err)
+		return
+	}
 	if err != nil {
 		respondWithInternalServerError(w, r, "", "replicasSet", err)
 		return
@@ -250,6 +822,19 @@ func serveReplicasSet(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+// forceFromRequest reads the force query parameter, false when absent.
+func forceFromRequest(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("force")
+	if value == "" {
+		return false, nil
+	}
+	force, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid force value: %q", value)
+	}
+	return force, nil
+}
+
 // Endpoint #5
 func serveLiveness(w http.ResponseWriter, r *http.Request) {
 	self := "serveLiveness"
@@ -261,7 +846,1341 @@ func serveLiveness(w http.ResponseWriter, r *http.Request) {
 func serveReadiness(w http.ResponseWriter, r *http.Request) {
 	self := "serveReadiness"
 	klog.Infof("%s: entry", self)
+	if ready, _ := CacheStateGet(); !ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Endpoint #7
+func serveMetrics(w http.ResponseWriter, r *http.Request) {
+	self := "serveMetrics"
+	klog.Infof("%s: entry", self)
+	legacyregistry.Handler().ServeHTTP(w, r)
+}
+
+// Endpoint #8
+func serveDebugDrift(w http.ResponseWriter, r *http.Request) {
+	self := "serveDebugDrift"
+	klog.Infof("%s: entry", self)
+	if !RequestIsAdmin(r) {
+		respondWithForbidden(w, r, "admin permission required", r.URL.Path)
+		return
+	}
+	report := DriftReportGet()
+	if report == nil {
+		respondWithNotFound(w, r, "no drift check has completed yet", r.URL.Path)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+// Endpoint #9
+func serveDebugCache(w http.ResponseWriter, r *http.Request) {
+	self := "serveDebugCache"
+	klog.Infof("%s: entry", self)
+	if !RequestIsAdmin(r) {
+		respondWithForbidden(w, r, "admin permission required", r.URL.Path)
+		return
+	}
+	report, err := CacheIntrospect(HttpSavedApp)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "CacheIntrospect", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(report)
+}
+
+// Endpoint #10
+func serveChangesGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveChangesGet"
+	klog.Infof("%s: entry", self)
+	since, limit, epoch, err := changeListFromRequest(r)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid change list options", r.URL.RawQuery, err)
+		return
+	}
+	changeList, ok := ChangeJournal.Since(since, limit)
+	if epoch != "" && epoch != changeList.Epoch {
+		respondWithGone(w, r, "journal restarted; relist", changeList.Epoch)
+		return
+	}
+	if !ok {
+		respondWithGone(w, r, "changes since this seq no longer held; relist", strconv.FormatUint(since, 10))
+		return
+	}
 	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(changeList)
+}
+
+// Endpoint #11
+func serveStatefulSetsGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveStatefulSetsGet"
+	klog.Infof("%s: entry", self)
+	matches := reNamespaceOneStatefulSets.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 2 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName := matches[1]
+	klog.Infof("%s: nsName=%q", self, nsName)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	filter, err := listFilterFromRequest(r, workloadFields)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid selector", r.URL.RawQuery, err)
+		return
+	}
+	opts, err := listOptionsFromRequest(r)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid list options", r.URL.RawQuery, err)
+		return
+	}
+	statefulSetList, listPage, err := StatefulSetCachedListGet(false, nsName, filter, opts)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "StatefulSetCachedListGet", err)
+		return
+	}
+	klog.Infof("%s: statefulSetList=%#v", self, statefulSetList)
+	stringList := []string{}
+	for _, statefulSet := range statefulSetList {
+		stringList = append(stringList, statefulSet.Name)
+	}
+	namespaceStatefulSets := NamespaceStatefulSets{Namespace: nsName, StatefulSets: stringList, ListPage: &listPage}
+	setListPageHeaders(w, listPage)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(namespaceStatefulSets)
+}
+
+// Endpoint #11A
+func serveStatefulSetsAllGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveStatefulSetsAllGet"
+	klog.Infof("%s: entry", self)
+	filter, err := listFilterFromRequest(r, workloadFields)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid selector", r.URL.RawQuery, err)
+		return
+	}
+	opts, err := listOptionsFromRequest(r)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid list options", r.URL.RawQuery, err)
+		return
+	}
+	namespaceList, listPage, err := StatefulSetCachedListAllGet(false, filter, opts)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "StatefulSetCachedListAllGet", err)
+		return
+	}
+	klog.Infof("%s: namespaceList=%#v", self, namespaceList)
+	namespaceStatefulSets := []NamespaceStatefulSets{}
+	for _, namespace := range namespaceList {
+		stringList := []string{}
+		for _, statefulSet := range namespace.StatefulSets {
+			stringList = append(stringList, statefulSet.Name)
+		}
+		namespaceStatefulSets = append(namespaceStatefulSets,
+			NamespaceStatefulSets{Namespace: namespace.Name, StatefulSets: stringList})
+	}
+	respondWithList(w, opts, namespaceStatefulSets, listPage)
+}
+
+// Endpoint #12
+func serveStatefulSetReplicasGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveStatefulSetReplicasGet"
+	klog.Infof("%s: entry", self)
+	matches := reStatefulSetOneReplicas.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 3 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName, sName := matches[1], matches[2]
+	klog.Infof("%s: nsName=%q;  sName=%q", self, nsName, sName)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	if !StatefulSetCachedExists(false, nsName, sName) {
+		respondWithNotFound(w, r, "statefulset not found", fmt.Sprintf("%s/%s", nsName, sName))
+		return
+	}
+	statefulSet, err := StatefulSetReplicasCachedGet(false, nsName, sName)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "StatefulSetReplicasCachedGet", err)
+		return
+	}
+	namespaceStatefulSetReplica := NamespaceStatefulSetReplica{Namespace: nsName, StatefulSet: sName,
+		Replicas: statefulSet.Replicas, ReadyReplicas: statefulSet.ReadyReplicas,
+		CurrentRevision: statefulSet.CurrentRevision, UpdateRevision: statefulSet.UpdateRevision}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(namespaceStatefulSetReplica)
+}
+
+// Endpoint #12A
+func serveStatefulSetReplicasAllGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveStatefulSetReplicasAllGet"
+	klog.Infof("%s: entry", self)
+	matches := reStatefulSetAllReplicas.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 2 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName := matches[1]
+	klog.Infof("%s: nsName=%q", self, nsName)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	filter, err := listFilterFromRequest(r, workloadFields)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid selector", r.URL.RawQuery, err)
+		return
+	}
+	opts, err := listOptionsFromRequest(r)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid list options", r.URL.RawQuery, err)
+		return
+	}
+	statefulSetList, listPage, err := StatefulSetCachedListGet(false, nsName, filter, opts)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "StatefulSetCachedListGet", err)
+		return
+	}
+	namespaceListItem := NamespaceStatefulSetListItem{Name: nsName, StatefulSets: statefulSetList, ListPage: &listPage}
+	setListPageHeaders(w, listPage)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(namespaceListItem)
+}
+
+// Endpoint #13
+func serveStatefulSetReplicasSet(w http.ResponseWriter, r *http.Request) {
+	self := "serveStatefulSetReplicasSet"
+	klog.Infof("%s: entry", self)
+	matches := reStatefulSetSetReplicas.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 4 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName, sName, replicas := matches[1], matches[2], matches[3]
+	klog.Infof("%s: nsName=%q;  sName=%q;  replicas=%v", self, nsName, sName, replicas)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	if !StatefulSetCachedExists(false, nsName, sName) {
+		respondWithNotFound(w, r, "statefulset not found", fmt.Sprintf("%s/%s", nsName, sName))
+		return
+	}
+	replicasI, _ := strconv.Atoi(replicas)
+	err := StatefulSetReplicasSet(HttpSavedApp, nsName, sName, replicasI)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "StatefulSetReplicasSet", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// Endpoint #14
+func serveDaemonSetsGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveDaemonSetsGet"
+	klog.Infof("%s: entry", self)
+	matches := reNamespaceOneDaemonSets.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 2 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName := matches[1]
+	klog.Infof("%s: nsName=%q", self, nsName)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	filter, err := listFilterFromRequest(r, workloadFields)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid selector", r.URL.RawQuery, err)
+		return
+	}
+	opts, err := listOptionsFromRequest(r)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid list options", r.URL.RawQuery, err)
+		return
+	}
+	daemonSetList, listPage, err := DaemonSetCachedListGet(false, nsName, filter, opts)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "DaemonSetCachedListGet", err)
+		return
+	}
+	namespaceListItem := NamespaceDaemonSetListItem{Name: nsName, DaemonSets: daemonSetList, ListPage: &listPage}
+	setListPageHeaders(w, listPage)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(namespaceListItem)
+}
+
+// Endpoint #14A
+func serveDaemonSetsAllGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveDaemonSetsAllGet"
+	klog.Infof("%s: entry", self)
+	filter, err := listFilterFromRequest(r, workloadFields)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid selector", r.URL.RawQuery, err)
+		return
+	}
+	opts, err := listOptionsFromRequest(r)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid list options", r.URL.RawQuery, err)
+		return
+	}
+	namespaceList, listPage, err := DaemonSetCachedListAllGet(false, filter, opts)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "DaemonSetCachedListAllGet", err)
+		return
+	}
+	klog.Infof("%s: namespaceList=%#v", self, namespaceList)
+	respondWithList(w, opts, namespaceList, listPage)
+}
+
+// Endpoint #15
+func serveDaemonSetGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveDaemonSetGet"
+	klog.Infof("%s: entry", self)
+	matches := reDaemonSetOne.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 3 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName, dsName := matches[1], matches[2]
+	klog.Infof("%s: nsName=%q;  dsName=%q", self, nsName, dsName)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	if !DaemonSetCachedExists(false, nsName, dsName) {
+		respondWithNotFound(w, r, "daemonset not found", fmt.Sprintf("%s/%s", nsName, dsName))
+		return
+	}
+	daemonSet, err := DaemonSetCachedGet(false, nsName, dsName)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "DaemonSetCachedGet", err)
+		return
+	}
+	namespaceDaemonSet := NamespaceDaemonSet{Namespace: nsName, DaemonSet: dsName,
+		Desired: daemonSet.Desired, Current: daemonSet.Current, Ready: daemonSet.Ready,
+		Updated: daemonSet.Updated, Misscheduled: daemonSet.Misscheduled}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(namespaceDaemonSet)
+}
+
+// Endpoint #16
+func serveDeploymentPodsGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveDeploymentPodsGet"
+	klog.Infof("%s: entry", self)
+	matches := reDeploymentPods.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 3 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName, dName := matches[1], matches[2]
+	klog.Infof("%s: nsName=%q;  dName=%q", self, nsName, dName)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	if !DeploymentCachedExists(false, nsName, dName) {
+		respondWithNotFound(w, r, "deployment not found", fmt.Sprintf("%s/%s", nsName, dName))
+		return
+	}
+	if InformersSavedApp == nil {
+		respondWithServiceUnavailable(w, r, "informers not yet synced", r.URL.Path)
+		return
+	}
+	podList, err := DeploymentPodsGet(nsName, dName)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "DeploymentPodsGet", err)
+		return
+	}
+	namespaceDeploymentPods := NamespaceDeploymentPods{Namespace: nsName, Deployment: dName, Pods: podList}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(namespaceDeploymentPods)
+}
+
+// Endpoint #17
+func serveHPAsGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveHPAsGet"
+	klog.Infof("%s: entry", self)
+	matches := reNamespaceHPAs.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 2 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName := matches[1]
+	klog.Infof("%s: nsName=%q", self, nsName)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	if InformersSavedApp == nil {
+		respondWithServiceUnavailable(w, r, "informers not yet synced", r.URL.Path)
+		return
+	}
+	hpaList, err := HPAListGet(nsName)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "HPAListGet", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NamespaceHPAs{Namespace: nsName, HPAs: hpaList})
+}
+
+// deploymentHPALookup resolves the deployment and HPA of endpoints #18 and
+// #19, responding itself when either is missing.
+func deploymentHPALookup(w http.ResponseWriter, r *http.Request) (string, string, *autoscalingv2.HorizontalPodAutoscaler, bool) {
+	matches := reDeploymentHPA.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 3 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return "", "", nil, false
+	}
+	nsName, dName := matches[1], matches[2]
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return "", "", nil, false
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return "", "", nil, false
+	}
+	if !DeploymentCachedExists(false, nsName, dName) {
+		respondWithNotFound(w, r, "deployment not found", fmt.Sprintf("%s/%s", nsName, dName))
+		return "", "", nil, false
+	}
+	if InformersSavedApp == nil {
+		respondWithServiceUnavailable(w, r, "informers not yet synced", r.URL.Path)
+		return "", "", nil, false
+	}
+	hpa, err := DeploymentHPAGet(nsName, dName)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "DeploymentHPAGet", err)
+		return "", "", nil, false
+	}
+	if hpa == nil {
+		respondWithNotFound(w, r, "hpa not found", fmt.Sprintf("%s/%s", nsName, dName))
+		return "", "", nil, false
+	}
+	return nsName, dName, hpa, true
+}
+
+// respondWithDeploymentHPA reports hpa with the deployment's spec replicas
+// as cached.
+func respondWithDeploymentHPA(w http.ResponseWriter, r *http.Request, nsName string, dName string,
+	hpa *autoscalingv2.HorizontalPodAutoscaler) {
+	deployment, err := ReplicasCachedListGet(false, nsName, dName)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "ReplicasCachedListGet", err)
+		return
+	}
+	namespaceDeploymentHPA := NamespaceDeploymentHPA{Namespace: nsName, Deployment: dName, Replicas: deployment.Replicas,
+		HPAItem: hpaItemFrom(hpa)}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(namespaceDeploymentHPA)
+}
+
+// Endpoint #18
+func serveDeploymentHPAGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveDeploymentHPAGet"
+	klog.Infof("%s: entry", self)
+	nsName, dName, hpa, ok := deploymentHPALookup(w, r)
+	if !ok {
+		return
+	}
+	klog.Infof("%s: nsName=%q;  dName=%q;  hpa=%q", self, nsName, dName, hpa.Name)
+	respondWithDeploymentHPA(w, r, nsName, dName, hpa)
+}
+
+// Endpoint #19
+func serveDeploymentHPASet(w http.ResponseWriter, r *http.Request) {
+	self := "serveDeploymentHPASet"
+	klog.Infof("%s: entry", self)
+	if !RequestIsAdmin(r) {
+		respondWithForbidden(w, r, "admin permission required", r.URL.Path)
+		return
+	}
+	nsName, dName, hpa, ok := deploymentHPALookup(w, r)
+	if !ok {
+		return
+	}
+	klog.Infof("%s: nsName=%q;  dName=%q;  hpa=%q", self, nsName, dName, hpa.Name)
+	patch, err := hpaPatchFromRequest(r.Body, hpaItemFrom(hpa))
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid hpa patch", hpa.Name, err)
+		return
+	}
+	hName := hpa.Name
+	hpa, err = HPASet(HttpSavedApp, nsName, hName, patch)
+	if apierrors.IsInvalid(err) {
+		respondWithBadRequest(w, r, "hpa patch rejected", hName, err)
+		return
+	}
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "HPASet", err)
+		return
+	}
+	respondWithDeploymentHPA(w, r, nsName, dName, hpa)
+}
+
+// Endpoint #20
+func serveDeploymentServicesGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveDeploymentServicesGet"
+	klog.Infof("%s: entry", self)
+	matches := reDeploymentServices.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 3 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName, dName := matches[1], matches[2]
+	klog.Infof("%s: nsName=%q;  dName=%q", self, nsName, dName)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	if !DeploymentCachedExists(false, nsName, dName) {
+		respondWithNotFound(w, r, "deployment not found", fmt.Sprintf("%s/%s", nsName, dName))
+		return
+	}
+	if InformersSavedApp == nil {
+		respondWithServiceUnavailable(w, r, "informers not yet synced", r.URL.Path)
+		return
+	}
+	serviceList, err := DeploymentServiceListGet(nsName, dName)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "DeploymentServiceListGet", err)
+		return
+	}
+	namespaceDeploymentServices := NamespaceDeploymentServices{Namespace: nsName, Deployment: dName, Services: serviceList}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(namespaceDeploymentServices)
+}
+
+// Endpoint #21
+func serveServicesGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveServicesGet"
+	klog.Infof("%s: entry", self)
+	matches := reNamespaceServices.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 2 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName := matches[1]
+	klog.Infof("%s: nsName=%q", self, nsName)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	if InformersSavedApp == nil {
+		respondWithServiceUnavailable(w, r, "informers not yet synced", r.URL.Path)
+		return
+	}
+	serviceList, err := ServiceListGet(nsName, nil)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "ServiceListGet", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NamespaceServices{Namespace: nsName, Services: serviceList})
+}
+
+// Endpoint #22
+func serveJobsGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveJobsGet"
+	klog.Infof("%s: entry", self)
+	matches := reNamespaceJobs.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 2 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName := matches[1]
+	klog.Infof("%s: nsName=%q", self, nsName)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	if InformersSavedApp == nil {
+		respondWithServiceUnavailable(w, r, "informers not yet synced", r.URL.Path)
+		return
+	}
+	jobList, err := JobListGet(nsName)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "JobListGet", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NamespaceJobs{Namespace: nsName, Jobs: jobList})
+}
+
+// Endpoint #23
+func serveCronJobsGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveCronJobsGet"
+	klog.Infof("%s: entry", self)
+	matches := reNamespaceCronJobs.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 2 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName := matches[1]
+	klog.Infof("%s: nsName=%q", self, nsName)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	if InformersSavedApp == nil {
+		respondWithServiceUnavailable(w, r, "informers not yet synced", r.URL.Path)
+		return
+	}
+	cronJobList, err := CronJobListGet(nsName)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "CronJobListGet", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NamespaceCronJobs{Namespace: nsName, CronJobs: cronJobList})
+}
+
+// Endpoint #24
+func serveCronJobSuspendSet(w http.ResponseWriter, r *http.Request) {
+	self := "serveCronJobSuspendSet"
+	klog.Infof("%s: entry", self)
+	if !RequestIsAdmin(r) {
+		respondWithForbidden(w, r, "admin permission required", r.URL.Path)
+		return
+	}
+	matches := reCronJobSuspend.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 4 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName, cName, action := matches[1], matches[2], matches[3]
+	klog.Infof("%s: nsName=%q;  cName=%q;  action=%q", self, nsName, cName, action)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	cronJob, err := CronJobSuspendSet(HttpSavedApp, nsName, cName, action == "suspend")
+	if apierrors.IsNotFound(err) {
+		respondWithNotFound(w, r, "cronjob not found", fmt.Sprintf("%s/%s", nsName, cName))
+		return
+	}
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "CronJobSuspendSet", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NamespaceCronJob{Namespace: nsName, CronJobItem: cronJobItemFrom(cronJob)})
+}
+
+// Endpoint #25
+func serveCronJobRun(w http.ResponseWriter, r *http.Request) {
+	self := "serveCronJobRun"
+	klog.Infof("%s: entry", self)
+	if !RequestIsAdmin(r) {
+		respondWithForbidden(w, r, "admin permission required", r.URL.Path)
+		return
+	}
+	matches := reCronJobRun.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 3 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName, cName := matches[1], matches[2]
+	klog.Infof("%s: nsName=%q;  cName=%q", self, nsName, cName)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	job, err := CronJobRun(HttpSavedApp, nsName, cName)
+	if apierrors.IsNotFound(err) {
+		respondWithNotFound(w, r, "cronjob not found", fmt.Sprintf("%s/%s", nsName, cName))
+		return
+	}
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "CronJobRun", err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(NamespaceCronJobRun{Namespace: nsName, CronJob: cName, Job: job.Name})
+}
+
+// nodesReadable answers for the node read endpoints when the node informer
+// can't serve them, and reports whether it can.
+func nodesReadable(w http.ResponseWriter, r *http.Request) bool {
+	if InformersSavedApp == nil {
+		respondWithServiceUnavailable(w, r, "informers not yet synced", r.URL.Path)
+		return false
+	}
+	if !NodesWatched() {
+		respondWithForbidden(w, r, "nodes not watched", r.URL.Path)
+		return false
+	}
+	return true
+}
+
+// Endpoint #26
+func serveNodesGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveNodesGet"
+	klog.Infof("%s: entry", self)
+	if !nodesReadable(w, r) {
+		return
+	}
+	nodeList, err := NodeListGet()
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "NodeListGet", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(Nodes{Nodes: nodeList})
+}
+
+// Endpoint #27
+func serveNodeGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveNodeGet"
+	klog.Infof("%s: entry", self)
+	matches := reNodeOne.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 2 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nName := matches[1]
+	klog.Infof("%s: nName=%q", self, nName)
+	if !nodesReadable(w, r) {
+		return
+	}
+	node, err := NodeGet(nName)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "NodeGet", err)
+		return
+	}
+	if node == nil {
+		respondWithNotFound(w, r, "node not found", nName)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(node)
+}
+
+// Endpoint #28
+func serveNodeCordonSet(w http.ResponseWriter, r *http.Request) {
+	self := "serveNodeCordonSet"
+	klog.Infof("%s: entry", self)
+	matches := reNodeCordon.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 3 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nName, action := matches[1], matches[2]
+	klog.Infof("%s: nName=%q;  action=%q", self, nName, action)
+	if !RequestIsAdmin(r) {
+		respondWithForbidden(w, r, "admin permission required", r.URL.Path)
+		return
+	}
+	node, err := NodeUnschedulableSet(HttpSavedApp, nName, action == "cordon")
+	if apierrors.IsNotFound(err) {
+		respondWithNotFound(w, r, "node not found", nName)
+		return
+	}
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "NodeUnschedulableSet", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NodeCordon{Node: node.Name, Unschedulable: node.Spec.Unschedulable})
+}
+
+// Endpoint #29
+func serveNodeDrainPreviewGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveNodeDrainPreviewGet"
+	klog.Infof("%s: entry", self)
+	matches := reNodeDrainPreview.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 2 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nName := matches[1]
+	klog.Infof("%s: nName=%q", self, nName)
+	if !nodesReadable(w, r) {
+		return
+	}
+	preview, err := NodeDrainPreviewGet(nName)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "NodeDrainPreviewGet", err)
+		return
+	}
+	if preview == nil {
+		respondWithNotFound(w, r, "node not found", nName)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(preview)
+}
+
+// Endpoint #30
+func serveDeploymentEventsGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveDeploymentEventsGet"
+	klog.Infof("%s: entry", self)
+	matches := reDeploymentEvents.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 3 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName, dName := matches[1], matches[2]
+	klog.Infof("%s: nsName=%q;  dName=%q", self, nsName, dName)
+	since, err := eventsSinceFromRequest(r, time.Now())
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid query", r.URL.RawQuery, err)
+		return
+	}
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	if !DeploymentCachedExists(false, nsName, dName) {
+		respondWithNotFound(w, r, "deployment not found", fmt.Sprintf("%s/%s", nsName, dName))
+		return
+	}
+	if InformersSavedApp == nil {
+		respondWithServiceUnavailable(w, r, "informers not yet synced", r.URL.Path)
+		return
+	}
+	eventList, err := DeploymentEventsGet(nsName, dName, since)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "DeploymentEventsGet", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NamespaceDeploymentEvents{Namespace: nsName, Deployment: dName, Events: eventList})
+}
+
+// Endpoint #31
+func serveQuotaGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveQuotaGet"
+	klog.Infof("%s: entry", self)
+	matches := reNamespaceQuota.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 2 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName := matches[1]
+	klog.Infof("%s: nsName=%q", self, nsName)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	if InformersSavedApp == nil {
+		respondWithServiceUnavailable(w, r, "informers not yet synced", r.URL.Path)
+		return
+	}
+	quotaList, err := QuotaListGet(nsName)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "QuotaListGet", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NamespaceQuotas{Namespace: nsName, Quotas: quotaList})
+}
+
+// Endpoint #32
+func serveResourceReplicasGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveResourceReplicasGet"
+	klog.Infof("%s: entry", self)
+	matches := reResourceOneReplicas.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 4 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName, resource, name := matches[1], matches[2], matches[3]
+	klog.Infof("%s: nsName=%q;  resource=%q;  name=%q", self, nsName, resource, name)
+	gr, ok := scalableResourceFromRequest(w, r, nsName, resource)
+	if !ok {
+		return
+	}
+	replicas, err := ScaleReplicasGet(HttpSavedApp, nsName, *gr, name)
+	if apierrors.IsNotFound(err) {
+		respondWithNotFound(w, r, gr.String()+" not found", fmt.Sprintf("%s/%s", nsName, name))
+		return
+	}
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "ScaleReplicasGet", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NamespaceResourceReplica{Resource: gr.String(),
+		NamespaceDeploymentReplica: NamespaceDeploymentReplica{Namespace: nsName, Deployment: name, Replicas: replicas}})
+}
+
+// Endpoint #33
+func serveResourceReplicasSet(w http.ResponseWriter, r *http.Request) {
+	self := "serveResourceReplicasSet"
+	klog.Infof("%s: entry", self)
+	matches := reResourceSetReplicas.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 5 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName, resource, name, replicas := matches[1], matches[2], matches[3], matches[4]
+	klog.Infof("%s: nsName=%q;  resource=%q;  name=%q;  replicas=%v", self, nsName, resource, name, replicas)
+	gr, ok := scalableResourceFromRequest(w, r, nsName, resource)
+	if !ok {
+		return
+	}
+	if *gr == deploymentsResource {
+		respondWithBadRequest(w, r, "deployments are scaled through /namespaces/{namespace}/deployments", gr.String(),
+			fmt.Errorf("%s: scaling deployments here would skip their PodDisruptionBudget and ResourceQuota checks", self))
+		return
+	}
+	replicasI, err := strconv.Atoi(replicas)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid replica count", replicas, err)
+		return
+	}
+	scaled, err := ScaleReplicasSet(HttpSavedApp, nsName, *gr, name, replicasI)
+	if apierrors.IsNotFound(err) {
+		respondWithNotFound(w, r, gr.String()+" not found", fmt.Sprintf("%s/%s", nsName, name))
+		return
+	}
+	if apierrors.IsInvalid(err) {
+		respondWithBadRequest(w, r, "replica count rejected", replicas, err)
+		return
+	}
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "ScaleReplicasSet", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NamespaceResourceReplica{Resource: gr.String(),
+		NamespaceDeploymentReplica: NamespaceDeploymentReplica{Namespace: nsName, Deployment: name, Replicas: scaled}})
+}
+
+// scalableResourceFromRequest checks the namespace of endpoints 32 and 33
+// and resolves their resource, answering the request itself if either
+// fails.
+func scalableResourceFromRequest(w http.ResponseWriter, r *http.Request, nsName string,
+	resource string) (*schema.GroupResource, bool) {
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return nil, false
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return nil, false
+	}
+	gr, candidates, err := scalableResourceGet(HttpSavedApp, resource)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "scalableResourceGet", err)
+		return nil, false
+	}
+	if len(candidates) > 0 {
+		respondWithBadRequest(w, r, "ambiguous resource; qualify it with its group", resource,
+			fmt.Errorf("served as %s", strings.Join(candidates, ", ")))
+		return nil, false
+	}
+	if gr == nil {
+		respondWithNotFound(w, r, "resource not found or not scalable", resource)
+		return nil, false
+	}
+	return gr, true
+}
+
+// Endpoint #34
+func serveNamespaceGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveNamespaceGet"
+	klog.Infof("%s: entry", self)
+	matches := reNamespaceOne.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 2 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName := matches[1]
+	klog.Infof("%s: nsName=%q", self, nsName)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	namespace, err := NamespaceCachedGet(false, nsName)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "NamespaceCachedGet", err)
+		return
+	}
+	namespaceDetail := NamespaceDetail{Namespace: nsName, Labels: namespace.Labels, Annotations: namespace.Annotations,
+		Phase: namespacePhase(&namespace), DeploymentCount: len(namespace.Deployments)}
+	if namespaceDetail.Labels == nil {
+		namespaceDetail.Labels = map[string]string{}
+	}
+	if namespaceDetail.Annotations == nil {
+		namespaceDetail.Annotations = map[string]string{}
+	}
+	if !namespace.CreationTime.IsZero() {
+		namespaceDetail.CreationTime = &namespace.CreationTime
+	}
+	for _, deployment := range namespace.Deployments {
+		namespaceDetail.Replicas += deployment.Replicas
+	}
+	if RequestIsAdmin(r) && !ProtectedNamespaces[nsName] && !namespaceTerminating(&namespace) {
+		namespaceDetail.DeleteToken = namespaceDeleteToken(&namespace, time.Now())
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(namespaceDetail)
+}
+
+// Endpoint #35
+func serveNamespaceCreate(w http.ResponseWriter, r *http.Request) {
+	self := "serveNamespaceCreate"
+	klog.Infof("%s: entry", self)
+	if !RequestIsAdmin(r) {
+		respondWithForbidden(w, r, "admin permission required", r.URL.Path)
+		return
+	}
+	create, err := namespacePostFromRequest(r.Body)
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid namespace create", r.URL.Path, err)
+		return
+	}
+	klog.Infof("%s: nsName=%q", self, create.Namespace)
+	if !NamespaceWatched(create.Namespace) {
+		respondWithForbidden(w, r, "namespace not watched", create.Namespace)
+		return
+	}
+	created, err := NamespaceCreate(HttpSavedApp, create, NamespaceTemplateSaved)
+	if apierrors.IsAlreadyExists(err) {
+		respondWithConflict(w, r, "namespace already exists", create.Namespace, err)
+		return
+	}
+	if apierrors.IsInvalid(err) {
+		respondWithBadRequest(w, r, "namespace create rejected", create.Namespace, err)
+		return
+	}
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "NamespaceCreate", err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// Endpoint #36
+func serveNamespaceDelete(w http.ResponseWriter, r *http.Request) {
+	self := "serveNamespaceDelete"
+	klog.Infof("%s: entry", self)
+	matches := reNamespaceOne.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 2 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName := matches[1]
+	klog.Infof("%s: nsName=%q", self, nsName)
+	if !RequestIsAdmin(r) {
+		respondWithForbidden(w, r, "admin permission required", r.URL.Path)
+		return
+	}
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if ProtectedNamespaces[nsName] {
+		respondWithForbidden(w, r, "namespace is protected", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	namespace, err := NamespaceCachedGet(false, nsName)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "NamespaceCachedGet", err)
+		return
+	}
+	if namespaceTerminating(&namespace) {
+		respondWithConflict(w, r, "namespace is terminating", nsName,
+			fmt.Errorf("%s: namespace %q is already being deleted", self, nsName))
+		return
+	}
+	err = namespaceDeleteTokenCheck(&namespace, r.URL.Query().Get("confirm"), time.Now())
+	if err != nil {
+		respondWithPreconditionFailed(w, r, "delete token missing, wrong or expired", nsName, err)
+		return
+	}
+	err = NamespaceDelete(HttpSavedApp, nsName)
+	if apierrors.IsNotFound(err) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "NamespaceDelete", err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(NamespaceDeleted{Namespace: nsName, Phase: string(corev1.NamespaceTerminating)})
+}
+
+// Endpoint #37
+func serveIngressesGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveIngressesGet"
+	klog.Infof("%s: entry", self)
+	matches := reNamespaceIngresses.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 2 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName := matches[1]
+	klog.Infof("%s: nsName=%q", self, nsName)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	if InformersSavedApp == nil {
+		respondWithServiceUnavailable(w, r, "informers not yet synced", r.URL.Path)
+		return
+	}
+	ingressList, err := IngressListGet(nsName)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "IngressListGet", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NamespaceIngresses{Namespace: nsName, Ingresses: ingressList})
+}
+
+// Endpoint #38
+func serveDeploymentHostsGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveDeploymentHostsGet"
+	klog.Infof("%s: entry", self)
+	matches := reDeploymentHosts.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 3 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName, dName := matches[1], matches[2]
+	klog.Infof("%s: nsName=%q;  dName=%q", self, nsName, dName)
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	if !DeploymentCachedExists(false, nsName, dName) {
+		respondWithNotFound(w, r, "deployment not found", fmt.Sprintf("%s/%s", nsName, dName))
+		return
+	}
+	if InformersSavedApp == nil {
+		respondWithServiceUnavailable(w, r, "informers not yet synced", r.URL.Path)
+		return
+	}
+	hostList, err := DeploymentHostsGet(nsName, dName)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "DeploymentHostsGet", err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(NamespaceDeploymentHosts{Namespace: nsName, Deployment: dName, Hosts: hostList})
+}
+
+// Endpoint #39
+func serveDeploymentLogsGet(w http.ResponseWriter, r *http.Request) {
+	self := "serveDeploymentLogsGet"
+	klog.Infof("%s: entry", self)
+	matches := reDeploymentLogs.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 3 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName, dName := matches[1], matches[2]
+	klog.Infof("%s: nsName=%q;  dName=%q", self, nsName, dName)
+	options, err := logOptionsFromRequest(r, time.Now())
+	if err != nil {
+		respondWithBadRequest(w, r, "invalid query", r.URL.RawQuery, err)
+		return
+	}
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	if !DeploymentCachedExists(false, nsName, dName) {
+		respondWithNotFound(w, r, "deployment not found", fmt.Sprintf("%s/%s", nsName, dName))
+		return
+	}
+	if InformersSavedApp == nil {
+		respondWithServiceUnavailable(w, r, "informers not yet synced", r.URL.Path)
+		return
+	}
+	podList, err := DeploymentPodsGet(nsName, dName)
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "DeploymentPodsGet", err)
+		return
+	}
+	pods := make([]string, 0, len(podList))
+	for _, pod := range podList {
+		pods = append(pods, pod.Name)
+	}
+	client := requestClient(r)
+	if !LogStreams.Acquire(client, len(pods), HttpSavedApp.LogStreamsPerClient) {
+		respondWithTooManyRequests(w, r, "too many log streams", client)
+		return
+	}
+	defer LogStreams.Release(client, len(pods))
+	flush := func() {}
+	if flusher, ok := w.(http.Flusher); ok {
+		flush = flusher.Flush
+	}
+	w.Header().Set("content-type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	flush()
+	DeploymentLogsStream(r.Context(), HttpSavedApp, nsName, pods, options, w, flush)
+}
+
+// Endpoint #40
+func servePodEvict(w http.ResponseWriter, r *http.Request) {
+	self := "servePodEvict"
+	klog.Infof("%s: entry", self)
+	matches := reDeploymentPodEvict.FindStringSubmatch(r.URL.Path)
+	if len(matches) < 4 {
+		respondWithNotFound(w, r, "invalid arg(s)", r.URL.Path)
+		return
+	}
+	nsName, dName, pName := matches[1], matches[2], matches[3]
+	deleteFallback := false
+	if value := r.URL.Query().Get("delete"); value != "" {
+		var err error
+		deleteFallback, err = strconv.ParseBool(value)
+		if err != nil {
+			respondWithBadRequest(w, r, "invalid query", r.URL.RawQuery, err)
+			return
+		}
+	}
+	klog.Infof("%s: nsName=%q;  dName=%q;  pName=%q;  delete=%t", self, nsName, dName, pName, deleteFallback)
+	if deleteFallback && !RequestIsAdmin(r) {
+		respondWithForbidden(w, r, "admin permission required for delete", r.URL.Path)
+		return
+	}
+	if !NamespaceWatched(nsName) {
+		respondWithForbidden(w, r, "namespace not watched", nsName)
+		return
+	}
+	if !NamespaceCachedExists(false, nsName) {
+		respondWithNotFound(w, r, "namespace not found", nsName)
+		return
+	}
+	if !DeploymentCachedExists(false, nsName, dName) {
+		respondWithNotFound(w, r, "deployment not found", fmt.Sprintf("%s/%s", nsName, dName))
+		return
+	}
+	owned, err := DeploymentPodOwned(HttpSavedApp, nsName, dName, pName)
+	if apierrors.IsNotFound(err) {
+		respondWithNotFound(w, r, "pod not found", fmt.Sprintf("%s/%s", nsName, pName))
+		return
+	}
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "DeploymentPodOwned", err)
+		return
+	}
+	if !owned {
+		respondWithNotFound(w, r, "pod not part of deployment", fmt.Sprintf("%s/%s", nsName, pName))
+		return
+	}
+	action, err := PodEvict(HttpSavedApp, nsName, pName, deleteFallback)
+	if apierrors.IsTooManyRequests(err) {
+		respondWithConflict(w, r, "eviction blocked by PodDisruptionBudget", fmt.Sprintf("%s/%s", nsName, pName), err)
+		return
+	}
+	if apierrors.IsNotFound(err) {
+		respondWithNotFound(w, r, "pod not found", fmt.Sprintf("%s/%s", nsName, pName))
+		return
+	}
+	if err != nil {
+		respondWithInternalServerError(w, r, "", "PodEvict", err)
+		return
+	}
+	klog.Infof("%s: pod \"%s/%s\" of deployment %q %s", self, nsName, pName, dName, action)
+	changePodEvictRecord(nsName, dName, pName)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(NamespaceDeploymentPodEvicted{Namespace: nsName, Deployment: dName, Pod: pName,
+		Action: action})
 }
 
 func initHttp(App *AppX) error {
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// terminatingTestCache caches namespace active, and namespace leaving as
+// Terminating, each with deployment web.
+func terminatingTestCache() *memoryCache {
+	return newMemoryCacheFrom(NamespaceMap{
+		"active": {Name: "active", Deployments: DeploymentMap{"web": {Name: "web", Replicas: 2}}},
+		"leaving": {Name: "leaving", Phase: string(corev1.NamespaceTerminating),
+			Deployments: DeploymentMap{"web": {Name: "web", Replicas: 2}}},
+	})
+}
+
+func TestRequestNamespaceTerminating(t *testing.T) {
+	savedCache, savedSynced := LiveCache, CacheSynced
+	defer func() { LiveCache, CacheSynced = savedCache, savedSynced }()
+	LiveCache, CacheSynced = terminatingTestCache(), true
+	tests := []struct {
+		method string
+		path   string
+		want   bool
+	}{
+		{method: http.MethodPut, path: "/namespaces/leaving/deployments/web/replica_count/3", want: true},
+		{method: http.MethodGet, path: "/namespaces/leaving/deployments/web/replica_count/3", want: true},
+		{method: http.MethodGet, path: "/namespaces/leaving/statefulsets/db/replica_count/3", want: true},
+		{method: http.MethodPut, path: "/namespaces/leaving/rollouts.argoproj.io/canary/replica_count/3", want: true},
+		{method: http.MethodPatch, path: "/namespaces/leaving/deployments/web/hpa", want: true},
+		{method: http.MethodPost, path: "/namespaces/leaving/cronjobs/nightly/run", want: true},
+		{method: http.MethodPost, path: "/namespaces/leaving/deployments/web/pods/web-1/evict", want: true},
+		{method: http.MethodGet, path: "/namespaces/leaving/deployments/web/replica_count", want: false},
+		{method: http.MethodGet, path: "/namespaces/leaving/deployments", want: false},
+		{method: http.MethodPut, path: "/namespaces/active/deployments/web/replica_count/3", want: false},
+		{method: http.MethodPut, path: "/namespaces/unknown/deployments/web/replica_count/3", want: false},
+		// Endpoint 36 answers for the namespace itself.
+		{method: http.MethodDelete, path: "/namespaces/leaving", want: false},
+		{method: http.MethodPost, path: "/namespaces", want: false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		if got := requestIsWrite(r) && requestNamespaceTerminating(r); got != tt.want {
+			t.Errorf("%s %s refused = %v, want %v", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestServeHTTPTerminatingNamespace(t *testing.T) {
+	savedCache, savedSynced, savedApp, savedInformersApp := LiveCache, CacheSynced, HttpSavedApp, InformersSavedApp
+	defer func() {
+		LiveCache, CacheSynced, HttpSavedApp, InformersSavedApp = savedCache, savedSynced, savedApp, savedInformersApp
+	}()
+	LiveCache, CacheSynced = terminatingTestCache(), true
+	HttpSavedApp, InformersSavedApp = &AppX{Role: RoleAll}, nil
+	tests := []struct {
+		method string
+		path   string
+		want   int
+	}{
+		{method: http.MethodPut, path: "/namespaces/leaving/deployments/web/replica_count/3", want: http.StatusConflict},
+		{method: http.MethodGet, path: "/namespaces/leaving/deployments/web/replica_count/3", want: http.StatusConflict},
+		{method: http.MethodPost, path: "/namespaces/leaving/cronjobs/nightly/suspend", want: http.StatusConflict},
+		{method: http.MethodGet, path: "/namespaces/leaving/deployments", want: http.StatusOK},
+		{method: http.MethodGet, path: "/namespaces/leaving/deployments/web/replica_count", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		(&handler{}).ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("%s %s = %d, want %d: %s", tt.method, tt.path, w.Code, tt.want, w.Body.String())
+		}
+	}
+}
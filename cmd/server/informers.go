@@ -1,85 +1,187 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"reflect"
 	"sync"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/informers"
 	appsinformers "k8s.io/client-go/informers/apps/v1"
-	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/metadata/metadatainformer"
 	"k8s.io/client-go/tools/cache"
 	klog "k8s.io/klog/v2"
 )
 
 var (
 	InformersSavedApp *AppX
+	WatchedNamespaces map[string]bool
 )
 
 type NamespaceLoggingController struct {
-	informerFactory   informers.SharedInformerFactory
-	namespaceInformer coreinformers.NamespaceInformer
+	name              string
+	informerFactory   metadatainformer.SharedInformerFactory
+	namespaceInformer informers.GenericInformer
 }
 
 type DeploymentLoggingController struct {
+	name               string
 	informerFactory    informers.SharedInformerFactory
 	deploymentInformer appsinformers.DeploymentInformer
 }
 
+type StatefulSetLoggingController struct {
+	name                string
+	informerFactory     informers.SharedInformerFactory
+	statefulSetInformer appsinformers.StatefulSetInformer
+}
+
+type DaemonSetLoggingController struct {
+	name              string
+	informerFactory   informers.SharedInformerFactory
+	daemonSetInformer appsinformers.DaemonSetInformer
+}
+
 type StringList []string
-type MapStringList map[string]StringList
 
 type DeploymentItem struct {
-	Name     string `json:"deployment"`
-	Replicas int    `json:"replica_count"`
+	Name     string            `json:"deployment"`
+	Replicas int               `json:"replica_count"`
+	Labels   map[string]string `json:"labels,omitempty"`
 }
 type DeploymentMap map[string]*DeploymentItem
 
+type StatefulSetItem struct {
+	Name            string            `json:"statefulset"`
+	Replicas        int               `json:"replica_count"`
+	ReadyReplicas   int               `json:"ready_replicas"`
+	CurrentRevision string            `json:"current_revision,omitempty"`
+	UpdateRevision  string            `json:"update_revision,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+}
+type StatefulSetMap map[string]*StatefulSetItem
+
+// DaemonSetItem holds a daemonset's node counts from its status.
+type DaemonSetItem struct {
+	Name         string            `json:"daemonset"`
+	Desired      int               `json:"desired"`
+	Current      int               `json:"current"`
+	Ready        int               `json:"ready"`
+	Updated      int               `json:"updated"`
+	Misscheduled int               `json:"misscheduled"`
+	Labels       map[string]string `json:"labels,omitempty"`
+}
+type DaemonSetMap map[string]*DaemonSetItem
+
+// NamespaceItem is a namespace with its workloads.  Phase is Terminating
+// once the namespace has been deleted and is waiting for its contents to
+// go; it is left empty for Active.
 type NamespaceItem struct {
-	Name        string        `json:"namespace"`
-	Deployments DeploymentMap `json:"deployments"`
+	Name         string            `json:"namespace"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	Phase        string            `json:"phase,omitempty"`
+	CreationTime time.Time         `json:"creation_time"`
+	Deployments  DeploymentMap     `json:"deployments"`
+	StatefulSets StatefulSetMap    `json:"statefulsets,omitempty"`
+	DaemonSets   DaemonSetMap      `json:"daemonsets,omitempty"`
 }
 type NamespaceMap map[string]*NamespaceItem
 type NamespaceListItem struct {
 	Name        string           `json:"namespace"`
 	Deployments []DeploymentItem `json:"deployments"`
+	*ListPage
+}
+type NamespaceStatefulSetListItem struct {
+	Name         string            `json:"namespace"`
+	StatefulSets []StatefulSetItem `json:"statefulsets"`
+	*ListPage
+}
+type NamespaceDaemonSetListItem struct {
+	Name       string          `json:"namespace"`
+	DaemonSets []DaemonSetItem `json:"daemonsets"`
+	*ListPage
 }
 
 var (
-	Namespaces     NamespaceMap = make(NamespaceMap)
 	NamespacesLock sync.Mutex
+	// InformersLock guards App.Informers, which initInformers fills while
+	// the HTTP server already answers /debug/cache.
+	InformersLock sync.RWMutex
 )
 
-func (c *DeploymentLoggingController) Run(stopCh chan struct{}) error {
+// NamespaceWatched reports whether nsName is in the set of namespaces this
+// server watches.  A nil set means that all namespaces are watched.
+func NamespaceWatched(nsName string) bool {
+	if WatchedNamespaces == nil {
+		return true
+	}
+	return WatchedNamespaces[nsName]
+}
+
+func (c *DeploymentLoggingController) Run(stopCh chan struct{}, syncTimeout time.Duration) error {
 	c.informerFactory.Start(stopCh)
-	if !cache.WaitForCacheSync(stopCh, c.deploymentInformer.Informer().HasSynced) {
+	if !informerSyncWait(stopCh, syncTimeout, c.deploymentInformer.Informer().HasSynced) {
 		return fmt.Errorf("failed to sync")
 	}
 	return nil
 }
 
+func deploymentItemFrom(deployment *appsv1.Deployment) *DeploymentItem {
+	di := new(DeploymentItem)
+	di.Name, di.Replicas, di.Labels = deployment.Name, 1, deployment.Labels
+	if deployment.Spec.Replicas != nil {
+		di.Replicas = int(*deployment.Spec.Replicas)
+	}
+	return di
+}
+
 func (c *DeploymentLoggingController) deploymentAdd(obj interface{}) {
 	NamespacesLock.Lock()
 	defer NamespacesLock.Unlock()
 	self := "deploymentAdd"
+	informerEventRecord(c.name, "add")
 	deploymentObject := obj.(*appsv1.Deployment)
-	nsName, dName, dReplicas := deploymentObject.Namespace, deploymentObject.Name, int(*deploymentObject.Spec.Replicas)
-	dMap, ok := Namespaces[nsName]
-	if !ok {
+	nsName, dName := deploymentObject.Namespace, deploymentObject.Name
+	namespace, err := LiveCache.NamespaceGet(nsName)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).NamespaceGet")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceGet", err)
+		return
+	}
+	if namespace == nil {
+		informerRejectRecord(c.name, "event refs unknown namespace")
 		klog.Errorf("%s: event refs unknown namespace: %q", self, nsName)
 		return
 	}
-	_, ok = dMap.Deployments[dName]
-	if ok {
+	existing, err := LiveCache.DeploymentGet(nsName, dName)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).DeploymentGet")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).DeploymentGet", err)
+		return
+	}
+	if existing != nil && CacheSynced {
+		informerRejectRecord(c.name, "event refs existing deployment")
 		klog.Errorf("%s: event refs existing deployment: \"%s/%s\"", self, nsName, dName)
 		return
 	}
-	di := new(DeploymentItem)
-	di.Name, di.Replicas = dName, dReplicas
-	Namespaces[nsName].Deployments[dName] = di
-	klog.Infof("%s: created: \"%s/%s\"  replicas=%d", self, nsName, dName, dReplicas)
+	di := deploymentItemFrom(deploymentObject)
+	err = LiveCache.DeploymentPut(nsName, di)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).DeploymentPut")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).DeploymentPut", err)
+		return
+	}
+	changeDeploymentRecord(ChangeAdd, nsName, di, dName)
+	klog.Infof("%s: created: \"%s/%s\"  replicas=%d", self, nsName, dName, di.Replicas)
 }
 
 func (c *DeploymentLoggingController) deploymentUpdate(old, new interface{}) {
@@ -87,66 +189,427 @@ func (c *DeploymentLoggingController) deploymentUpdate(old, new interface{}) {
 	defer NamespacesLock.Unlock()
 	self := "deploymentUpdate"
 	oldDeployment, newDeployment := old.(*appsv1.Deployment), new.(*appsv1.Deployment)
+	if oldDeployment.ResourceVersion == newDeployment.ResourceVersion {
+		informerEventRecord(c.name, "resync")
+		return // periodic resync
+	}
+	informerEventRecord(c.name, "update")
 	oldReplicas, newReplicas := *oldDeployment.Spec.Replicas, *newDeployment.Spec.Replicas
 	oldNS, oldName := oldDeployment.Namespace, oldDeployment.Name
 	newNS, newName := newDeployment.Namespace, newDeployment.Name
 	nameChange, replicasChange := oldName != newName, oldReplicas != newReplicas
+	labelsChange := !reflect.DeepEqual(oldDeployment.Labels, newDeployment.Labels)
 	if oldNS != newNS {
 		klog.Errorf("%s: event includes namespace name change: old=%#v;  new=%#v", self, oldDeployment, newDeployment)
 	}
-	dMap, ok := Namespaces[oldNS]
-	if !ok {
+	namespace, err := LiveCache.NamespaceGet(oldNS)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).NamespaceGet")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceGet", err)
+		return
+	}
+	if namespace == nil {
+		informerRejectRecord(c.name, "event refs unknown namespace")
 		klog.Errorf("%s: event refs unknown namespace: %q", self, oldNS)
 		return
 	}
-	_, ok = dMap.Deployments[oldName]
-	if !ok {
+	di, err := LiveCache.DeploymentGet(oldNS, oldName)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).DeploymentGet")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).DeploymentGet", err)
+		return
+	}
+	if di == nil {
+		informerRejectRecord(c.name, "event refs unknown orig deployment")
 		klog.Errorf("%s: event refs unknown orig deployment: \"%s/%s\"", self, oldNS, oldName)
 		return
 	}
-	_, ok = dMap.Deployments[newName]
-	if nameChange && ok {
-		klog.Errorf("%s: event refs existing new name: \"%s/%s\" -> \"%s/%s\"", self, oldNS, oldName, oldNS, newName)
+	if nameChange {
+		existing, err := LiveCache.DeploymentGet(oldNS, newName)
+		if err != nil {
+			informerErrorRecord(c.name, "(Cache).DeploymentGet")
+			klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).DeploymentGet", err)
+			return
+		}
+		if existing != nil {
+			informerRejectRecord(c.name, "event refs existing new name")
+			klog.Errorf("%s: event refs existing new name: \"%s/%s\" -> \"%s/%s\"", self, oldNS, oldName, oldNS, newName)
+			return
+		}
+	}
+	if !nameChange && !replicasChange && !labelsChange {
+		informerRejectRecord(c.name, "event is not deployment name, replica count or labels change")
+		klog.Errorf("%s: event is not deployment name, replica count or labels change. ignored.", self)
 		return
 	}
-	if !nameChange && !replicasChange {
-		klog.Errorf("%s: event is not deployment name change or replica count change. ignored.", self)
+	updated := *di
+	updated.Name, updated.Replicas, updated.Labels = newName, int(newReplicas), newDeployment.Labels
+	err = LiveCache.DeploymentPut(oldNS, &updated)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).DeploymentPut")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).DeploymentPut", err)
 		return
 	}
 	if nameChange {
-		Namespaces[oldNS].Deployments[newName] = Namespaces[oldNS].Deployments[oldName]
-		delete(Namespaces[oldNS].Deployments, oldName)
+		err = LiveCache.DeploymentDelete(oldNS, oldName)
+		if err != nil {
+			informerErrorRecord(c.name, "(Cache).DeploymentDelete")
+			klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).DeploymentDelete", err)
+			return
+		}
 		klog.Infof("%s: name updated: \"%s/%s\" -> \"%s/%s\"", self, oldNS, oldName, oldNS, newName)
 	}
+	changeDeploymentRecord(ChangeUpdate, oldNS, &updated, oldName)
 	if replicasChange {
-		Namespaces[oldNS].Deployments[newName].Replicas = int(newReplicas)
 		klog.Infof("%s: replica count updated: \"%s/%s\": %d -> %d", self, oldNS, newName, oldReplicas, newReplicas)
 	}
+	if labelsChange {
+		klog.Infof("%s: labels updated: \"%s/%s\": %v -> %v", self, oldNS, newName, oldDeployment.Labels, newDeployment.Labels)
+	}
+}
+
+// deletedObject returns the object a delete handler is handed.  When the
+// informer missed the delete and learned of it only on relisting, that is
+// a cache.DeletedFinalStateUnknown tombstone holding the last state seen.
+func deletedObject(obj interface{}) interface{} {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		return tombstone.Obj
+	}
+	return obj
 }
 
 func (c *DeploymentLoggingController) deploymentDelete(obj interface{}) {
 	NamespacesLock.Lock()
 	defer NamespacesLock.Unlock()
 	self := "deploymentDelete"
-	deployment := obj.(*appsv1.Deployment)
+	informerEventRecord(c.name, "delete")
+	deployment, ok := deletedObject(obj).(*appsv1.Deployment)
+	if !ok {
+		informerRejectRecord(c.name, "event object is not a deployment")
+		klog.Errorf("%s: event object is not a deployment: %#v", self, obj)
+		return
+	}
 	nsName, name := deployment.Namespace, deployment.Name
-	dMap, ok := Namespaces[nsName]
+	namespace, err := LiveCache.NamespaceGet(nsName)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).NamespaceGet")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceGet", err)
+		return
+	}
+	if namespace == nil {
+		informerRejectRecord(c.name, "event refs unknown namespace")
+		klog.Errorf("%s: event refs unknown namespace: %q", self, nsName)
+		return
+	}
+	di, err := LiveCache.DeploymentGet(nsName, name)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).DeploymentGet")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).DeploymentGet", err)
+		return
+	}
+	if di == nil {
+		informerRejectRecord(c.name, "event refs unknown deployment")
+		klog.Errorf("%s: event refs unknown deployment: \"%s/%s\"", self, nsName, name)
+		return
+	}
+	err = LiveCache.DeploymentDelete(nsName, name)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).DeploymentDelete")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).DeploymentDelete", err)
+		return
+	}
+	changeDeploymentRecord(ChangeDelete, nsName, di, name)
+	klog.Infof("%s: deleted: \"%s/%s\"", self, nsName, name)
+}
+
+func (c *StatefulSetLoggingController) Run(stopCh chan struct{}, syncTimeout time.Duration) error {
+	c.informerFactory.Start(stopCh)
+	if !informerSyncWait(stopCh, syncTimeout, c.statefulSetInformer.Informer().HasSynced) {
+		return fmt.Errorf("failed to sync")
+	}
+	return nil
+}
+
+func statefulSetItemFrom(statefulSet *appsv1.StatefulSet) *StatefulSetItem {
+	si := new(StatefulSetItem)
+	si.Name, si.Replicas, si.Labels = statefulSet.Name, 1, statefulSet.Labels
+	if statefulSet.Spec.Replicas != nil {
+		si.Replicas = int(*statefulSet.Spec.Replicas)
+	}
+	si.ReadyReplicas = int(statefulSet.Status.ReadyReplicas)
+	si.CurrentRevision, si.UpdateRevision = statefulSet.Status.CurrentRevision, statefulSet.Status.UpdateRevision
+	return si
+}
+
+func (c *StatefulSetLoggingController) statefulSetAdd(obj interface{}) {
+	NamespacesLock.Lock()
+	defer NamespacesLock.Unlock()
+	self := "statefulSetAdd"
+	informerEventRecord(c.name, "add")
+	statefulSetObject := obj.(*appsv1.StatefulSet)
+	nsName, sName := statefulSetObject.Namespace, statefulSetObject.Name
+	namespace, err := LiveCache.NamespaceGet(nsName)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).NamespaceGet")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceGet", err)
+		return
+	}
+	if namespace == nil {
+		informerRejectRecord(c.name, "event refs unknown namespace")
+		klog.Errorf("%s: event refs unknown namespace: %q", self, nsName)
+		return
+	}
+	existing, err := LiveCache.StatefulSetGet(nsName, sName)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).StatefulSetGet")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).StatefulSetGet", err)
+		return
+	}
+	if existing != nil && CacheSynced {
+		informerRejectRecord(c.name, "event refs existing statefulset")
+		klog.Errorf("%s: event refs existing statefulset: \"%s/%s\"", self, nsName, sName)
+		return
+	}
+	si := statefulSetItemFrom(statefulSetObject)
+	err = LiveCache.StatefulSetPut(nsName, si)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).StatefulSetPut")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).StatefulSetPut", err)
+		return
+	}
+	changeStatefulSetRecord(ChangeAdd, nsName, si)
+	klog.Infof("%s: created: \"%s/%s\"  replicas=%d", self, nsName, sName, si.Replicas)
+}
+
+// statefulSetUpdate also follows status changes, so that ready replicas and
+// revisions stay current during rollouts.
+func (c *StatefulSetLoggingController) statefulSetUpdate(old, new interface{}) {
+	NamespacesLock.Lock()
+	defer NamespacesLock.Unlock()
+	self := "statefulSetUpdate"
+	oldStatefulSet, newStatefulSet := old.(*appsv1.StatefulSet), new.(*appsv1.StatefulSet)
+	if oldStatefulSet.ResourceVersion == newStatefulSet.ResourceVersion {
+		informerEventRecord(c.name, "resync")
+		return // periodic resync
+	}
+	informerEventRecord(c.name, "update")
+	nsName, sName := newStatefulSet.Namespace, newStatefulSet.Name
+	namespace, err := LiveCache.NamespaceGet(nsName)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).NamespaceGet")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceGet", err)
+		return
+	}
+	if namespace == nil {
+		informerRejectRecord(c.name, "event refs unknown namespace")
+		klog.Errorf("%s: event refs unknown namespace: %q", self, nsName)
+		return
+	}
+	existing, err := LiveCache.StatefulSetGet(nsName, sName)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).StatefulSetGet")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).StatefulSetGet", err)
+		return
+	}
+	if existing == nil {
+		informerRejectRecord(c.name, "event refs unknown statefulset")
+		klog.Errorf("%s: event refs unknown statefulset: \"%s/%s\"", self, nsName, sName)
+		return
+	}
+	si := statefulSetItemFrom(newStatefulSet)
+	if reflect.DeepEqual(existing, si) {
+		return
+	}
+	err = LiveCache.StatefulSetPut(nsName, si)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).StatefulSetPut")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).StatefulSetPut", err)
+		return
+	}
+	changeStatefulSetRecord(ChangeUpdate, nsName, si)
+	klog.Infof("%s: updated: \"%s/%s\": replicas=%d;  ready=%d;  currentRevision=%q;  updateRevision=%q",
+		self, nsName, sName, si.Replicas, si.ReadyReplicas, si.CurrentRevision, si.UpdateRevision)
+}
+
+func (c *StatefulSetLoggingController) statefulSetDelete(obj interface{}) {
+	NamespacesLock.Lock()
+	defer NamespacesLock.Unlock()
+	self := "statefulSetDelete"
+	informerEventRecord(c.name, "delete")
+	statefulSet, ok := deletedObject(obj).(*appsv1.StatefulSet)
 	if !ok {
+		informerRejectRecord(c.name, "event object is not a statefulset")
+		klog.Errorf("%s: event object is not a statefulset: %#v", self, obj)
+		return
+	}
+	nsName, name := statefulSet.Namespace, statefulSet.Name
+	si, err := LiveCache.StatefulSetGet(nsName, name)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).StatefulSetGet")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).StatefulSetGet", err)
+		return
+	}
+	if si == nil {
+		informerRejectRecord(c.name, "event refs unknown statefulset")
+		klog.Errorf("%s: event refs unknown statefulset: \"%s/%s\"", self, nsName, name)
+		return
+	}
+	err = LiveCache.StatefulSetDelete(nsName, name)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).StatefulSetDelete")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).StatefulSetDelete", err)
+		return
+	}
+	changeStatefulSetRecord(ChangeDelete, nsName, si)
+	klog.Infof("%s: deleted: \"%s/%s\"", self, nsName, name)
+}
+
+func (c *DaemonSetLoggingController) Run(stopCh chan struct{}, syncTimeout time.Duration) error {
+	c.informerFactory.Start(stopCh)
+	if !informerSyncWait(stopCh, syncTimeout, c.daemonSetInformer.Informer().HasSynced) {
+		return fmt.Errorf("failed to sync")
+	}
+	return nil
+}
+
+func daemonSetItemFrom(daemonSet *appsv1.DaemonSet) *DaemonSetItem {
+	return &DaemonSetItem{
+		Name:         daemonSet.Name,
+		Desired:      int(daemonSet.Status.DesiredNumberScheduled),
+		Current:      int(daemonSet.Status.CurrentNumberScheduled),
+		Ready:        int(daemonSet.Status.NumberReady),
+		Updated:      int(daemonSet.Status.UpdatedNumberScheduled),
+		Misscheduled: int(daemonSet.Status.NumberMisscheduled),
+		Labels:       daemonSet.Labels,
+	}
+}
+
+func (c *DaemonSetLoggingController) daemonSetAdd(obj interface{}) {
+	NamespacesLock.Lock()
+	defer NamespacesLock.Unlock()
+	self := "daemonSetAdd"
+	informerEventRecord(c.name, "add")
+	daemonSetObject := obj.(*appsv1.DaemonSet)
+	nsName, dsName := daemonSetObject.Namespace, daemonSetObject.Name
+	namespace, err := LiveCache.NamespaceGet(nsName)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).NamespaceGet")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceGet", err)
+		return
+	}
+	if namespace == nil {
+		informerRejectRecord(c.name, "event refs unknown namespace")
 		klog.Errorf("%s: event refs unknown namespace: %q", self, nsName)
 		return
 	}
-	_, ok = dMap.Deployments[name]
+	existing, err := LiveCache.DaemonSetGet(nsName, dsName)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).DaemonSetGet")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).DaemonSetGet", err)
+		return
+	}
+	if existing != nil && CacheSynced {
+		informerRejectRecord(c.name, "event refs existing daemonset")
+		klog.Errorf("%s: event refs existing daemonset: \"%s/%s\"", self, nsName, dsName)
+		return
+	}
+	dsi := daemonSetItemFrom(daemonSetObject)
+	err = LiveCache.DaemonSetPut(nsName, dsi)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).DaemonSetPut")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).DaemonSetPut", err)
+		return
+	}
+	changeDaemonSetRecord(ChangeAdd, nsName, dsi)
+	klog.Infof("%s: created: \"%s/%s\"  desired=%d", self, nsName, dsName, dsi.Desired)
+}
+
+// daemonSetUpdate follows status changes, as a daemonset's node counts
+// are all status.
+func (c *DaemonSetLoggingController) daemonSetUpdate(old, new interface{}) {
+	NamespacesLock.Lock()
+	defer NamespacesLock.Unlock()
+	self := "daemonSetUpdate"
+	oldDaemonSet, newDaemonSet := old.(*appsv1.DaemonSet), new.(*appsv1.DaemonSet)
+	if oldDaemonSet.ResourceVersion == newDaemonSet.ResourceVersion {
+		informerEventRecord(c.name, "resync")
+		return // periodic resync
+	}
+	informerEventRecord(c.name, "update")
+	nsName, dsName := newDaemonSet.Namespace, newDaemonSet.Name
+	namespace, err := LiveCache.NamespaceGet(nsName)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).NamespaceGet")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceGet", err)
+		return
+	}
+	if namespace == nil {
+		informerRejectRecord(c.name, "event refs unknown namespace")
+		klog.Errorf("%s: event refs unknown namespace: %q", self, nsName)
+		return
+	}
+	existing, err := LiveCache.DaemonSetGet(nsName, dsName)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).DaemonSetGet")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).DaemonSetGet", err)
+		return
+	}
+	if existing == nil {
+		informerRejectRecord(c.name, "event refs unknown daemonset")
+		klog.Errorf("%s: event refs unknown daemonset: \"%s/%s\"", self, nsName, dsName)
+		return
+	}
+	dsi := daemonSetItemFrom(newDaemonSet)
+	if reflect.DeepEqual(existing, dsi) {
+		return
+	}
+	err = LiveCache.DaemonSetPut(nsName, dsi)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).DaemonSetPut")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).DaemonSetPut", err)
+		return
+	}
+	changeDaemonSetRecord(ChangeUpdate, nsName, dsi)
+	klog.Infof("%s: updated: \"%s/%s\": desired=%d;  current=%d;  ready=%d;  updated=%d;  misscheduled=%d",
+		self, nsName, dsName, dsi.Desired, dsi.Current, dsi.Ready, dsi.Updated, dsi.Misscheduled)
+}
+
+func (c *DaemonSetLoggingController) daemonSetDelete(obj interface{}) {
+	NamespacesLock.Lock()
+	defer NamespacesLock.Unlock()
+	self := "daemonSetDelete"
+	informerEventRecord(c.name, "delete")
+	daemonSet, ok := deletedObject(obj).(*appsv1.DaemonSet)
 	if !ok {
-		klog.Errorf("%s: event refs unknown deployment: \"%s/%s\"", self, nsName, name)
+		informerRejectRecord(c.name, "event object is not a daemonset")
+		klog.Errorf("%s: event object is not a daemonset: %#v", self, obj)
+		return
+	}
+	nsName, name := daemonSet.Namespace, daemonSet.Name
+	dsi, err := LiveCache.DaemonSetGet(nsName, name)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).DaemonSetGet")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).DaemonSetGet", err)
+		return
+	}
+	if dsi == nil {
+		informerRejectRecord(c.name, "event refs unknown daemonset")
+		klog.Errorf("%s: event refs unknown daemonset: \"%s/%s\"", self, nsName, name)
+		return
+	}
+	err = LiveCache.DaemonSetDelete(nsName, name)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).DaemonSetDelete")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).DaemonSetDelete", err)
 		return
 	}
-	delete(Namespaces[nsName].Deployments, name)
+	changeDaemonSetRecord(ChangeDelete, nsName, dsi)
 	klog.Infof("%s: deleted: \"%s/%s\"", self, nsName, name)
 }
 
-func (c *NamespaceLoggingController) Run(stopCh chan struct{}) error {
+func (c *NamespaceLoggingController) Run(stopCh chan struct{}, syncTimeout time.Duration) error {
 	c.informerFactory.Start(stopCh)
-	if !cache.WaitForCacheSync(stopCh, c.namespaceInformer.Informer().HasSynced) {
+	if !informerSyncWait(stopCh, syncTimeout, c.namespaceInformer.Informer().HasSynced) {
 		return fmt.Errorf("failed to sync")
 	}
 	return nil
@@ -156,15 +619,31 @@ func (c *NamespaceLoggingController) namespaceAdd(obj interface{}) {
 	NamespacesLock.Lock()
 	defer NamespacesLock.Unlock()
 	self := "namespaceAdd"
-	namespaceObject := obj.(*corev1.Namespace)
+	informerEventRecord(c.name, "add")
+	namespaceObject := obj.(*metav1.PartialObjectMetadata)
 	nsName := namespaceObject.Name
-	if _, ok := Namespaces[nsName]; ok {
+	if !NamespaceWatched(nsName) {
+		return
+	}
+	existing, err := LiveCache.NamespaceGet(nsName)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).NamespaceGet")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceGet", err)
+		return
+	}
+	if existing != nil && CacheSynced {
+		informerRejectRecord(c.name, "event refs existing namespace")
 		klog.Errorf("%s: event refs existing namespace: %q", self, nsName)
 		return
 	}
-	ni := new(NamespaceItem)
-	ni.Name, ni.Deployments = nsName, make(DeploymentMap)
-	Namespaces[nsName] = ni
+	ni := namespaceItemFrom(namespaceObject)
+	err = LiveCache.NamespacePut(ni)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).NamespacePut")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespacePut", err)
+		return
+	}
+	changeNamespaceRecord(ChangeAdd, ni, nsName)
 	klog.Infof("%s: created: %q", self, nsName)
 }
 
@@ -172,23 +651,70 @@ func (c *NamespaceLoggingController) namespaceUpdate(old, new interface{}) {
 	NamespacesLock.Lock()
 	defer NamespacesLock.Unlock()
 	self := "namespaceUpdate"
-	oldNamespace := old.(*corev1.Namespace)
-	newNamespace := new.(*corev1.Namespace)
+	oldNamespace := old.(*metav1.PartialObjectMetadata)
+	newNamespace := new.(*metav1.PartialObjectMetadata)
+	if oldNamespace.ResourceVersion == newNamespace.ResourceVersion {
+		informerEventRecord(c.name, "resync")
+		return // periodic resync
+	}
+	informerEventRecord(c.name, "update")
 	oldName, newName := oldNamespace.Name, newNamespace.Name
-	if oldName == newName {
-		klog.Errorf("%s: event is not name change. ignored.", self)
+	if !NamespaceWatched(oldName) {
 		return
 	}
-	if _, ok := Namespaces[oldName]; !ok {
+	labelsChange := !reflect.DeepEqual(oldNamespace.Labels, newNamespace.Labels)
+	annotationsChange := !reflect.DeepEqual(oldNamespace.Annotations, newNamespace.Annotations)
+	phaseChange := (oldNamespace.DeletionTimestamp == nil) != (newNamespace.DeletionTimestamp == nil)
+	if oldName == newName && !labelsChange && !annotationsChange && !phaseChange {
+		informerRejectRecord(c.name, "event is not name, labels, annotations or phase change")
+		klog.Errorf("%s: event is not name, labels, annotations or phase change. ignored.", self)
+		return
+	}
+	ni, err := LiveCache.NamespaceGet(oldName)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).NamespaceGet")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceGet", err)
+		return
+	}
+	if ni == nil {
+		informerRejectRecord(c.name, "event refs unknown old namespace")
 		klog.Errorf("%s: event refs unknown old namespace: %q", self, oldName)
 		return
 	}
-	if _, ok := Namespaces[newName]; ok {
+	updated := *ni
+	fresh := namespaceItemFrom(newNamespace)
+	updated.Name, updated.Labels, updated.Annotations = fresh.Name, fresh.Labels, fresh.Annotations
+	updated.Phase, updated.CreationTime = fresh.Phase, fresh.CreationTime
+	if oldName == newName {
+		err = LiveCache.NamespacePut(&updated)
+		if err != nil {
+			informerErrorRecord(c.name, "(Cache).NamespacePut")
+			klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespacePut", err)
+			return
+		}
+		changeNamespaceRecord(ChangeUpdate, &updated, oldName)
+		klog.Infof("%s: metadata updated: %q: labels %v -> %v;  phase %q", self, oldName, oldNamespace.Labels,
+			newNamespace.Labels, namespacePhase(&updated))
+		return
+	}
+	existing, err := LiveCache.NamespaceGet(newName)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).NamespaceGet")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceGet", err)
+		return
+	}
+	if existing != nil {
+		informerRejectRecord(c.name, "event refs existing new namespace")
 		klog.Errorf("%s: event refs existing new namespace: %q", self, newName)
 		return
 	}
-	Namespaces[newName] = Namespaces[oldName]
-	delete(Namespaces, oldName)
+	err = cacheNamespaceRename(LiveCache, &updated, oldName)
+	if err != nil {
+		informerErrorRecord(c.name, "cacheNamespaceRename")
+		klog.Errorf("%s: call to %q failed: %#v", self, "cacheNamespaceRename", err)
+		return
+	}
+	changeNamespaceRecord(ChangeUpdate, &updated, oldName)
 	klog.Infof("%s: updated: %q -> %q", self, oldName, newName)
 }
 
@@ -196,23 +722,75 @@ func (c *NamespaceLoggingController) namespaceDelete(obj interface{}) {
 	NamespacesLock.Lock()
 	defer NamespacesLock.Unlock()
 	self := "namespaceDelete"
-	namespaceObject := obj.(*corev1.Namespace)
+	informerEventRecord(c.name, "delete")
+	namespaceObject, ok := deletedObject(obj).(*metav1.PartialObjectMetadata)
+	if !ok {
+		informerRejectRecord(c.name, "event object is not a namespace")
+		klog.Errorf("%s: event object is not a namespace: %#v", self, obj)
+		return
+	}
 	nsName := namespaceObject.Name
-	if _, ok := Namespaces[nsName]; !ok {
+	if !NamespaceWatched(nsName) {
+		return
+	}
+	ni, err := LiveCache.NamespaceGet(nsName)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).NamespaceGet")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceGet", err)
+		return
+	}
+	if ni == nil {
+		informerRejectRecord(c.name, "event refs unknown namespace")
 		klog.Errorf("%s: event refs unknown namespace: %q", self, nsName)
 		return
 	}
-	delete(Namespaces, nsName)
+	err = LiveCache.NamespaceDelete(nsName)
+	if err != nil {
+		informerErrorRecord(c.name, "(Cache).NamespaceDelete")
+		klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespaceDelete", err)
+		return
+	}
+	changeNamespaceRecord(ChangeDelete, ni, nsName)
 	klog.Infof("%s: deleted: %q", self, nsName)
 }
 
-func NewDeploymentLoggingController(informerFactory informers.SharedInformerFactory) (*DeploymentLoggingController, error) {
+// namespaceItemFrom makes the cache entry for namespace, without its
+// workloads.  A namespace's phase is in its status, which the metadata
+// informer does not carry, but a namespace is Terminating exactly when
+// it has a deletion timestamp.
+func namespaceItemFrom(namespace *metav1.PartialObjectMetadata) *NamespaceItem {
+	ni := &NamespaceItem{Name: namespace.Name, Labels: namespace.Labels, Annotations: namespace.Annotations,
+		CreationTime: namespace.CreationTimestamp.UTC()}
+	if namespace.DeletionTimestamp != nil {
+		ni.Phase = string(corev1.NamespaceTerminating)
+	}
+	return ni
+}
+
+func namespaceTerminating(ni *NamespaceItem) bool {
+	return ni.Phase == string(corev1.NamespaceTerminating)
+}
+
+// namespacePhase returns the phase of ni, Active or Terminating.
+func namespacePhase(ni *NamespaceItem) string {
+	if ni.Phase == "" {
+		return string(corev1.NamespaceActive)
+	}
+	return ni.Phase
+}
+
+func NewDeploymentLoggingController(name string, informerFactory informers.SharedInformerFactory) (*DeploymentLoggingController, error) {
 	deploymentInformer := informerFactory.Apps().V1().Deployments()
 	c := &DeploymentLoggingController{
+		name:               name,
 		informerFactory:    informerFactory,
 		deploymentInformer: deploymentInformer,
 	}
-	_, err := deploymentInformer.Informer().AddEventHandler(
+	err := deploymentInformer.Informer().SetTransform(deploymentTransform)
+	if err != nil {
+		return nil, err
+	}
+	_, err = deploymentInformer.Informer().AddEventHandler(
 		cache.ResourceEventHandlerFuncs{
 			AddFunc:    c.deploymentAdd,
 			UpdateFunc: c.deploymentUpdate,
@@ -225,13 +803,66 @@ func NewDeploymentLoggingController(informerFactory informers.SharedInformerFact
 	return c, nil
 }
 
-func NewNamespaceLoggingController(informerFactory informers.SharedInformerFactory) (*NamespaceLoggingController, error) {
-	namespaceInformer := informerFactory.Core().V1().Namespaces()
+func NewStatefulSetLoggingController(name string, informerFactory informers.SharedInformerFactory) (*StatefulSetLoggingController, error) {
+	statefulSetInformer := informerFactory.Apps().V1().StatefulSets()
+	c := &StatefulSetLoggingController{
+		name:                name,
+		informerFactory:     informerFactory,
+		statefulSetInformer: statefulSetInformer,
+	}
+	err := statefulSetInformer.Informer().SetTransform(statefulSetTransform)
+	if err != nil {
+		return nil, err
+	}
+	_, err = statefulSetInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc:    c.statefulSetAdd,
+			UpdateFunc: c.statefulSetUpdate,
+			DeleteFunc: c.statefulSetDelete,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func NewDaemonSetLoggingController(name string, informerFactory informers.SharedInformerFactory) (*DaemonSetLoggingController, error) {
+	daemonSetInformer := informerFactory.Apps().V1().DaemonSets()
+	c := &DaemonSetLoggingController{
+		name:              name,
+		informerFactory:   informerFactory,
+		daemonSetInformer: daemonSetInformer,
+	}
+	err := daemonSetInformer.Informer().SetTransform(daemonSetTransform)
+	if err != nil {
+		return nil, err
+	}
+	_, err = daemonSetInformer.Informer().AddEventHandler(
+		cache.ResourceEventHandlerFuncs{
+			AddFunc:    c.daemonSetAdd,
+			UpdateFunc: c.daemonSetUpdate,
+			DeleteFunc: c.daemonSetDelete,
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func NewNamespaceLoggingController(name string, informerFactory metadatainformer.SharedInformerFactory) (*NamespaceLoggingController, error) {
+	namespaceInformer := informerFactory.ForResource(corev1.SchemeGroupVersion.WithResource("namespaces"))
 	c := &NamespaceLoggingController{
+		name:              name,
 		informerFactory:   informerFactory,
 		namespaceInformer: namespaceInformer,
 	}
-	_, err := namespaceInformer.Informer().AddEventHandler(
+	err := namespaceInformer.Informer().SetTransform(metadataTransform)
+	if err != nil {
+		return nil, err
+	}
+	_, err = namespaceInformer.Informer().AddEventHandler(
 		cache.ResourceEventHandlerFuncs{
 			AddFunc:    c.namespaceAdd,
 			UpdateFunc: c.namespaceUpdate,
@@ -244,26 +875,386 @@ func NewNamespaceLoggingController(informerFactory informers.SharedInformerFacto
 	return c, nil
 }
 
+// initWatchedNamespaces turns the --namespaces and --namespace-selector
+// flags into WatchedNamespaces.  With neither flag set, WatchedNamespaces
+// stays nil and the whole cluster is watched.
+func initWatchedNamespaces(App *AppX) error {
+	self := "initWatchedNamespaces"
+	if len(App.WatchNamespaces) == 0 && App.NamespaceSelector == "" {
+		return nil
+	}
+	WatchedNamespaces = make(map[string]bool)
+	for _, nsName := range App.WatchNamespaces {
+		WatchedNamespaces[nsName] = true
+	}
+	if App.NamespaceSelector != "" {
+		if _, err := labels.Parse(App.NamespaceSelector); err != nil {
+			return fmt.Errorf("%s: invalid namespace selector: %q: %v", self, App.NamespaceSelector, err)
+		}
+		namespaceList, err := App.Clientset.CoreV1().Namespaces().List(context.TODO(),
+			metav1.ListOptions{LabelSelector: App.NamespaceSelector})
+		if err != nil {
+			return fmt.Errorf("%s: call to %q failed: %#v", self, "(clientset).CoreV1().Namespaces().List()", err)
+		}
+		for _, namespace := range namespaceList.Items {
+			WatchedNamespaces[namespace.Name] = true
+		}
+	}
+	klog.Infof("%s: watching namespaces: %v", self, WatchedNamespaces)
+	return nil
+}
+
+// informerResources maps the resources that informers are named after,
+// see informerName, to the API group and resource they list and watch.
+var informerResources = map[string]schema.GroupResource{
+	"namespaces":     {Resource: "namespaces"},
+	"deployments":    {Group: "apps", Resource: "deployments"},
+	"statefulsets":   {Group: "apps", Resource: "statefulsets"},
+	"daemonsets":     {Group: "apps", Resource: "daemonsets"},
+	"replicasets":    {Group: "apps", Resource: "replicasets"},
+	"pods":           {Resource: "pods"},
+	"hpas":           {Group: "autoscaling", Resource: "horizontalpodautoscalers"},
+	"services":       {Resource: "services"},
+	"endpointslices": {Group: "discovery.k8s.io", Resource: "endpointslices"},
+	"ingresses":      {Group: "networking.k8s.io", Resource: "ingresses"},
+	"jobs":           {Group: "batch", Resource: "jobs"},
+	"cronjobs":       {Group: "batch", Resource: "cronjobs"},
+	"pdbs":           {Group: "policy", Resource: "poddisruptionbudgets"},
+	"events":         {Resource: "events"},
+	"resourcequotas": {Resource: "resourcequotas"},
+	"limitranges":    {Resource: "limitranges"},
+	"nodes":          {Resource: "nodes"},
+}
+
+var (
+	informerPermissionsLock sync.Mutex
+	informerPermissions     = make(map[string]bool)
+)
+
+// informerPermitted asks the API server whether this server may list and
+// watch resource, a key of informerResources, in namespace nsName, or
+// cluster-wide when nsName is metav1.NamespaceAll.  Answers are
+// remembered, and a cluster-wide permission answers for every namespace.
+func informerPermitted(App *AppX, resource string, nsName string) bool {
+	self := "informerPermitted"
+	if nsName != metav1.NamespaceAll && informerPermitted(App, resource, metav1.NamespaceAll) {
+		return true
+	}
+	informerPermissionsLock.Lock()
+	defer informerPermissionsLock.Unlock()
+	key := informerName(resource, nsName)
+	if allowed, ok := informerPermissions[key]; ok {
+		return allowed
+	}
+	groupResource := informerResources[resource]
+	allowed := true
+	for _, verb := range []string{"list", "watch"} {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{Verb: verb, Namespace: nsName,
+					Group: groupResource.Group, Resource: groupResource.Resource},
+			},
+		}
+		review, err := App.Clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(context.TODO(), review, metav1.CreateOptions{})
+		if err != nil {
+			klog.Errorf("%s: call to %q failed: %#v", self, "(clientset).AuthorizationV1().SelfSubjectAccessReviews().Create()", err)
+			allowed = false
+			break
+		}
+		if !review.Status.Allowed {
+			allowed = false
+			break
+		}
+	}
+	informerPermissions[key] = allowed
+	return allowed
+}
+
+// informersPermitted reports whether the informers for resources may run
+// in namespace nsName.  If one may not, none of them run: they are logged
+// and recorded as skipped, so that the endpoints reading them answer 403.
+func informersPermitted(App *AppX, nsName string, resources ...string) bool {
+	self := "informersPermitted"
+	denied := make([]string, 0)
+	for _, resource := range resources {
+		if !informerPermitted(App, resource, nsName) {
+			denied = append(denied, resource)
+		}
+	}
+	if len(denied) == 0 {
+		return true
+	}
+	InformersLock.Lock()
+	if App.InformersSkipped == nil {
+		App.InformersSkipped = make(map[string]bool)
+	}
+	for _, resource := range resources {
+		App.InformersSkipped[informerName(resource, nsName)] = true
+	}
+	InformersLock.Unlock()
+	klog.Infof("%s: list/watch of %v not permitted in namespace %q; skipping informers for %v",
+		self, denied, nsName, resources)
+	return false
+}
+
+// InformerSkipped reports whether the informer for resource that would see
+// namespace nsName was skipped because it may not list and watch.
+func InformerSkipped(App *AppX, resource string, nsName string) bool {
+	factoryNamespace := metav1.NamespaceAll
+	if WatchedNamespaces != nil {
+		factoryNamespace = nsName
+	}
+	InformersLock.RLock()
+	defer InformersLock.RUnlock()
+	return App.InformersSkipped[informerName(resource, factoryNamespace)]
+}
+
+// informerSyncContext returns the context to wait for informers to sync
+// in: it is done when stopCh is closed or, if timeout is not zero, once
+// timeout has passed.
+func informerSyncContext(stopCh <-chan struct{}, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout == 0 {
+		return context.WithCancel(wait.ContextForChannel(stopCh))
+	}
+	return context.WithTimeout(wait.ContextForChannel(stopCh), timeout)
+}
+
+// informerSyncWait waits for synced within informerSyncContext.
+func informerSyncWait(stopCh <-chan struct{}, timeout time.Duration, synced ...cache.InformerSynced) bool {
+	ctx, cancel := informerSyncContext(stopCh, timeout)
+	defer cancel()
+	return cache.WaitForCacheSync(ctx.Done(), synced...)
+}
+
+// seedWatchedNamespaces stands in for the namespace informer when it can't
+// be run, by caching each watched namespace as read by a Get.  A namespace
+// that can't be read is not cached, so it is served as not found, and as
+// nothing watches them the cached namespaces don't change after startup.
+func seedWatchedNamespaces(App *AppX) {
+	NamespacesLock.Lock()
+	defer NamespacesLock.Unlock()
+	self := "seedWatchedNamespaces"
+	for nsName := range WatchedNamespaces {
+		namespace, err := App.Clientset.CoreV1().Namespaces().Get(context.TODO(), nsName, metav1.GetOptions{})
+		if err != nil {
+			klog.Errorf("%s: call to %q failed: %q: %#v", self, "(clientset).CoreV1().Namespaces().Get()", nsName, err)
+			continue
+		}
+		ni := namespaceItemFrom(&metav1.PartialObjectMetadata{ObjectMeta: namespace.ObjectMeta})
+		if namespace.Status.Phase == corev1.NamespaceTerminating {
+			ni.Phase = string(corev1.NamespaceTerminating)
+		}
+		err = LiveCache.NamespacePut(ni)
+		if err != nil {
+			klog.Errorf("%s: call to %q failed: %#v", self, "(Cache).NamespacePut", err)
+			continue
+		}
+		changeNamespaceRecord(ChangeAdd, ni, nsName)
+		klog.Infof("%s: created: %q", self, nsName)
+	}
+}
+
+func informerRegister(App *AppX, name string, informer cache.SharedIndexInformer) {
+	InformersLock.Lock()
+	defer InformersLock.Unlock()
+	if App.Informers == nil {
+		App.Informers = make(map[string]cache.SharedIndexInformer)
+	}
+	App.Informers[name] = informer
+}
+
+// informerLookup returns the informer registered as name, if any.
+func informerLookup(App *AppX, name string) (cache.SharedIndexInformer, bool) {
+	InformersLock.RLock()
+	defer InformersLock.RUnlock()
+	informer, ok := App.Informers[name]
+	return informer, ok
+}
+
+// informersCopy returns a copy of the informers registered so far, for
+// callers that range over them.
+func informersCopy(App *AppX) map[string]cache.SharedIndexInformer {
+	InformersLock.RLock()
+	defer InformersLock.RUnlock()
+	copied := make(map[string]cache.SharedIndexInformer, len(App.Informers))
+	for name, informer := range App.Informers {
+		copied[name] = informer
+	}
+	return copied
+}
+
+// auxInformerAdds lists the functions that add the auxiliary informers of
+// a namespace, with the resources each must be permitted to list and watch.
+var auxInformerAdds = []struct {
+	name      string
+	resources []string
+	add       func(App *AppX, nsName string, factory informers.SharedInformerFactory) error
+}{
+	{"podInformersAdd", []string{"replicasets", "pods"}, podInformersAdd},
+	{"hpaInformerAdd", []string{"hpas"}, hpaInformerAdd},
+	{"serviceInformersAdd", []string{"services", "endpointslices"}, serviceInformersAdd},
+	{"ingressInformerAdd", []string{"ingresses"}, ingressInformerAdd},
+	{"jobInformersAdd", []string{"jobs", "cronjobs"}, jobInformersAdd},
+	{"pdbInformerAdd", []string{"pdbs"}, pdbInformerAdd},
+	{"eventInformerAdd", []string{"events"}, eventInformerAdd},
+	{"quotaInformersAdd", []string{"resourcequotas", "limitranges"}, quotaInformersAdd},
+}
+
 func initInformers(App *AppX) error {
 	self := "initInformers"
-	factory := informers.NewSharedInformerFactory(App.Clientset, time.Hour*24)
-	namespaceLoggingController, err := NewNamespaceLoggingController(factory)
-	if err != nil {
-		return fmt.Errorf("%s: call to %q failed: %#v", self, "clientcmd.BuildConfigFromFlags", err)
+	resync := App.ResyncPeriod
+	App.Stop = make(chan struct{})
+	if App.Role == RoleAPI {
+		klog.Infof("%s: role %q runs no informers", self, App.Role)
+		return nil
 	}
-	deploymentLoggingController, err := NewDeploymentLoggingController(factory)
-	if err != nil {
-		return fmt.Errorf("%s: call to %q failed: %#v", self, "clientcmd.BuildConfigFromFlags", err)
+	App.Factories = make(map[string]informers.SharedInformerFactory)
+	var namespaceFactory metadatainformer.SharedInformerFactory
+	if WatchedNamespaces == nil {
+		if !informersPermitted(App, metav1.NamespaceAll, "namespaces") {
+			return fmt.Errorf("%s: namespaces may not be listed and watched cluster-wide; restrict the watch with --namespaces", self)
+		}
+		namespaceFactory = metadatainformer.NewSharedInformerFactory(App.MetadataClient, resync)
+		App.Factories[metav1.NamespaceAll] = informers.NewSharedInformerFactory(App.Clientset, resync)
+	} else {
+		for nsName := range WatchedNamespaces {
+			App.Factories[nsName] = informers.NewSharedInformerFactoryWithOptions(App.Clientset, resync,
+				informers.WithNamespace(nsName))
+		}
+		if informersPermitted(App, metav1.NamespaceAll, "namespaces") {
+			namespaceFactory = metadatainformer.NewSharedInformerFactory(App.MetadataClient, resync)
+		} else {
+			seedWatchedNamespaces(App)
+		}
 	}
-	App.Stop = make(chan struct{})
-	err = namespaceLoggingController.Run(App.Stop)
+	if namespaceFactory != nil {
+		namespaceLoggingController, err := NewNamespaceLoggingController("namespaces", namespaceFactory)
+		if err != nil {
+			return fmt.Errorf("%s: call to %q failed: %#v", self, "NewNamespaceLoggingController", err)
+		}
+		informerRegister(App, namespaceLoggingController.name, namespaceLoggingController.namespaceInformer.Informer())
+		err = namespaceLoggingController.Run(App.Stop, App.InformerSyncTimeout)
+		if err != nil {
+			return fmt.Errorf("%s: call to %q failed: %#v", self, "(NamespaceLoggingController).Run", err)
+		}
+	}
+	for nsName, factory := range App.Factories {
+		if informersPermitted(App, nsName, "deployments") {
+			deploymentLoggingController, err := NewDeploymentLoggingController(informerName("deployments", nsName), factory)
+			if err != nil {
+				return fmt.Errorf("%s: call to %q failed: %#v", self, "NewDeploymentLoggingController", err)
+			}
+			informerRegister(App, deploymentLoggingController.name, deploymentLoggingController.deploymentInformer.Informer())
+			err = deploymentLoggingController.Run(App.Stop, App.InformerSyncTimeout)
+			if err != nil {
+				return fmt.Errorf("%s: call to %q failed: %#v", self, "(DeploymentLoggingController).Run", err)
+			}
+		}
+		if informersPermitted(App, nsName, "statefulsets") {
+			statefulSetLoggingController, err := NewStatefulSetLoggingController(informerName("statefulsets", nsName), factory)
+			if err != nil {
+				return fmt.Errorf("%s: call to %q failed: %#v", self, "NewStatefulSetLoggingController", err)
+			}
+			informerRegister(App, statefulSetLoggingController.name, statefulSetLoggingController.statefulSetInformer.Informer())
+			err = statefulSetLoggingController.Run(App.Stop, App.InformerSyncTimeout)
+			if err != nil {
+				return fmt.Errorf("%s: call to %q failed: %#v", self, "(StatefulSetLoggingController).Run", err)
+			}
+		}
+		if informersPermitted(App, nsName, "daemonsets") {
+			daemonSetLoggingController, err := NewDaemonSetLoggingController(informerName("daemonsets", nsName), factory)
+			if err != nil {
+				return fmt.Errorf("%s: call to %q failed: %#v", self, "NewDaemonSetLoggingController", err)
+			}
+			informerRegister(App, daemonSetLoggingController.name, daemonSetLoggingController.daemonSetInformer.Informer())
+			err = daemonSetLoggingController.Run(App.Stop, App.InformerSyncTimeout)
+			if err != nil {
+				return fmt.Errorf("%s: call to %q failed: %#v", self, "(DaemonSetLoggingController).Run", err)
+			}
+		}
+		for _, aux := range auxInformerAdds {
+			if !informersPermitted(App, nsName, aux.resources...) {
+				continue
+			}
+			err := aux.add(App, nsName, factory)
+			if err != nil {
+				return fmt.Errorf("%s: call to %q failed: %#v", self, aux.name, err)
+			}
+		}
+		err := auxInformersRun(App, factory)
+		if err != nil {
+			return fmt.Errorf("%s: call to %q failed: %#v", self, "auxInformersRun", err)
+		}
+	}
+	err := nodeInformerAdd(App)
 	if err != nil {
-		return fmt.Errorf("%s: call to %q failed: %#v", self, "clientcmd.BuildConfigFromFlags", err)
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "nodeInformerAdd", err)
 	}
-	err = deploymentLoggingController.Run(App.Stop)
+	cachePrune(App)
+	err = cacheSyncedSet()
 	if err != nil {
-		return fmt.Errorf("%s: call to %q failed: %#v", self, "clientcmd.BuildConfigFromFlags", err)
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "cacheSyncedSet", err)
 	}
+	klog.Infof("%s: cache synced", self)
 	InformersSavedApp = App
 	return nil
 }
+
+// auxInformerAdd sets up an auxiliary informer: one with no handlers that
+// does not feed the cache, and whose store endpoints read directly.
+// Auxiliary informers run only in roles all and watcher.
+func auxInformerAdd(App *AppX, name string, informer cache.SharedIndexInformer, transform cache.TransformFunc,
+	indexers cache.Indexers) error {
+	self := "auxInformerAdd"
+	klog.Infof("%s: name=%q", self, name)
+	if len(indexers) > 0 {
+		err := informer.AddIndexers(indexers)
+		if err != nil {
+			return fmt.Errorf("%s: call to %q failed: %#v", self, "(SharedIndexInformer).AddIndexers", err)
+		}
+	}
+	err := informer.SetTransform(transform)
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "(SharedIndexInformer).SetTransform", err)
+	}
+	informerRegister(App, name, informer)
+	return nil
+}
+
+// auxInformersRun starts the informers added to factory since it was last
+// started and waits for them to sync, for at most --informer-sync-timeout.
+func auxInformersRun(App *AppX, factory informers.SharedInformerFactory) error {
+	factory.Start(App.Stop)
+	ctx, cancel := informerSyncContext(App.Stop, App.InformerSyncTimeout)
+	defer cancel()
+	for informerType, synced := range factory.WaitForCacheSync(ctx.Done()) {
+		if !synced {
+			return fmt.Errorf("failed to sync: %v", informerType)
+		}
+	}
+	return nil
+}
+
+// informerGet returns the informer for resource that sees namespace
+// nsName, or nil before the informers have synced or in role api.
+func informerGet(resource string, nsName string) cache.SharedIndexInformer {
+	App := InformersSavedApp
+	if App == nil {
+		return nil
+	}
+	factoryNamespace := metav1.NamespaceAll
+	if WatchedNamespaces != nil {
+		factoryNamespace = nsName
+	}
+	informer, _ := informerLookup(App, informerName(resource, factoryNamespace))
+	return informer
+}
+
+// informerName names a per-namespace informer, e.g. "deployments/personal",
+// or just "deployments" when it watches the whole cluster.
+func informerName(resource string, nsName string) string {
+	if nsName == metav1.NamespaceAll {
+		return resource
+	}
+	return resource + "/" + nsName
+}
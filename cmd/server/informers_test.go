@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestSeedWatchedNamespaces(t *testing.T) {
+	savedCache, savedWatched := LiveCache, WatchedNamespaces
+	defer func() { LiveCache, WatchedNamespaces = savedCache, savedWatched }()
+	LiveCache = newMemoryCache()
+	WatchedNamespaces = map[string]bool{"active": true, "leaving": true, "missing": true}
+	created := metav1.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	App := &AppX{Clientset: fake.NewSimpleClientset(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "active", Labels: map[string]string{"team": "a"},
+			CreationTimestamp: created}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "leaving"},
+			Status: corev1.NamespaceStatus{Phase: corev1.NamespaceTerminating}},
+	)}
+	seedWatchedNamespaces(App)
+
+	ni, err := LiveCache.NamespaceGet("active")
+	if err != nil || ni == nil {
+		t.Fatalf("NamespaceGet(%q) = %v, %v; want the namespace", "active", ni, err)
+	}
+	if ni.Labels["team"] != "a" || !ni.CreationTime.Equal(created.Time) || namespaceTerminating(ni) {
+		t.Errorf("NamespaceGet(%q) = %+v; want labels, creation time and no phase from the API", "active", ni)
+	}
+	ni, err = LiveCache.NamespaceGet("leaving")
+	if err != nil || ni == nil {
+		t.Fatalf("NamespaceGet(%q) = %v, %v; want the namespace", "leaving", ni, err)
+	}
+	if !namespaceTerminating(ni) {
+		t.Errorf("NamespaceGet(%q) phase = %q, want %q", "leaving", ni.Phase, corev1.NamespaceTerminating)
+	}
+	ni, err = LiveCache.NamespaceGet("missing")
+	if err != nil || ni != nil {
+		t.Errorf("NamespaceGet(%q) = %v, %v; want nothing cached", "missing", ni, err)
+	}
+}
+
+// TestInformersConcurrentAccess registers informers while /debug/cache and
+// lookups read them, as happens when the HTTP server starts before
+// initInformers.  Run with -race.
+func TestInformersConcurrentAccess(t *testing.T) {
+	App := &AppX{Role: RoleAll}
+	factory := informers.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	informer := factory.Apps().V1().Deployments().Informer()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			informerRegister(App, informerName("deployments", fmt.Sprintf("ns%d", i)), informer)
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		if _, err := CacheIntrospect(App); err != nil {
+			t.Fatalf("CacheIntrospect() failed: %v", err)
+		}
+		informerLookup(App, informerName("deployments", "ns0"))
+	}
+	<-done
+	if got := len(informersCopy(App)); got != 200 {
+		t.Errorf("informersCopy() has %d informers, want 200", got)
+	}
+}
+
+// TestDeleteTombstones hands the delete handlers both the deleted object
+// and the tombstone an informer passes when it missed the delete.
+func TestDeleteTombstones(t *testing.T) {
+	savedCache, savedWatched := LiveCache, WatchedNamespaces
+	defer func() { LiveCache, WatchedNamespaces = savedCache, savedWatched }()
+	WatchedNamespaces = nil
+	deploymentController := &DeploymentLoggingController{name: "deployments"}
+	statefulSetController := &StatefulSetLoggingController{name: "statefulsets"}
+	daemonSetController := &DaemonSetLoggingController{name: "daemonsets"}
+	namespaceController := &NamespaceLoggingController{name: "namespaces"}
+	objectMeta := metav1.ObjectMeta{Name: "web", Namespace: "personal"}
+	tests := []struct {
+		name    string
+		handler func(obj interface{})
+		obj     interface{}
+		gone    func(ni *NamespaceItem) bool
+	}{
+		{name: "deployment", handler: deploymentController.deploymentDelete,
+			obj:  &appsv1.Deployment{ObjectMeta: objectMeta},
+			gone: func(ni *NamespaceItem) bool { return ni != nil && ni.Deployments["web"] == nil }},
+		{name: "statefulset", handler: statefulSetController.statefulSetDelete,
+			obj:  &appsv1.StatefulSet{ObjectMeta: objectMeta},
+			gone: func(ni *NamespaceItem) bool { return ni != nil && ni.StatefulSets["web"] == nil }},
+		{name: "daemonset", handler: daemonSetController.daemonSetDelete,
+			obj:  &appsv1.DaemonSet{ObjectMeta: objectMeta},
+			gone: func(ni *NamespaceItem) bool { return ni != nil && ni.DaemonSets["web"] == nil }},
+		{name: "namespace", handler: namespaceController.namespaceDelete,
+			obj:  &metav1.PartialObjectMetadata{ObjectMeta: metav1.ObjectMeta{Name: "personal"}},
+			gone: func(ni *NamespaceItem) bool { return ni == nil }},
+	}
+	for _, tt := range tests {
+		for _, tombstone := range []bool{false, true} {
+			t.Run(fmt.Sprintf("%s tombstone=%v", tt.name, tombstone), func(t *testing.T) {
+				LiveCache = newMemoryCacheFrom(NamespaceMap{"personal": {Name: "personal",
+					Deployments:  DeploymentMap{"web": {Name: "web", Replicas: 1}},
+					StatefulSets: StatefulSetMap{"web": {Name: "web", Replicas: 1}},
+					DaemonSets:   DaemonSetMap{"web": {Name: "web"}}}})
+				obj := tt.obj
+				if tombstone {
+					obj = cache.DeletedFinalStateUnknown{Key: "personal/web", Obj: tt.obj}
+				}
+				tt.handler(obj)
+				ni, _ := LiveCache.NamespaceGet("personal")
+				if !tt.gone(ni) {
+					t.Errorf("%s delete left the cache entry: %+v", tt.name, ni)
+				}
+			})
+		}
+	}
+	// A tombstone of an unexpected type is rejected, not a panic.
+	deploymentController.deploymentDelete(cache.DeletedFinalStateUnknown{Key: "personal/web", Obj: "web"})
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	klog "k8s.io/klog/v2"
+)
+
+const (
+	ingressServiceIndex = "ingressService"
+	// ingressClassAnnotation is how ingresses chose their class before
+	// spec.ingressClassName.
+	ingressClassAnnotation = "kubernetes.io/ingress.class"
+)
+
+// IngressPathItem is one path of an ingress rule, or its default backend,
+// with the deployments whose pod template labels match the selector of
+// the backend service.  Host is "*" for a rule without a host and for the
+// default backend.  Resource names a backend that is not a service.
+type IngressPathItem struct {
+	Host           string   `json:"host"`
+	Path           string   `json:"path,omitempty"`
+	PathType       string   `json:"path_type,omitempty"`
+	DefaultBackend bool     `json:"default_backend,omitempty"`
+	TLS            bool     `json:"tls"`
+	Service        string   `json:"service,omitempty"`
+	Port           string   `json:"port,omitempty"`
+	Resource       string   `json:"resource,omitempty"`
+	ServiceMissing bool     `json:"service_missing,omitempty"`
+	Deployments    []string `json:"deployments"`
+}
+
+type IngressItem struct {
+	Name      string            `json:"ingress"`
+	Class     string            `json:"class,omitempty"`
+	Addresses []string          `json:"addresses,omitempty"`
+	Paths     []IngressPathItem `json:"paths"`
+}
+
+// DeploymentHostItem is a host and path that reaches a deployment, and
+// the ingress and service it goes through.
+type DeploymentHostItem struct {
+	Host     string `json:"host"`
+	Path     string `json:"path,omitempty"`
+	PathType string `json:"path_type,omitempty"`
+	TLS      bool   `json:"tls"`
+	Ingress  string `json:"ingress"`
+	Service  string `json:"service"`
+	Port     string `json:"port,omitempty"`
+}
+
+// ingressServiceIndexFunc indexes ingresses by the namespace and name of
+// each service they send traffic to.
+func ingressServiceIndexFunc(obj interface{}) ([]string, error) {
+	ingress, ok := obj.(*networkingv1.Ingress)
+	if !ok {
+		return []string{}, nil
+	}
+	found := make(map[string]bool)
+	keys := make([]string, 0)
+	for _, backend := range ingressBackends(ingress) {
+		if backend.Service == nil {
+			continue
+		}
+		key := ingress.Namespace + "/" + backend.Service.Name
+		if !found[key] {
+			found[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func ingressInformerAdd(App *AppX, nsName string, factory informers.SharedInformerFactory) error {
+	self := "ingressInformerAdd"
+	klog.Infof("%s: entry", self)
+	err := auxInformerAdd(App, informerName("ingresses", nsName), factory.Networking().V1().Ingresses().Informer(),
+		ingressTransform, cache.Indexers{ingressServiceIndex: ingressServiceIndexFunc})
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "auxInformerAdd", err)
+	}
+	return nil
+}
+
+// IngressListGet returns the ingresses of namespace nsName, sorted by
+// name, with each path resolved to its service and deployments.
+func IngressListGet(nsName string) ([]IngressItem, error) {
+	self := "IngressListGet"
+	klog.Infof("%s: entry", self)
+	ingressInformer := informerGet("ingresses", nsName)
+	if ingressInformer == nil {
+		return nil, fmt.Errorf("%s: informers not available for namespace: %q", self, nsName)
+	}
+	serviceDeployments, err := serviceDeploymentsGet(nsName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "serviceDeploymentsGet", err)
+	}
+	objs, err := ingressInformer.GetIndexer().ByIndex(cache.NamespaceIndex, nsName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Indexer).ByIndex", err)
+	}
+	ingressList := make([]IngressItem, 0, len(objs))
+	for _, obj := range objs {
+		ingressList = append(ingressList, ingressItemFrom(obj.(*networkingv1.Ingress), serviceDeployments))
+	}
+	sort.Slice(ingressList, func(i, j int) bool { return ingressList[i].Name < ingressList[j].Name })
+	return ingressList, nil
+}
+
+// DeploymentHostsGet returns the hosts and paths that reach deployment
+// nsName/dName: those of the ingresses whose backend service selects its
+// pods, sorted by host, path and ingress.
+func DeploymentHostsGet(nsName string, dName string) ([]DeploymentHostItem, error) {
+	self := "DeploymentHostsGet"
+	klog.Infof("%s: entry", self)
+	ingressInformer := informerGet("ingresses", nsName)
+	if ingressInformer == nil {
+		return nil, fmt.Errorf("%s: informers not available for namespace: %q", self, nsName)
+	}
+	serviceDeployments, err := serviceDeploymentsGet(nsName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "serviceDeploymentsGet", err)
+	}
+	ingresses := make(map[string]*networkingv1.Ingress)
+	for sName, dNames := range serviceDeployments {
+		if !slices.Contains(dNames, dName) {
+			continue
+		}
+		objs, err := ingressInformer.GetIndexer().ByIndex(ingressServiceIndex, nsName+"/"+sName)
+		if err != nil {
+			return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Indexer).ByIndex", err)
+		}
+		for _, obj := range objs {
+			ingress := obj.(*networkingv1.Ingress)
+			ingresses[ingress.Name] = ingress
+		}
+	}
+	hostList := make([]DeploymentHostItem, 0)
+	for _, ingress := range ingresses {
+		for _, path := range ingressItemFrom(ingress, serviceDeployments).Paths {
+			if slices.Contains(path.Deployments, dName) {
+				hostList = append(hostList, DeploymentHostItem{Host: path.Host, Path: path.Path, PathType: path.PathType,
+					TLS: path.TLS, Ingress: ingress.Name, Service: path.Service, Port: path.Port})
+			}
+		}
+	}
+	sort.Slice(hostList, func(i, j int) bool {
+		if hostList[i].Host != hostList[j].Host {
+			return hostList[i].Host < hostList[j].Host
+		}
+		if hostList[i].Path != hostList[j].Path {
+			return hostList[i].Path < hostList[j].Path
+		}
+		return hostList[i].Ingress < hostList[j].Ingress
+	})
+	return hostList, nil
+}
+
+// serviceDeploymentsGet maps each service of namespace nsName to the
+// sorted names of the deployments whose pod template labels match its
+// selector.  Services without a selector match no deployment.
+func serviceDeploymentsGet(nsName string) (map[string][]string, error) {
+	self := "serviceDeploymentsGet"
+	serviceInformer, deploymentInformer := informerGet("services", nsName), informerGet("deployments", nsName)
+	if serviceInformer == nil || deploymentInformer == nil {
+		return nil, fmt.Errorf("%s: informers not available for namespace: %q", self, nsName)
+	}
+	services, err := serviceInformer.GetIndexer().ByIndex(cache.NamespaceIndex, nsName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Indexer).ByIndex", err)
+	}
+	deployments, err := deploymentInformer.GetIndexer().ByIndex(cache.NamespaceIndex, nsName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Indexer).ByIndex", err)
+	}
+	serviceDeployments := make(map[string][]string, len(services))
+	for _, obj := range services {
+		service := obj.(*corev1.Service)
+		dNames := make([]string, 0)
+		if len(service.Spec.Selector) > 0 {
+			selector := labels.SelectorFromSet(service.Spec.Selector)
+			for _, obj := range deployments {
+				deployment := obj.(*appsv1.Deployment)
+				if selector.Matches(labels.Set(deployment.Spec.Template.Labels)) {
+					dNames = append(dNames, deployment.Name)
+				}
+			}
+		}
+		sort.Strings(dNames)
+		serviceDeployments[service.Name] = dNames
+	}
+	return serviceDeployments, nil
+}
+
+// ingressBackends returns the backends of ingress: that of each rule path,
+// then the default backend.
+func ingressBackends(ingress *networkingv1.Ingress) []networkingv1.IngressBackend {
+	backends := make([]networkingv1.IngressBackend, 0)
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			backends = append(backends, path.Backend)
+		}
+	}
+	if ingress.Spec.DefaultBackend != nil {
+		backends = append(backends, *ingress.Spec.DefaultBackend)
+	}
+	return backends
+}
+
+// ingressItemFrom resolves the paths of ingress, in the order of its rules
+// and then its default backend, through serviceDeployments.
+func ingressItemFrom(ingress *networkingv1.Ingress, serviceDeployments map[string][]string) IngressItem {
+	ii := IngressItem{Name: ingress.Name, Class: ingress.Annotations[ingressClassAnnotation],
+		Paths: make([]IngressPathItem, 0)}
+	if ingress.Spec.IngressClassName != nil {
+		ii.Class = *ingress.Spec.IngressClassName
+	}
+	for _, lb := range ingress.Status.LoadBalancer.Ingress {
+		if lb.Hostname != "" {
+			ii.Addresses = append(ii.Addresses, lb.Hostname)
+		} else if lb.IP != "" {
+			ii.Addresses = append(ii.Addresses, lb.IP)
+		}
+	}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		host := rule.Host
+		if host == "" {
+			host = "*"
+		}
+		for _, path := range rule.HTTP.Paths {
+			ipi := ingressPathItemFrom(ingress, host, path.Backend, serviceDeployments)
+			ipi.Path = path.Path
+			if path.PathType != nil {
+				ipi.PathType = string(*path.PathType)
+			}
+			ii.Paths = append(ii.Paths, ipi)
+		}
+	}
+	if ingress.Spec.DefaultBackend != nil {
+		ipi := ingressPathItemFrom(ingress, "*", *ingress.Spec.DefaultBackend, serviceDeployments)
+		ipi.DefaultBackend = true
+		ii.Paths = append(ii.Paths, ipi)
+	}
+	return ii
+}
+
+func ingressPathItemFrom(ingress *networkingv1.Ingress, host string, backend networkingv1.IngressBackend,
+	serviceDeployments map[string][]string) IngressPathItem {
+	ipi := IngressPathItem{Host: host, TLS: ingressHostTLS(ingress, host), Deployments: []string{}}
+	switch {
+	case backend.Service != nil:
+		ipi.Service = backend.Service.Name
+		if backend.Service.Port.Name != "" {
+			ipi.Port = backend.Service.Port.Name
+		} else {
+			ipi.Port = strconv.Itoa(int(backend.Service.Port.Number))
+		}
+		dNames, ok := serviceDeployments[backend.Service.Name]
+		if !ok {
+			ipi.ServiceMissing = true
+		} else {
+			ipi.Deployments = dNames
+		}
+	case backend.Resource != nil:
+		ipi.Resource = backend.Resource.Kind + "/" + backend.Resource.Name
+	}
+	return ipi
+}
+
+// ingressHostTLS reports whether one of the TLS entries of ingress covers
+// host, either by name or by a wildcard such as "*.example.com", which
+// covers a single label.  An entry without hosts covers every host.
+func ingressHostTLS(ingress *networkingv1.Ingress, host string) bool {
+	for _, tls := range ingress.Spec.TLS {
+		if len(tls.Hosts) == 0 {
+			return true
+		}
+		for _, tlsHost := range tls.Hosts {
+			if tlsHost == host {
+				return true
+			}
+			if suffix, ok := strings.CutPrefix(tlsHost, "*."); ok && host != "*" {
+				if _, rest, ok := strings.Cut(host, "."); ok && rest == suffix {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
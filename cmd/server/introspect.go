@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klog "k8s.io/klog/v2"
+)
+
+// InformerCacheCounts are the cache entries within an informer's reach.
+type InformerCacheCounts struct {
+	Namespaces   int `json:"namespaces"`
+	Deployments  int `json:"deployments"`
+	StatefulSets int `json:"statefulsets"`
+	DaemonSets   int `json:"daemonsets"`
+}
+
+// InformerStats counts what an informer's event handlers did with the
+// events they were handed.  Rejected events are those a handler dropped
+// because they disagreed with the cache, keyed by reason; handler errors
+// are failed cache calls, keyed by call.  Informers that feed the cache
+// carry its counts; auxiliary informers, which don't, carry instead the
+// number of objects in their own store.
+type InformerStats struct {
+	Informer string `json:"informer"`
+	*InformerCacheCounts
+	StoreObjects            *int           `json:"store_objects,omitempty"`
+	HasSynced               bool           `json:"has_synced"`
+	LastSyncResourceVersion string         `json:"last_sync_resource_version"`
+	LastEventTime           *time.Time     `json:"last_event_time,omitempty"`
+	LastEventType           string         `json:"last_event_type,omitempty"`
+	Events                  map[string]int `json:"events"`
+	Rejected                map[string]int `json:"rejected"`
+	HandlerErrors           map[string]int `json:"handler_errors"`
+}
+
+type CacheIntrospection struct {
+	Role         string          `json:"role"`
+	CacheBackend string          `json:"cache_backend"`
+	Synced       bool            `json:"synced"`
+	Stale        bool            `json:"stale"`
+	Namespaces   int             `json:"namespaces"`
+	Deployments  int             `json:"deployments"`
+	StatefulSets int             `json:"statefulsets"`
+	DaemonSets   int             `json:"daemonsets"`
+	Informers    []InformerStats `json:"informers"`
+}
+
+var (
+	informerStats     = make(map[string]*InformerStats)
+	informerStatsLock sync.Mutex
+)
+
+// informerStatsGet returns the stats of informer name, creating them on
+// first use.  The caller must hold informerStatsLock.
+func informerStatsGet(name string) *InformerStats {
+	stats, ok := informerStats[name]
+	if !ok {
+		stats = &InformerStats{Informer: name, Events: make(map[string]int), Rejected: make(map[string]int),
+			HandlerErrors: make(map[string]int)}
+		informerStats[name] = stats
+	}
+	return stats
+}
+
+func informerEventRecord(name string, eventType string) {
+	informerStatsLock.Lock()
+	defer informerStatsLock.Unlock()
+	stats := informerStatsGet(name)
+	now := time.Now().UTC()
+	stats.LastEventTime, stats.LastEventType = &now, eventType
+	stats.Events[eventType]++
+}
+
+func informerRejectRecord(name string, reason string) {
+	informerStatsLock.Lock()
+	defer informerStatsLock.Unlock()
+	informerStatsGet(name).Rejected[reason]++
+}
+
+func informerErrorRecord(name string, call string) {
+	informerStatsLock.Lock()
+	defer informerStatsLock.Unlock()
+	informerStatsGet(name).HandlerErrors[call]++
+}
+
+// cacheInformerResources are the resources whose informers feed the cache.
+var cacheInformerResources = map[string]bool{"namespaces": true, "deployments": true, "statefulsets": true,
+	"daemonsets": true}
+
+// CacheIntrospect reports the state of the cache and of each informer.
+// Namespace and workload counts are those of the cache entries within
+// the reach of each informer that feeds the cache.
+func CacheIntrospect(App *AppX) (*CacheIntrospection, error) {
+	self := "CacheIntrospect"
+	klog.Infof("%s: entry", self)
+	ready, stale := CacheStateGet()
+	report := &CacheIntrospection{Role: App.Role, CacheBackend: App.CacheBackend, Synced: ready && !stale, Stale: stale,
+		Informers: []InformerStats{}}
+	NamespacesLock.Lock()
+	namespaces, err := cacheNamespaceMap(cacheView())
+	NamespacesLock.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "cacheNamespaceMap", err)
+	}
+	for _, namespace := range namespaces {
+		report.Namespaces++
+		report.Deployments += len(namespace.Deployments)
+		report.StatefulSets += len(namespace.StatefulSets)
+		report.DaemonSets += len(namespace.DaemonSets)
+	}
+	registered := informersCopy(App)
+	informerStatsLock.Lock()
+	defer informerStatsLock.Unlock()
+	for name, informer := range registered {
+		stats := *informerStatsGet(name)
+		stats.Events, stats.Rejected, stats.HandlerErrors =
+			mapCopy(stats.Events), mapCopy(stats.Rejected), mapCopy(stats.HandlerErrors)
+		stats.HasSynced = informer.HasSynced()
+		stats.LastSyncResourceVersion = informer.LastSyncResourceVersion()
+		resource, nsName, scoped := strings.Cut(name, "/")
+		if !cacheInformerResources[resource] {
+			storeObjects := len(informer.GetStore().ListKeys())
+			stats.StoreObjects = &storeObjects
+			report.Informers = append(report.Informers, stats)
+			continue
+		}
+		stats.InformerCacheCounts = &InformerCacheCounts{Namespaces: report.Namespaces, Deployments: report.Deployments,
+			StatefulSets: report.StatefulSets, DaemonSets: report.DaemonSets}
+		if scoped && nsName != metav1.NamespaceAll {
+			stats.InformerCacheCounts = &InformerCacheCounts{}
+			if namespace, ok := namespaces[nsName]; ok {
+				stats.Namespaces, stats.Deployments = 1, len(namespace.Deployments)
+				stats.StatefulSets, stats.DaemonSets = len(namespace.StatefulSets), len(namespace.DaemonSets)
+			}
+		}
+		report.Informers = append(report.Informers, stats)
+	}
+	sort.Slice(report.Informers, func(i, j int) bool { return report.Informers[i].Informer < report.Informers[j].Informer })
+	return report, nil
+}
+
+func mapCopy(m map[string]int) map[string]int {
+	c := make(map[string]int, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
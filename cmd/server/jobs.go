@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	klog "k8s.io/klog/v2"
+)
+
+const (
+	JobRunning   = "Running"
+	JobPending   = "Pending"
+	JobSuspended = "Suspended"
+	JobComplete  = "Complete"
+	JobFailed    = "Failed"
+
+	// jobNameLength is the longest a job name can be and still be used
+	// as the value of its pods' job-name label.
+	jobNameLength = 63
+)
+
+// JobItem is a job's progress.  CronJob names the cronjob that created
+// the job, if any.
+type JobItem struct {
+	Name           string     `json:"job"`
+	CronJob        string     `json:"cronjob,omitempty"`
+	Status         string     `json:"status"`
+	Completions    *int       `json:"completions,omitempty"`
+	Active         int        `json:"active"`
+	Succeeded      int        `json:"succeeded"`
+	Failed         int        `json:"failed"`
+	StartTime      *time.Time `json:"start_time,omitempty"`
+	CompletionTime *time.Time `json:"completion_time,omitempty"`
+}
+
+type CronJobItem struct {
+	Name               string     `json:"cronjob"`
+	Schedule           string     `json:"schedule"`
+	TimeZone           string     `json:"time_zone,omitempty"`
+	Suspended          bool       `json:"suspended"`
+	Active             int        `json:"active"`
+	LastScheduleTime   *time.Time `json:"last_schedule_time,omitempty"`
+	LastSuccessfulTime *time.Time `json:"last_successful_time,omitempty"`
+}
+
+func jobInformersAdd(App *AppX, nsName string, factory informers.SharedInformerFactory) error {
+	self := "jobInformersAdd"
+	klog.Infof("%s: entry", self)
+	err := auxInformerAdd(App, informerName("jobs", nsName), factory.Batch().V1().Jobs().Informer(), jobTransform, nil)
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "auxInformerAdd", err)
+	}
+	err = auxInformerAdd(App, informerName("cronjobs", nsName), factory.Batch().V1().CronJobs().Informer(),
+		cronJobTransform, nil)
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "auxInformerAdd", err)
+	}
+	return nil
+}
+
+func JobListGet(nsName string) ([]JobItem, error) {
+	self := "JobListGet"
+	klog.Infof("%s: entry", self)
+	informer := informerGet("jobs", nsName)
+	if informer == nil {
+		return nil, fmt.Errorf("%s: informers not available for namespace: %q", self, nsName)
+	}
+	objs, err := informer.GetIndexer().ByIndex(cache.NamespaceIndex, nsName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Indexer).ByIndex", err)
+	}
+	jobList := make([]JobItem, 0, len(objs))
+	for _, obj := range objs {
+		jobList = append(jobList, jobItemFrom(obj.(*batchv1.Job)))
+	}
+	sort.Slice(jobList, func(i, j int) bool { return jobList[i].Name < jobList[j].Name })
+	return jobList, nil
+}
+
+func CronJobListGet(nsName string) ([]CronJobItem, error) {
+	self := "CronJobListGet"
+	klog.Infof("%s: entry", self)
+	informer := informerGet("cronjobs", nsName)
+	if informer == nil {
+		return nil, fmt.Errorf("%s: informers not available for namespace: %q", self, nsName)
+	}
+	objs, err := informer.GetIndexer().ByIndex(cache.NamespaceIndex, nsName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Indexer).ByIndex", err)
+	}
+	cronJobList := make([]CronJobItem, 0, len(objs))
+	for _, obj := range objs {
+		cronJobList = append(cronJobList, cronJobItemFrom(obj.(*batchv1.CronJob)))
+	}
+	sort.Slice(cronJobList, func(i, j int) bool { return cronJobList[i].Name < cronJobList[j].Name })
+	return cronJobList, nil
+}
+
+func jobItemFrom(job *batchv1.Job) JobItem {
+	ji := JobItem{Name: job.Name, Status: JobPending, Active: int(job.Status.Active), Succeeded: int(job.Status.Succeeded),
+		Failed: int(job.Status.Failed), StartTime: timePtrFrom(job.Status.StartTime),
+		CompletionTime: timePtrFrom(job.Status.CompletionTime)}
+	if owner := metav1.GetControllerOfNoCopy(job); owner != nil && owner.Kind == "CronJob" {
+		ji.CronJob = owner.Name
+	}
+	if job.Spec.Completions != nil {
+		completions := int(*job.Spec.Completions)
+		ji.Completions = &completions
+	}
+	if ji.Active > 0 {
+		ji.Status = JobRunning
+	}
+	for _, condition := range job.Status.Conditions {
+		if condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		switch condition.Type {
+		case batchv1.JobComplete:
+			ji.Status = JobComplete
+		case batchv1.JobFailed:
+			ji.Status = JobFailed
+		case batchv1.JobSuspended:
+			ji.Status = JobSuspended
+		}
+	}
+	return ji
+}
+
+func cronJobItemFrom(cronJob *batchv1.CronJob) CronJobItem {
+	ci := CronJobItem{Name: cronJob.Name, Schedule: cronJob.Spec.Schedule, Active: len(cronJob.Status.Active),
+		LastScheduleTime:   timePtrFrom(cronJob.Status.LastScheduleTime),
+		LastSuccessfulTime: timePtrFrom(cronJob.Status.LastSuccessfulTime)}
+	if cronJob.Spec.TimeZone != nil {
+		ci.TimeZone = *cronJob.Spec.TimeZone
+	}
+	if cronJob.Spec.Suspend != nil {
+		ci.Suspended = *cronJob.Spec.Suspend
+	}
+	return ci
+}
+
+func timePtrFrom(t *metav1.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	utc := t.UTC()
+	return &utc
+}
+
+// jobFromCronJob builds a job from cronJob's job template as
+// "kubectl create job --from=cronjob/..." does: template labels and
+// annotations are copied, the job is marked as instantiated manually, and
+// the cronjob owns it.  The name is the cronjob's with a "-manual-" and
+// time suffix, shortened if need be to fit a label value.
+func jobFromCronJob(cronJob *batchv1.CronJob, now time.Time) *batchv1.Job {
+	suffix := "-manual-" + strconv.FormatInt(now.Unix(), 36)
+	prefix := cronJob.Name
+	if len(prefix)+len(suffix) > jobNameLength {
+		prefix = strings.TrimRight(prefix[:jobNameLength-len(suffix)], "-")
+	}
+	annotations := map[string]string{"cronjob.kubernetes.io/instantiate": "manual"}
+	for k, v := range cronJob.Spec.JobTemplate.Annotations {
+		annotations[k] = v
+	}
+	return &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{APIVersion: batchv1.SchemeGroupVersion.String(), Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            prefix + suffix,
+			Namespace:       cronJob.Namespace,
+			Annotations:     annotations,
+			Labels:          cronJob.Spec.JobTemplate.Labels,
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(cronJob, batchv1.SchemeGroupVersion.WithKind("CronJob"))},
+		},
+		Spec: cronJob.Spec.JobTemplate.Spec,
+	}
+}
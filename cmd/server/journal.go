@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	klog "k8s.io/klog/v2"
+)
+
+const (
+	ChangeAdd    = "add"
+	ChangeUpdate = "update"
+	ChangeDelete = "delete"
+
+	changesLimitDefault = 1000
+)
+
+// ChangeItem is one mutation of the cache.  It carries the state after the
+// change rather than a delta, so applying a change twice is harmless.  For
+// a rename, OldName is the name before the change.
+type ChangeItem struct {
+	Seq         uint64            `json:"seq"`
+	Time        time.Time         `json:"time"`
+	Kind        string            `json:"kind"`
+	Op          string            `json:"op"`
+	Namespace   string            `json:"namespace"`
+	Deployment  string            `json:"deployment,omitempty"`
+	StatefulSet string            `json:"statefulset,omitempty"`
+	DaemonSet   string            `json:"daemonset,omitempty"`
+	Pod         string            `json:"pod,omitempty"`
+	OldName     string            `json:"old_name,omitempty"`
+	Replicas    *int              `json:"replica_count,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
+type ChangeList struct {
+	Epoch   string       `json:"epoch"`
+	Since   uint64       `json:"since"`
+	Latest  uint64       `json:"latest"`
+	More    bool         `json:"more"`
+	Changes []ChangeItem `json:"changes"`
+}
+
+// changeJournal is a ring buffer of the latest ChangeItems.  Sequence
+// numbers start at 1 and restart with the process; epoch tells runs apart.
+type changeJournal struct {
+	lock    sync.Mutex
+	epoch   string
+	entries []ChangeItem
+	next    int
+	latest  uint64
+}
+
+var (
+	ChangeJournal = newChangeJournal(10000)
+)
+
+func newChangeJournal(size int) *changeJournal {
+	return &changeJournal{
+		epoch:   strconv.FormatInt(time.Now().UnixNano(), 36),
+		entries: make([]ChangeItem, 0, size),
+	}
+}
+
+func (j *changeJournal) Record(item ChangeItem) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	if cap(j.entries) == 0 {
+		return
+	}
+	j.latest++
+	item.Seq, item.Time = j.latest, time.Now().UTC()
+	if len(j.entries) < cap(j.entries) {
+		j.entries = append(j.entries, item)
+		return
+	}
+	j.entries[j.next] = item
+	j.next = (j.next + 1) % len(j.entries)
+}
+
+func (j *changeJournal) Latest() (epoch string, latest uint64) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.epoch, j.latest
+}
+
+// Since returns up to limit changes with a sequence number above since.
+// ok is false when changes after since have already been overwritten, or
+// since is ahead of the journal, so that the caller must relist.
+func (j *changeJournal) Since(since uint64, limit int) (list ChangeList, ok bool) {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	list = ChangeList{Epoch: j.epoch, Since: since, Latest: j.latest, Changes: []ChangeItem{}}
+	oldest := j.latest - uint64(len(j.entries)) + 1
+	if since > j.latest || since+1 < oldest {
+		return list, false
+	}
+	for seq := since + 1; seq <= j.latest; seq++ {
+		if len(list.Changes) == limit {
+			list.More = true
+			break
+		}
+		list.Changes = append(list.Changes, j.entries[(j.next+int(seq-oldest))%len(j.entries)])
+	}
+	return list, true
+}
+
+func changeNamespaceRecord(op string, ni *NamespaceItem, oldName string) {
+	item := ChangeItem{Kind: "namespace", Op: op, Namespace: ni.Name, Labels: ni.Labels}
+	if oldName != ni.Name {
+		item.OldName = oldName
+	}
+	ChangeJournal.Record(item)
+}
+
+func changeDeploymentRecord(op string, nsName string, di *DeploymentItem, oldName string) {
+	item := ChangeItem{Kind: "deployment", Op: op, Namespace: nsName, Deployment: di.Name, Labels: di.Labels}
+	if op != ChangeDelete {
+		replicas := di.Replicas
+		item.Replicas = &replicas
+	}
+	if oldName != di.Name {
+		item.OldName = oldName
+	}
+	ChangeJournal.Record(item)
+}
+
+func changeStatefulSetRecord(op string, nsName string, si *StatefulSetItem) {
+	item := ChangeItem{Kind: "statefulset", Op: op, Namespace: nsName, StatefulSet: si.Name, Labels: si.Labels}
+	if op != ChangeDelete {
+		replicas := si.Replicas
+		item.Replicas = &replicas
+	}
+	ChangeJournal.Record(item)
+}
+
+// changeDaemonSetRecord records a daemonset's desired count as its
+// replica count.
+func changeDaemonSetRecord(op string, nsName string, dsi *DaemonSetItem) {
+	item := ChangeItem{Kind: "daemonset", Op: op, Namespace: nsName, DaemonSet: dsi.Name, Labels: dsi.Labels}
+	if op != ChangeDelete {
+		replicas := dsi.Desired
+		item.Replicas = &replicas
+	}
+	ChangeJournal.Record(item)
+}
+
+// changePodEvictRecord records the eviction, or deletion, of pod pName of
+// deployment nsName/dName.  No informer watches pods, so endpoint 40
+// records it once the API server has accepted it.
+func changePodEvictRecord(nsName string, dName string, pName string) {
+	ChangeJournal.Record(ChangeItem{Kind: "pod", Op: ChangeDelete, Namespace: nsName, Deployment: dName, Pod: pName})
+}
+
+// changeListFromRequest reads the since, limit and epoch query parameters.
+// A client passing the epoch of an earlier run is told to relist.
+func changeListFromRequest(r *http.Request) (since uint64, limit int, epoch string, err error) {
+	self := "changeListFromRequest"
+	query := r.URL.Query()
+	since, err = strconv.ParseUint(query.Get("since"), 10, 64)
+	if err != nil {
+		return 0, 0, "", fmt.Errorf("%s: invalid since value: %q", self, query.Get("since"))
+	}
+	limit = changesLimitDefault
+	if limitS := query.Get("limit"); limitS != "" {
+		limit, err = strconv.Atoi(limitS)
+		if err != nil || limit <= 0 || limit > changesLimitDefault {
+			return 0, 0, "", fmt.Errorf("%s: invalid limit value: %q", self, limitS)
+		}
+	}
+	return since, limit, query.Get("epoch"), nil
+}
+
+func initChangeJournal(App *AppX) error {
+	self := "initChangeJournal"
+	klog.Infof("%s: entry", self)
+	if App.ChangeJournalSize < 0 {
+		return fmt.Errorf("%s: invalid %q flag value: %d", self, "change-journal-size", App.ChangeJournalSize)
+	}
+	ChangeJournal = newChangeJournal(App.ChangeJournalSize)
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestChangeJournalSince(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		recorded int
+		since    uint64
+		limit    int
+		want     []uint64
+		wantMore bool
+		wantOK   bool
+	}{
+		{name: "empty journal", size: 3, since: 0, limit: 10, want: []uint64{}, wantOK: true},
+		{name: "disabled journal", size: 0, recorded: 2, since: 0, limit: 10, want: []uint64{}, wantOK: true},
+		{name: "all", size: 3, recorded: 3, since: 0, limit: 10, want: []uint64{1, 2, 3}, wantOK: true},
+		{name: "at head", size: 3, recorded: 3, since: 3, limit: 10, want: []uint64{}, wantOK: true},
+		{name: "past head", size: 3, recorded: 3, since: 4, limit: 10, wantOK: false},
+		{name: "past head of empty journal", size: 3, since: 1, limit: 10, wantOK: false},
+		{name: "wrapped", size: 3, recorded: 5, since: 2, limit: 10, want: []uint64{3, 4, 5}, wantOK: true},
+		{name: "wrapped tail", size: 3, recorded: 7, since: 5, limit: 10, want: []uint64{6, 7}, wantOK: true},
+		{name: "wrapped past oldest", size: 3, recorded: 5, since: 1, limit: 10, wantOK: false},
+		{name: "wrapped from zero", size: 3, recorded: 5, since: 0, limit: 10, wantOK: false},
+		{name: "wrapped at head", size: 3, recorded: 5, since: 5, limit: 10, want: []uint64{}, wantOK: true},
+		{name: "limited", size: 3, recorded: 5, since: 2, limit: 2, want: []uint64{3, 4}, wantMore: true, wantOK: true},
+		{name: "limit reached exactly", size: 3, recorded: 5, since: 3, limit: 2, want: []uint64{4, 5}, wantOK: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := newChangeJournal(tt.size)
+			for i := 0; i < tt.recorded; i++ {
+				j.Record(ChangeItem{Kind: "deployment", Op: ChangeUpdate, Namespace: "personal", Deployment: "web"})
+			}
+			list, ok := j.Since(tt.since, tt.limit)
+			if ok != tt.wantOK {
+				t.Fatalf("Since(%d, %d) ok = %v, want %v", tt.since, tt.limit, ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			got := make([]uint64, 0, len(list.Changes))
+			for _, change := range list.Changes {
+				got = append(got, change.Seq)
+			}
+			if !reflect.DeepEqual(got, tt.want) || list.More != tt.wantMore {
+				t.Errorf("Since(%d, %d) = %v more=%v, want %v more=%v", tt.since, tt.limit, got, list.More,
+					tt.want, tt.wantMore)
+			}
+		})
+	}
+}
+
+func TestServeChangesGet(t *testing.T) {
+	saved := ChangeJournal
+	defer func() { ChangeJournal = saved }()
+	ChangeJournal = newChangeJournal(3)
+	for i := 0; i < 5; i++ {
+		ChangeJournal.Record(ChangeItem{Kind: "deployment", Op: ChangeUpdate, Namespace: "personal", Deployment: "web"})
+	}
+	epoch, _ := ChangeJournal.Latest()
+	tests := []struct {
+		name     string
+		query    string
+		want     int
+		wantSeqs []uint64
+	}{
+		{name: "changes", query: "since=3", want: http.StatusOK, wantSeqs: []uint64{4, 5}},
+		{name: "same epoch", query: "since=3&epoch=" + epoch, want: http.StatusOK, wantSeqs: []uint64{4, 5}},
+		{name: "at head", query: "since=5", want: http.StatusOK, wantSeqs: []uint64{}},
+		{name: "epoch changed", query: "since=3&epoch=previous", want: http.StatusGone},
+		{name: "since past head", query: "since=6", want: http.StatusGone},
+		{name: "since overwritten", query: "since=1", want: http.StatusGone},
+		{name: "missing since", query: "", want: http.StatusBadRequest},
+		{name: "invalid limit", query: "since=3&limit=0", want: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			serveChangesGet(w, httptest.NewRequest("GET", "/changes?"+tt.query, nil))
+			if w.Code != tt.want {
+				t.Fatalf("GET /changes?%s = %d, want %d: %s", tt.query, w.Code, tt.want, w.Body.String())
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+			var list ChangeList
+			if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+				t.Fatalf("decoding the change list failed: %v", err)
+			}
+			got := make([]uint64, 0, len(list.Changes))
+			for _, change := range list.Changes {
+				got = append(got, change.Seq)
+			}
+			if !reflect.DeepEqual(got, tt.wantSeqs) {
+				t.Errorf("GET /changes?%s = %v, want %v", tt.query, got, tt.wantSeqs)
+			}
+		})
+	}
+}
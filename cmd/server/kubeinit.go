@@ -5,13 +5,20 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/scale"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
-func initKubeconfig(App *AppX) error {
-	self := "initKubeconfig"
+func initFlags(App *AppX) error {
+	self := "initFlags"
 	homedir, err := os.UserHomeDir()
 	if err != nil {
 		return fmt.Errorf("%s: call to %q failed: %#v", self, "os.UserHomeDir", err)
@@ -19,7 +26,49 @@ func initKubeconfig(App *AppX) error {
 	flag.StringVar(&App.Kubeconfig, "kubeconfig", filepath.Join(homedir, ".kube", "config"),
 		"absolute path to the kubeconfig file")
 	flag.StringVar(&App.Port, "port", "8088", "server port")
-	flag.Parse()
+	flag.Func("namespaces", "comma-separated list of namespaces to watch. Default: all namespaces",
+		func(value string) error {
+			for _, nsName := range strings.Split(value, ",") {
+				if nsName = strings.TrimSpace(nsName); nsName != "" {
+					App.WatchNamespaces = append(App.WatchNamespaces, nsName)
+				}
+			}
+			return nil
+		})
+	flag.StringVar(&App.NamespaceSelector, "namespace-selector", "",
+		"label selector picking the namespaces to watch, resolved at startup")
+	flag.DurationVar(&App.ResyncPeriod, "resync-period", time.Hour*24, "informer resync period")
+	flag.DurationVar(&App.InformerSyncTimeout, "informer-sync-timeout", time.Minute*5,
+		"how long to wait for each informer to sync at startup. 0 waits until the server stops")
+	flag.DurationVar(&App.DriftCheckInterval, "drift-check-interval", time.Minute*15,
+		"interval between cache drift checks against the API server. 0 disables the checker")
+	flag.BoolVar(&App.DriftRepair, "drift-repair", false,
+		"repair cache entries that disagree with the API server in two consecutive drift checks")
+	flag.StringVar(&App.SnapshotFile, "snapshot-file", "",
+		"file to persist cache snapshots to and warm-start from. Default: no snapshots")
+	flag.DurationVar(&App.SnapshotInterval, "snapshot-interval", time.Minute*5, "interval between cache snapshots")
+	flag.StringVar(&App.Role, "role", RoleAll,
+		"what this pod does: watcher (run informers, fill the cache), api (serve the cache) or all")
+	flag.StringVar(&App.CacheBackend, "cache-backend", CacheBackendMemory,
+		"where the cache lives: memory or redis. Roles watcher and api need redis")
+	flag.StringVar(&App.RedisAddr, "redis-addr", "127.0.0.1:6379",
+		"host:port of the redis server")
+	flag.StringVar(&App.RedisPrefix, "redis-prefix", "pp:", "prefix of the redis keys holding the cache")
+	flag.StringVar(&App.AdminTokenFile, "admin-token-file", "",
+		"file holding the bearer token that admin endpoints require. Default: admin endpoints disabled")
+	flag.StringVar(&App.NamespaceTemplateFile, "namespace-template-file", "",
+		"JSON file with the labels, default ResourceQuota and default LimitRange of namespaces created through the API")
+	flag.StringVar(&App.ProtectedNamespaces, "protected-namespaces", "default,kube-system,kube-public,kube-node-lease",
+		"comma-separated list of namespaces that cannot be deleted through the API")
+	flag.IntVar(&App.ChangeJournalSize, "change-journal-size", 10000,
+		"number of cache changes kept for /changes. 0 disables the journal")
+	flag.IntVar(&App.LogStreamsPerClient, "log-streams-per-client", 16,
+		"number of pod log streams each client can have open at once")
+	return nil
+}
+
+func initKubeconfig(App *AppX) error {
+	self := "initKubeconfig"
 	config, err := clientcmd.BuildConfigFromFlags("", App.Kubeconfig)
 	if err != nil {
 		return fmt.Errorf("%s: call to %q failed: %#v", self, "clientcmd.BuildConfigFromFlags", err)
@@ -28,5 +77,20 @@ func initKubeconfig(App *AppX) error {
 	if err != nil {
 		return fmt.Errorf("%s: call to %q failed: %#v", self, "kubernetes.NewForConfig", err)
 	}
+	App.MetadataClient, err = metadata.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "metadata.NewForConfig", err)
+	}
+	App.DynamicClient, err = dynamic.NewForConfig(config)
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "dynamic.NewForConfig", err)
+	}
+	App.DiscoveryClient = memory.NewMemCacheClient(App.Clientset.Discovery())
+	App.RESTMapper = restmapper.NewDeferredDiscoveryRESTMapper(App.DiscoveryClient)
+	App.ScaleClient, err = scale.NewForConfig(config, App.RESTMapper, dynamic.LegacyAPIPathResolverFunc,
+		scale.NewDiscoveryScaleKindResolver(App.DiscoveryClient))
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "scale.NewForConfig", err)
+	}
 	return nil
 }
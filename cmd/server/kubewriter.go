@@ -3,18 +3,54 @@ package main
 import (
 	"context"
 	"fmt"
+	"time"
 
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	klog "k8s.io/klog/v2"
 )
 
-func ReplicasSet(App *AppX, nsName string, dName string, replicas int) error {
+// ReplicasSet scales deployment nsName/dName to replicas.  Unless force is
+// set, a scale-down that would break a PodDisruptionBudget is refused
+// with a *PDBViolation, and a scale-up whose pods would not fit in a
+// ResourceQuota with a *QuotaViolation.
+func ReplicasSet(App *AppX, nsName string, dName string, replicas int, force bool) error {
 	self := "ReplicasSet"
 	s, err := App.Clientset.AppsV1().Deployments(nsName).GetScale(context.TODO(), dName, metav1.GetOptions{})
 	if err != nil {
 		return fmt.Errorf("%s: call to %q failed: %#v",
 			self, "(clientset).AppsV1().Deployments().GetScale()", err)
 	}
+	current := int(s.Spec.Replicas)
+	if !force && replicas != current {
+		deployment, err := App.Clientset.AppsV1().Deployments(nsName).Get(context.TODO(), dName, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("%s: call to %q failed: %#v", self, "(clientset).AppsV1().Deployments().Get()", err)
+		}
+		if replicas < current {
+			violation, err := scaleDownPDBCheck(App, deployment, current, replicas)
+			if err != nil {
+				return fmt.Errorf("%s: call to %q failed: %#v", self, "scaleDownPDBCheck", err)
+			}
+			if violation != nil {
+				return violation
+			}
+		} else {
+			violation, err := scaleUpQuotaCheck(App, deployment, current, replicas)
+			if err != nil {
+				return fmt.Errorf("%s: call to %q failed: %#v", self, "scaleUpQuotaCheck", err)
+			}
+			if violation != nil {
+				return violation
+			}
+		}
+	}
 	sc := *s
+	sc.Spec.Replicas = int32(replicas)
 	s, err = App.Clientset.AppsV1().Deployments(nsName).UpdateScale(context.TODO(), dName, &sc, metav1.UpdateOptions{})
 	if err != nil {
 		return fmt.Errorf("%s: error while scaling deployment: \"%s/%s\" to replicas=%d: %#v",
@@ -22,3 +58,227 @@ func ReplicasSet(App *AppX, nsName string, dName string, replicas int) error {
 	}
 	return nil
 }
+
+func StatefulSetReplicasSet(App *AppX, nsName string, sName string, replicas int) error {
+	self := "StatefulSetReplicasSet"
+	s, err := App.Clientset.AppsV1().StatefulSets(nsName).GetScale(context.TODO(), sName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v",
+			self, "(clientset).AppsV1().StatefulSets().GetScale()", err)
+	}
+	sc := *s
+	sc.Spec.Replicas = int32(replicas)
+	_, err = App.Clientset.AppsV1().StatefulSets(nsName).UpdateScale(context.TODO(), sName, &sc, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("%s: error while scaling statefulset: \"%s/%s\" to replicas=%d: %#v",
+			self, nsName, sName, replicas, err)
+	}
+	return nil
+}
+
+// HPASet applies patch to HPA nsName/hName.  Setting a CPU utilization
+// target replaces the target of an existing CPU utilization metric, or
+// adds one.
+func HPASet(App *AppX, nsName string, hName string, patch *HPAPatch) (*autoscalingv2.HorizontalPodAutoscaler, error) {
+	self := "HPASet"
+	hpa, err := App.Clientset.AutoscalingV2().HorizontalPodAutoscalers(nsName).Get(context.TODO(), hName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v",
+			self, "(clientset).AutoscalingV2().HorizontalPodAutoscalers().Get()", err)
+	}
+	if patch.MinReplicas != nil {
+		minReplicas := int32(*patch.MinReplicas)
+		hpa.Spec.MinReplicas = &minReplicas
+	}
+	if patch.MaxReplicas != nil {
+		hpa.Spec.MaxReplicas = int32(*patch.MaxReplicas)
+	}
+	if patch.TargetCPUUtilization != nil {
+		utilization := int32(*patch.TargetCPUUtilization)
+		metric := hpaCPUMetric(hpa.Spec.Metrics)
+		if metric == nil {
+			hpa.Spec.Metrics = append(hpa.Spec.Metrics, autoscalingv2.MetricSpec{
+				Type: autoscalingv2.ResourceMetricSourceType,
+				Resource: &autoscalingv2.ResourceMetricSource{Name: corev1.ResourceCPU,
+					Target: autoscalingv2.MetricTarget{Type: autoscalingv2.UtilizationMetricType}},
+			})
+			metric = &hpa.Spec.Metrics[len(hpa.Spec.Metrics)-1]
+		}
+		metric.Resource.Target.AverageUtilization = &utilization
+	}
+	hpa, err = App.Clientset.AutoscalingV2().HorizontalPodAutoscalers(nsName).Update(context.TODO(), hpa, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: error while updating hpa: \"%s/%s\": %w", self, nsName, hName, err)
+	}
+	return hpa, nil
+}
+
+func CronJobSuspendSet(App *AppX, nsName string, cName string, suspend bool) (*batchv1.CronJob, error) {
+	self := "CronJobSuspendSet"
+	cronJob, err := App.Clientset.BatchV1().CronJobs(nsName).Get(context.TODO(), cName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %w", self, "(clientset).BatchV1().CronJobs().Get()", err)
+	}
+	cronJob.Spec.Suspend = &suspend
+	cronJob, err = App.Clientset.BatchV1().CronJobs(nsName).Update(context.TODO(), cronJob, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: error while setting suspend=%t on cronjob: \"%s/%s\": %w",
+			self, suspend, nsName, cName, err)
+	}
+	return cronJob, nil
+}
+
+// CronJobRun creates a job from cronjob nsName/cName now, whatever its
+// schedule and suspension.
+func CronJobRun(App *AppX, nsName string, cName string) (*batchv1.Job, error) {
+	self := "CronJobRun"
+	cronJob, err := App.Clientset.BatchV1().CronJobs(nsName).Get(context.TODO(), cName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %w", self, "(clientset).BatchV1().CronJobs().Get()", err)
+	}
+	job, err := App.Clientset.BatchV1().Jobs(nsName).Create(context.TODO(), jobFromCronJob(cronJob, time.Now()),
+		metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: error while creating job from cronjob: \"%s/%s\": %w", self, nsName, cName, err)
+	}
+	return job, nil
+}
+
+// NodeUnschedulableSet cordons node nName, or uncordons it, as "kubectl
+// cordon" and "kubectl uncordon" do.
+func NodeUnschedulableSet(App *AppX, nName string, unschedulable bool) (*corev1.Node, error) {
+	self := "NodeUnschedulableSet"
+	node, err := App.Clientset.CoreV1().Nodes().Get(context.TODO(), nName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %w", self, "(clientset).CoreV1().Nodes().Get()", err)
+	}
+	if node.Spec.Unschedulable == unschedulable {
+		return node, nil
+	}
+	node.Spec.Unschedulable = unschedulable
+	node, err = App.Clientset.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: error while setting unschedulable=%t on node: %q: %w", self, unschedulable, nName, err)
+	}
+	return node, nil
+}
+
+// NamespaceCreate creates the namespace create asks for, with the labels
+// and, on request, the default ResourceQuota and LimitRange of template.
+// If a default cannot be created, the namespace is deleted again.
+func NamespaceCreate(App *AppX, create *NamespacePost, template *NamespaceTemplate) (*NamespaceCreated, error) {
+	self := "NamespaceCreate"
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: create.Namespace,
+		Labels: namespaceLabelsFrom(create, template)}}
+	namespace, err := App.Clientset.CoreV1().Namespaces().Create(context.TODO(), namespace, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: error while creating namespace: %q: %w", self, create.Namespace, err)
+	}
+	created := &NamespaceCreated{Namespace: namespace.Name, Labels: namespace.Labels}
+	if create.ResourceQuota {
+		quota := &corev1.ResourceQuota{ObjectMeta: metav1.ObjectMeta{Name: namespaceDefaultsName},
+			Spec: *template.ResourceQuota.DeepCopy()}
+		_, err = App.Clientset.CoreV1().ResourceQuotas(namespace.Name).Create(context.TODO(), quota, metav1.CreateOptions{})
+		if err != nil {
+			return nil, namespaceCreateUndo(App, namespace.Name,
+				fmt.Errorf("%s: error while creating resourcequota in namespace: %q: %w", self, namespace.Name, err))
+		}
+		created.ResourceQuota = namespaceDefaultsName
+	}
+	if create.LimitRange {
+		limitRange := &corev1.LimitRange{ObjectMeta: metav1.ObjectMeta{Name: namespaceDefaultsName},
+			Spec: *template.LimitRange.DeepCopy()}
+		_, err = App.Clientset.CoreV1().LimitRanges(namespace.Name).Create(context.TODO(), limitRange, metav1.CreateOptions{})
+		if err != nil {
+			return nil, namespaceCreateUndo(App, namespace.Name,
+				fmt.Errorf("%s: error while creating limitrange in namespace: %q: %w", self, namespace.Name, err))
+		}
+		created.LimitRange = namespaceDefaultsName
+	}
+	return created, nil
+}
+
+// namespaceCreateUndo deletes namespace nsName, half set up by
+// NamespaceCreate, and returns err, the reason.
+func namespaceCreateUndo(App *AppX, nsName string, err error) error {
+	self := "namespaceCreateUndo"
+	deleteErr := App.Clientset.CoreV1().Namespaces().Delete(context.TODO(), nsName, metav1.DeleteOptions{})
+	if deleteErr != nil {
+		klog.Errorf("%s: call to %q failed: %#v", self, "(clientset).CoreV1().Namespaces().Delete()", deleteErr)
+	}
+	return err
+}
+
+// NamespaceDelete deletes namespace nsName.  The API server marks it
+// Terminating at once and removes it once its contents are gone.
+func NamespaceDelete(App *AppX, nsName string) error {
+	self := "NamespaceDelete"
+	err := App.Clientset.CoreV1().Namespaces().Delete(context.TODO(), nsName, metav1.DeleteOptions{})
+	if err != nil {
+		return fmt.Errorf("%s: error while deleting namespace: %q: %w", self, nsName, err)
+	}
+	return nil
+}
+
+const (
+	PodEvicted = "evicted"
+	PodDeleted = "deleted"
+)
+
+// DeploymentPodOwned reports whether pod nsName/pName belongs to
+// deployment nsName/dName: whether the pod is controlled by a replicaset
+// that the deployment controls.  Owners are matched by UID, read from the
+// API server, so that a pod of an earlier deployment of the same name is
+// not taken for one of this.  A pod that does not exist is an error.
+func DeploymentPodOwned(App *AppX, nsName string, dName string, pName string) (bool, error) {
+	self := "DeploymentPodOwned"
+	pod, err := App.Clientset.CoreV1().Pods(nsName).Get(context.TODO(), pName, metav1.GetOptions{})
+	if err != nil {
+		return false, fmt.Errorf("%s: call to %q failed: %w", self, "(clientset).CoreV1().Pods().Get()", err)
+	}
+	podOwner := metav1.GetControllerOf(pod)
+	if podOwner == nil || podOwner.Kind != "ReplicaSet" {
+		return false, nil
+	}
+	replicaSet, err := App.Clientset.AppsV1().ReplicaSets(nsName).Get(context.TODO(), podOwner.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: call to %q failed: %#v", self, "(clientset).AppsV1().ReplicaSets().Get()", err)
+	}
+	replicaSetOwner := metav1.GetControllerOf(replicaSet)
+	if replicaSet.UID != podOwner.UID || replicaSetOwner == nil || replicaSetOwner.Kind != "Deployment" {
+		return false, nil
+	}
+	deployment, err := App.Clientset.AppsV1().Deployments(nsName).Get(context.TODO(), dName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: call to %q failed: %#v", self, "(clientset).AppsV1().Deployments().Get()", err)
+	}
+	return deployment.UID == replicaSetOwner.UID, nil
+}
+
+// PodEvict evicts pod nsName/pName through the Eviction API, which the API
+// server refuses while the eviction would break a PodDisruptionBudget.
+// With deleteFallback, a pod whose eviction is refused that way is
+// deleted instead.  It returns PodEvicted or PodDeleted.
+func PodEvict(App *AppX, nsName string, pName string, deleteFallback bool) (string, error) {
+	self := "PodEvict"
+	eviction := &policyv1.Eviction{ObjectMeta: metav1.ObjectMeta{Name: pName, Namespace: nsName}}
+	err := App.Clientset.CoreV1().Pods(nsName).EvictV1(context.TODO(), eviction)
+	if err == nil {
+		return PodEvicted, nil
+	}
+	if !deleteFallback || !apierrors.IsTooManyRequests(err) {
+		return "", fmt.Errorf("%s: error while evicting pod: \"%s/%s\": %w", self, nsName, pName, err)
+	}
+	klog.Infof("%s: eviction of pod \"%s/%s\" refused, deleting it: %v", self, nsName, pName, err)
+	err = App.Clientset.CoreV1().Pods(nsName).Delete(context.TODO(), pName, metav1.DeleteOptions{})
+	if err != nil {
+		return "", fmt.Errorf("%s: error while deleting pod: \"%s/%s\": %w", self, nsName, pName, err)
+	}
+	return PodDeleted, nil
+}
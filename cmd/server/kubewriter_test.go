@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// evictTestMeta returns the metadata of object personal/name, controlled
+// by owner unless owner.Kind is empty.
+func evictTestMeta(name string, uid types.UID, owner metav1.OwnerReference) metav1.ObjectMeta {
+	meta := metav1.ObjectMeta{Name: name, Namespace: "personal", UID: uid}
+	if owner.Kind != "" {
+		controller := true
+		owner.Controller = &controller
+		meta.OwnerReferences = []metav1.OwnerReference{owner}
+	}
+	return meta
+}
+
+// evictTestObjects returns deployment web, with UID deploymentUID,
+// replicaset web-7c5b9d, controlled by replicaSetOwner, and pod
+// web-7c5b9d-x2x4q, controlled by podOwner.
+func evictTestObjects(podOwner metav1.OwnerReference, replicaSetOwner metav1.OwnerReference,
+	deploymentUID types.UID) []runtime.Object {
+	return []runtime.Object{
+		&appsv1.Deployment{ObjectMeta: evictTestMeta("web", deploymentUID, metav1.OwnerReference{})},
+		&appsv1.ReplicaSet{ObjectMeta: evictTestMeta("web-7c5b9d", "rs-1", replicaSetOwner)},
+		&corev1.Pod{ObjectMeta: evictTestMeta("web-7c5b9d-x2x4q", "pod-1", podOwner)},
+	}
+}
+
+var (
+	evictTestPodOwner        = metav1.OwnerReference{Kind: "ReplicaSet", Name: "web-7c5b9d", UID: "rs-1"}
+	evictTestReplicaSetOwner = metav1.OwnerReference{Kind: "Deployment", Name: "web", UID: "deploy-1"}
+)
+
+func TestDeploymentPodOwned(t *testing.T) {
+	tests := []struct {
+		name       string
+		objects    []runtime.Object
+		deployment string
+		pod        string
+		want       bool
+		wantErr    bool
+	}{
+		{name: "owned", objects: evictTestObjects(evictTestPodOwner, evictTestReplicaSetOwner, "deploy-1"),
+			deployment: "web", pod: "web-7c5b9d-x2x4q", want: true},
+		{name: "pod not found", objects: evictTestObjects(evictTestPodOwner, evictTestReplicaSetOwner, "deploy-1"),
+			deployment: "web", pod: "web-7c5b9d-p9k2m", wantErr: true},
+		{name: "pod without controller",
+			objects:    evictTestObjects(metav1.OwnerReference{}, evictTestReplicaSetOwner, "deploy-1"),
+			deployment: "web", pod: "web-7c5b9d-x2x4q"},
+		{name: "pod of a statefulset",
+			objects: evictTestObjects(metav1.OwnerReference{Kind: "StatefulSet", Name: "web", UID: "deploy-1"},
+				evictTestReplicaSetOwner, "deploy-1"),
+			deployment: "web", pod: "web-7c5b9d-x2x4q"},
+		{name: "replicaset not found",
+			objects: evictTestObjects(metav1.OwnerReference{Kind: "ReplicaSet", Name: "web-5f8d2a", UID: "rs-1"},
+				evictTestReplicaSetOwner, "deploy-1"),
+			deployment: "web", pod: "web-7c5b9d-x2x4q"},
+		{name: "replicaset recreated",
+			objects: evictTestObjects(metav1.OwnerReference{Kind: "ReplicaSet", Name: "web-7c5b9d", UID: "rs-0"},
+				evictTestReplicaSetOwner, "deploy-1"),
+			deployment: "web", pod: "web-7c5b9d-x2x4q"},
+		{name: "replicaset without controller",
+			objects:    evictTestObjects(evictTestPodOwner, metav1.OwnerReference{}, "deploy-1"),
+			deployment: "web", pod: "web-7c5b9d-x2x4q"},
+		{name: "deployment recreated",
+			objects:    evictTestObjects(evictTestPodOwner, evictTestReplicaSetOwner, "deploy-2"),
+			deployment: "web", pod: "web-7c5b9d-x2x4q"},
+		{name: "other deployment", objects: evictTestObjects(evictTestPodOwner, evictTestReplicaSetOwner, "deploy-1"),
+			deployment: "api", pod: "web-7c5b9d-x2x4q"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			App := &AppX{Clientset: fake.NewSimpleClientset(tt.objects...)}
+			got, err := DeploymentPodOwned(App, "personal", tt.deployment, tt.pod)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DeploymentPodOwned(%q, %q) error = %v, want error %v", tt.deployment, tt.pod, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("DeploymentPodOwned(%q, %q) = %v, want %v", tt.deployment, tt.pod, got, tt.want)
+			}
+		})
+	}
+}
+
+// evictTestClientset returns a fake clientset holding objects whose
+// Eviction API answers with evictErr.
+func evictTestClientset(evictErr error, objects ...runtime.Object) *fake.Clientset {
+	clientset := fake.NewSimpleClientset(objects...)
+	clientset.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		return true, nil, evictErr
+	})
+	return clientset
+}
+
+func TestPodEvict(t *testing.T) {
+	blocked := apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+	failed := apierrors.NewInternalError(errors.New("etcdserver: request timed out"))
+	pod := &corev1.Pod{ObjectMeta: evictTestMeta("web-7c5b9d-x2x4q", "pod-1", evictTestPodOwner)}
+	tests := []struct {
+		name           string
+		evictErr       error
+		deleteFallback bool
+		objects        []runtime.Object
+		want           string
+		wantErr        func(error) bool
+		wantPod        bool
+	}{
+		{name: "evicted", objects: []runtime.Object{pod}, want: PodEvicted, wantPod: true},
+		{name: "evicted despite fallback", deleteFallback: true, objects: []runtime.Object{pod}, want: PodEvicted,
+			wantPod: true},
+		{name: "blocked", evictErr: blocked, objects: []runtime.Object{pod}, wantErr: apierrors.IsTooManyRequests,
+			wantPod: true},
+		{name: "blocked then deleted", evictErr: blocked, deleteFallback: true, objects: []runtime.Object{pod},
+			want: PodDeleted},
+		{name: "blocked then gone", evictErr: blocked, deleteFallback: true, wantErr: apierrors.IsNotFound},
+		{name: "failed not deleted", evictErr: failed, deleteFallback: true, objects: []runtime.Object{pod},
+			wantErr: apierrors.IsInternalError, wantPod: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			App := &AppX{Clientset: evictTestClientset(tt.evictErr, tt.objects...)}
+			got, err := PodEvict(App, "personal", "web-7c5b9d-x2x4q", tt.deleteFallback)
+			if tt.wantErr != nil {
+				if err == nil || !tt.wantErr(err) {
+					t.Errorf("PodEvict() error = %v, want a matching error", err)
+				}
+			} else if err != nil || got != tt.want {
+				t.Errorf("PodEvict() = %q, %v; want %q", got, err, tt.want)
+			}
+			_, getErr := App.Clientset.CoreV1().Pods("personal").Get(context.TODO(), "web-7c5b9d-x2x4q", metav1.GetOptions{})
+			if (getErr == nil) != tt.wantPod {
+				t.Errorf("pod present = %v, want %v", getErr == nil, tt.wantPod)
+			}
+		})
+	}
+}
+
+func TestServePodEvict(t *testing.T) {
+	savedApp, savedCache, savedSynced, savedJournal, savedToken := HttpSavedApp, LiveCache, CacheSynced,
+		ChangeJournal, AdminToken
+	defer func() {
+		HttpSavedApp, LiveCache, CacheSynced, ChangeJournal, AdminToken = savedApp, savedCache, savedSynced,
+			savedJournal, savedToken
+	}()
+	LiveCache, CacheSynced = newMemoryCacheFrom(NamespaceMap{
+		"personal": {Name: "personal", Deployments: DeploymentMap{"web": {Name: "web", Replicas: 2},
+			"api": {Name: "api", Replicas: 1}}},
+	}), true
+	AdminToken = "admin-secret"
+	blocked := apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+	tests := []struct {
+		name       string
+		evictErr   error
+		path       string
+		admin      bool
+		want       int
+		wantAction string
+	}{
+		{name: "evicted", path: "/namespaces/personal/deployments/web/pods/web-7c5b9d-x2x4q/evict",
+			want: http.StatusAccepted, wantAction: PodEvicted},
+		{name: "blocked", evictErr: blocked, path: "/namespaces/personal/deployments/web/pods/web-7c5b9d-x2x4q/evict",
+			want: http.StatusConflict},
+		{name: "delete needs admin", evictErr: blocked,
+			path: "/namespaces/personal/deployments/web/pods/web-7c5b9d-x2x4q/evict?delete=true",
+			want: http.StatusForbidden},
+		{name: "deleted", evictErr: blocked, admin: true,
+			path: "/namespaces/personal/deployments/web/pods/web-7c5b9d-x2x4q/evict?delete=true",
+			want: http.StatusAccepted, wantAction: PodDeleted},
+		{name: "invalid delete", path: "/namespaces/personal/deployments/web/pods/web-7c5b9d-x2x4q/evict?delete=maybe",
+			want: http.StatusBadRequest},
+		{name: "pod of another deployment", path: "/namespaces/personal/deployments/api/pods/web-7c5b9d-x2x4q/evict",
+			want: http.StatusNotFound},
+		{name: "pod not found", path: "/namespaces/personal/deployments/web/pods/web-7c5b9d-p9k2m/evict",
+			want: http.StatusNotFound},
+		{name: "deployment not cached", path: "/namespaces/personal/deployments/db/pods/web-7c5b9d-x2x4q/evict",
+			want: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			HttpSavedApp = &AppX{Clientset: evictTestClientset(tt.evictErr,
+				evictTestObjects(evictTestPodOwner, evictTestReplicaSetOwner, "deploy-1")...)}
+			ChangeJournal = newChangeJournal(10)
+			r := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			if tt.admin {
+				r.Header.Set("Authorization", "Bearer "+AdminToken)
+			}
+			w := httptest.NewRecorder()
+			servePodEvict(w, r)
+			if w.Code != tt.want {
+				t.Fatalf("POST %s = %d, want %d: %s", tt.path, w.Code, tt.want, w.Body.String())
+			}
+			list, _ := ChangeJournal.Since(0, 10)
+			if tt.wantAction == "" {
+				if len(list.Changes) != 0 {
+					t.Errorf("POST %s recorded %+v, want no changes", tt.path, list.Changes)
+				}
+				return
+			}
+			var evicted NamespaceDeploymentPodEvicted
+			if err := json.Unmarshal(w.Body.Bytes(), &evicted); err != nil {
+				t.Fatalf("decoding the response failed: %v", err)
+			}
+			if evicted.Action != tt.wantAction {
+				t.Errorf("POST %s action = %q, want %q", tt.path, evicted.Action, tt.wantAction)
+			}
+			want := ChangeItem{Seq: 1, Kind: "pod", Op: ChangeDelete, Namespace: "personal", Deployment: "web",
+				Pod: "web-7c5b9d-x2x4q"}
+			if len(list.Changes) != 1 {
+				t.Fatalf("POST %s recorded %+v, want one change", tt.path, list.Changes)
+			}
+			got := list.Changes[0]
+			got.Time = want.Time
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("POST %s recorded %+v, want %+v", tt.path, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	klog "k8s.io/klog/v2"
+)
+
+var (
+	LogStreams = &logStreamCounter{byClient: make(map[string]int)}
+)
+
+// logStreamCounter counts the pod log streams each client has open, so
+// that no client can hold more than --log-streams-per-client of them.
+type logStreamCounter struct {
+	mu       sync.Mutex
+	byClient map[string]int
+}
+
+// Acquire takes streams streams for client, all or none, and reports
+// whether that kept it within max.
+func (c *logStreamCounter) Acquire(client string, streams int, max int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.byClient[client]+streams > max {
+		return false
+	}
+	if streams > 0 {
+		c.byClient[client] += streams
+	}
+	return true
+}
+
+func (c *logStreamCounter) Release(client string, streams int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.byClient[client] -= streams
+	if c.byClient[client] <= 0 {
+		delete(c.byClient, client)
+	}
+}
+
+// requestClient names the client that sent r: by the common name of its
+// TLS client certificate, else by a digest of its bearer token, else by
+// its IP address, so that clients behind one proxy or NAT address are
+// told apart when they identify themselves.
+func requestClient(r *http.Request) string {
+	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 && r.TLS.PeerCertificates[0].Subject.CommonName != "" {
+		return "cn:" + r.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && token != "" {
+		digest := sha256.Sum256([]byte(token))
+		return "token:" + hex.EncodeToString(digest[:8])
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return "ip:" + r.RemoteAddr
+	}
+	return "ip:" + host
+}
+
+// logOptionsFromRequest reads the container, follow, tailLines and since
+// query parameters.  since is read as by the events endpoint.
+func logOptionsFromRequest(r *http.Request, now time.Time) (*corev1.PodLogOptions, error) {
+	self := "logOptionsFromRequest"
+	query := r.URL.Query()
+	options := &corev1.PodLogOptions{Container: query.Get("container")}
+	if options.Container != "" {
+		if problems := validation.IsDNS1123Label(options.Container); len(problems) > 0 {
+			return nil, fmt.Errorf("%s: invalid container value: %q", self, options.Container)
+		}
+	}
+	if value := query.Get("follow"); value != "" {
+		follow, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid follow value: %q", self, value)
+		}
+		options.Follow = follow
+	}
+	if value := query.Get("tailLines"); value != "" {
+		tailLines, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || tailLines < 0 {
+			return nil, fmt.Errorf("%s: invalid tailLines value: %q", self, value)
+		}
+		options.TailLines = &tailLines
+	}
+	since, err := eventsSinceFromRequest(r, now)
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %v", self, "eventsSinceFromRequest", err)
+	}
+	if !since.IsZero() {
+		sinceTime := metav1.NewTime(since)
+		options.SinceTime = &sinceTime
+	}
+	return options, nil
+}
+
+// DeploymentLogsStream streams the logs of pods, in namespace nsName, to
+// w, one line at a time, each prefixed with the name of its pod in
+// brackets, and calls flush after each line.  The pods' logs are read
+// concurrently, so lines of different pods interleave as they come.  A
+// pod whose logs cannot be read gets a line saying why.  It returns once
+// every pod's log has ended, which with options.Follow is when its
+// containers stop, or once ctx is done or w fails.
+func DeploymentLogsStream(ctx context.Context, App *AppX, nsName string, pods []string, options *corev1.PodLogOptions,
+	w io.Writer, flush func()) {
+	self := "DeploymentLogsStream"
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	lines := make(chan string)
+	var wg sync.WaitGroup
+	for _, pName := range pods {
+		wg.Add(1)
+		go func(pName string) {
+			defer wg.Done()
+			podLogsRead(ctx, App, nsName, pName, options, lines)
+		}(pName)
+	}
+	go func() {
+		wg.Wait()
+		close(lines)
+	}()
+	for line := range lines {
+		if ctx.Err() != nil {
+			continue
+		}
+		if _, err := io.WriteString(w, line); err != nil {
+			klog.Infof("%s: client gone: %v", self, err)
+			cancel()
+			continue
+		}
+		flush()
+	}
+}
+
+// podLogsRead sends the log of pod nsName/pName to lines, line by line,
+// until it ends or ctx is done.
+func podLogsRead(ctx context.Context, App *AppX, nsName string, pName string, options *corev1.PodLogOptions,
+	lines chan<- string) {
+	self := "podLogsRead"
+	prefix := "[" + pName + "] "
+	send := func(line string) bool {
+		select {
+		case lines <- prefix + line:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	stream, err := App.Clientset.CoreV1().Pods(nsName).GetLogs(pName, options).Stream(ctx)
+	if err != nil {
+		klog.Errorf("%s: call to %q failed: %#v", self, "(clientset).CoreV1().Pods().GetLogs().Stream()", err)
+		send(fmt.Sprintf("error: %v\n", err))
+		return
+	}
+	defer stream.Close()
+	reader := bufio.NewReader(stream)
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			if !strings.HasSuffix(line, "\n") {
+				line += "\n"
+			}
+			if !send(line) {
+				return
+			}
+		}
+		if err != nil {
+			if err != io.EOF && ctx.Err() == nil {
+				klog.Errorf("%s: pod %q: log stream failed: %v", self, nsName+"/"+pName, err)
+				send(fmt.Sprintf("error: %v\n", err))
+			}
+			return
+		}
+	}
+}
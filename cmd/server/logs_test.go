@@ -0,0 +1,150 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLogOptionsFromRequest(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tailLines := int64(20)
+	zeroLines := int64(0)
+	tenMinutesAgo := metav1.NewTime(now.Add(-10 * time.Minute))
+	atTime := metav1.NewTime(time.Date(2026, 2, 28, 8, 30, 0, 0, time.UTC))
+	tests := []struct {
+		name    string
+		query   string
+		want    *corev1.PodLogOptions
+		wantErr bool
+	}{
+		{name: "defaults", query: "", want: &corev1.PodLogOptions{}},
+		{name: "container", query: "container=sidecar", want: &corev1.PodLogOptions{Container: "sidecar"}},
+		{name: "follow", query: "follow=true", want: &corev1.PodLogOptions{Follow: true}},
+		{name: "no follow", query: "follow=0", want: &corev1.PodLogOptions{}},
+		{name: "tail lines", query: "tailLines=20", want: &corev1.PodLogOptions{TailLines: &tailLines}},
+		{name: "no tail lines", query: "tailLines=0", want: &corev1.PodLogOptions{TailLines: &zeroLines}},
+		{name: "since duration", query: "since=10m", want: &corev1.PodLogOptions{SinceTime: &tenMinutesAgo}},
+		{name: "since time", query: "since=2026-02-28T08:30:00Z", want: &corev1.PodLogOptions{SinceTime: &atTime}},
+		{name: "all", query: "container=app&follow=true&tailLines=20&since=10m",
+			want: &corev1.PodLogOptions{Container: "app", Follow: true, TailLines: &tailLines, SinceTime: &tenMinutesAgo}},
+		{name: "invalid container", query: "container=App_1", wantErr: true},
+		{name: "invalid follow", query: "follow=maybe", wantErr: true},
+		{name: "invalid tail lines", query: "tailLines=ten", wantErr: true},
+		{name: "negative tail lines", query: "tailLines=-1", wantErr: true},
+		{name: "invalid since", query: "since=yesterday", wantErr: true},
+		{name: "negative since", query: "since=-10m", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/namespaces/personal/deployments/web/logs?"+tt.query, nil)
+			got, err := logOptionsFromRequest(r, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("logOptionsFromRequest(%q) error = %v, want error %v", tt.query, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("logOptionsFromRequest(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogStreamCounter(t *testing.T) {
+	c := &logStreamCounter{byClient: make(map[string]int)}
+	steps := []struct {
+		release bool
+		client  string
+		streams int
+		want    bool
+	}{
+		{client: "ip:10.0.0.1", streams: 3, want: true},
+		{client: "ip:10.0.0.1", streams: 2, want: false},
+		{client: "ip:10.0.0.1", streams: 1, want: true},
+		{client: "ip:10.0.0.1", streams: 1, want: false},
+		// Another client has a budget of its own.
+		{client: "ip:10.0.0.2", streams: 4, want: true},
+		{client: "ip:10.0.0.2", streams: 5, want: false},
+		{release: true, client: "ip:10.0.0.1", streams: 3},
+		{client: "ip:10.0.0.1", streams: 3, want: true},
+		{client: "ip:10.0.0.1", streams: 0, want: true},
+		// A deployment with more pods than the budget is refused outright.
+		{release: true, client: "ip:10.0.0.2", streams: 4},
+		{client: "ip:10.0.0.2", streams: 5, want: false},
+	}
+	for i, step := range steps {
+		if step.release {
+			c.Release(step.client, step.streams)
+			continue
+		}
+		if got := c.Acquire(step.client, step.streams, 4); got != step.want {
+			t.Errorf("step %d: Acquire(%q, %d, 4) = %v, want %v", i, step.client, step.streams, got, step.want)
+		}
+	}
+	c.Release("ip:10.0.0.1", 4)
+	if len(c.byClient) != 0 {
+		t.Errorf("after releasing every stream byClient = %v, want empty", c.byClient)
+	}
+}
+
+func TestRequestClient(t *testing.T) {
+	tests := []struct {
+		name          string
+		remoteAddr    string
+		authorization string
+		commonName    string
+		want          string
+	}{
+		{name: "address", remoteAddr: "10.0.0.1:41234", want: "ip:10.0.0.1"},
+		{name: "address without port", remoteAddr: "10.0.0.1", want: "ip:10.0.0.1"},
+		{name: "not a bearer token", remoteAddr: "10.0.0.1:41234", authorization: "Basic dXNlcg==",
+			want: "ip:10.0.0.1"},
+		{name: "empty bearer token", remoteAddr: "10.0.0.1:41234", authorization: "Bearer ", want: "ip:10.0.0.1"},
+		{name: "client certificate", remoteAddr: "10.0.0.1:41234", authorization: "Bearer secret",
+			commonName: "deploy-bot", want: "cn:deploy-bot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			if tt.commonName != "" {
+				r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{
+					{Subject: pkix.Name{CommonName: tt.commonName}}}}
+			}
+			if got := requestClient(r); got != tt.want {
+				t.Errorf("requestClient() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRequestClientToken checks that clients sharing an address are told
+// apart by their bearer tokens, and that the token itself is not revealed.
+func TestRequestClientToken(t *testing.T) {
+	clientFor := func(token string) string {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = "10.0.0.1:41234"
+		r.Header.Set("Authorization", "Bearer "+token)
+		return requestClient(r)
+	}
+	alice, bob := clientFor("alice-secret"), clientFor("bob-secret")
+	if !strings.HasPrefix(alice, "token:") || alice == bob {
+		t.Errorf("requestClient() = %q and %q, want distinct token clients", alice, bob)
+	}
+	if alice != clientFor("alice-secret") {
+		t.Errorf("requestClient() is not stable for one token")
+	}
+	if strings.Contains(alice, "alice-secret") {
+		t.Errorf("requestClient() = %q reveals the token", alice)
+	}
+}
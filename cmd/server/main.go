@@ -3,18 +3,51 @@ package main
 import (
 	"flag"
 	"net/http"
+	"time"
 
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/metadata"
+	"k8s.io/client-go/scale"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/klog/examples/util/require"
 	klog "k8s.io/klog/v2"
 )
 
 type AppX struct {
-	Kubeconfig string
-	Clientset  *kubernetes.Clientset
-	Port       string
-	Mux        *http.ServeMux
-	Stop       chan struct{}
+	Kubeconfig            string
+	Clientset             kubernetes.Interface
+	MetadataClient        metadata.Interface
+	DynamicClient         dynamic.Interface
+	DiscoveryClient       discovery.CachedDiscoveryInterface
+	RESTMapper            meta.ResettableRESTMapper
+	ScaleClient           scale.ScalesGetter
+	Port                  string
+	Mux                   *http.ServeMux
+	Stop                  chan struct{}
+	WatchNamespaces       []string
+	NamespaceSelector     string
+	Factories             map[string]informers.SharedInformerFactory
+	ResyncPeriod          time.Duration
+	InformerSyncTimeout   time.Duration
+	DriftCheckInterval    time.Duration
+	DriftRepair           bool
+	Informers             map[string]cache.SharedIndexInformer
+	InformersSkipped      map[string]bool
+	SnapshotFile          string
+	SnapshotInterval      time.Duration
+	Role                  string
+	CacheBackend          string
+	RedisAddr             string
+	RedisPrefix           string
+	AdminTokenFile        string
+	NamespaceTemplateFile string
+	ProtectedNamespaces   string
+	ChangeJournalSize     int
+	LogStreamsPerClient   int
 }
 
 var (
@@ -25,23 +58,63 @@ func main() {
 	klog.InitFlags(nil)
 	require.NoError(flag.Set("logtostderr", "false"))
 	require.NoError(flag.Set("log_file", "/users/nobody/log.log"))
+	err := initFlags(&App)
+	if err != nil {
+		klog.Fatal(err)
+	}
 	flag.Parse()
 	defer klog.Flush()
 	klog.Info("nice to meet you")
-	err := initKubeconfig(&App)
+	err = initKubeconfig(&App)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	err = initAdmin(&App)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	err = initNamespaceTemplate(&App)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	err = initChangeJournal(&App)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	err = initWatchedNamespaces(&App)
 	if err != nil {
 		klog.Fatal(err)
 	}
+	err = initCache(&App)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	err = initSnapshot(&App)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	err = initHttp(&App)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- http.ListenAndServe(":"+App.Port, App.Mux)
+	}()
 	err = initInformers(&App)
 	if err != nil {
 		klog.Fatal(err)
 	}
 	defer close(App.Stop)
-	err = initHttp(&App)
+	err = initDriftChecker(&App)
+	if err != nil {
+		klog.Fatal(err)
+	}
+	err = initSnapshotWriter(&App)
 	if err != nil {
 		klog.Fatal(err)
 	}
-	err = http.ListenAndServe(":"+App.Port, App.Mux)
+	err = <-serveErr
 	if err != nil {
 		klog.Fatal(err)
 	}
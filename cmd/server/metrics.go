@@ -0,0 +1,49 @@
+package main
+
+import (
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const (
+	metricsNamespace = "rest_api_server"
+)
+
+var (
+	metricCacheDriftCount = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      "cache",
+			Name:           "drift_count",
+			Help:           "Number of cache entries that disagreed with the API server at the last drift check.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+	metricCacheDriftChecks = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      "cache",
+			Name:           "drift_checks_total",
+			Help:           "Number of drift checks run, by result.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"result"},
+	)
+	metricCacheDriftRepairs = metrics.NewCounter(
+		&metrics.CounterOpts{
+			Namespace:      metricsNamespace,
+			Subsystem:      "cache",
+			Name:           "drift_repairs_total",
+			Help:           "Number of cache entries repaired by the drift checker.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+)
+
+func init() {
+	legacyregistry.MustRegister(
+		metricCacheDriftCount,
+		metricCacheDriftChecks,
+		metricCacheDriftRepairs,
+	)
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	klog "k8s.io/klog/v2"
+)
+
+const (
+	// NamespaceDeleteTokenTTL is how long a delete token handed out by
+	// endpoint 34 stays good.
+	NamespaceDeleteTokenTTL = time.Minute * 5
+	// namespaceDefaultsName names the ResourceQuota and LimitRange created
+	// from the namespace template.
+	namespaceDefaultsName = "default"
+)
+
+var (
+	NamespaceTemplateSaved = &NamespaceTemplate{}
+	ProtectedNamespaces    map[string]bool
+)
+
+// NamespaceTemplate is read from --namespace-template-file.  Labels are
+// set on every namespace created through endpoint 35, over those the
+// request asks for; the ResourceQuota and LimitRange, when given, are
+// created in it on request.
+type NamespaceTemplate struct {
+	Labels        map[string]string         `json:"labels"`
+	ResourceQuota *corev1.ResourceQuotaSpec `json:"resource_quota,omitempty"`
+	LimitRange    *corev1.LimitRangeSpec    `json:"limit_range,omitempty"`
+}
+
+// NamespacePost is the body of a POST to /namespaces.
+type NamespacePost struct {
+	Namespace     string            `json:"namespace"`
+	Labels        map[string]string `json:"labels"`
+	ResourceQuota bool              `json:"resource_quota"`
+	LimitRange    bool              `json:"limit_range"`
+}
+
+type NamespaceCreated struct {
+	Namespace     string            `json:"namespace"`
+	Labels        map[string]string `json:"labels"`
+	ResourceQuota string            `json:"resource_quota,omitempty"`
+	LimitRange    string            `json:"limit_range,omitempty"`
+}
+
+type NamespaceDeleted struct {
+	Namespace string `json:"namespace"`
+	Phase     string `json:"phase"`
+}
+
+func initNamespaceTemplate(App *AppX) error {
+	self := "initNamespaceTemplate"
+	klog.Infof("%s: entry", self)
+	ProtectedNamespaces = make(map[string]bool)
+	for _, nsName := range strings.Split(App.ProtectedNamespaces, ",") {
+		if nsName = strings.TrimSpace(nsName); nsName != "" {
+			ProtectedNamespaces[nsName] = true
+		}
+	}
+	if App.NamespaceTemplateFile == "" {
+		return nil
+	}
+	data, err := os.ReadFile(App.NamespaceTemplateFile)
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "os.ReadFile", err)
+	}
+	template := new(NamespaceTemplate)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(template); err != nil {
+		return fmt.Errorf("%s: invalid namespace template: %q: %v", self, App.NamespaceTemplateFile, err)
+	}
+	NamespaceTemplateSaved = template
+	return nil
+}
+
+// namespacePostFromRequest reads and checks a NamespacePost.  Defaults
+// can only be asked for when the template has them.
+func namespacePostFromRequest(r io.Reader) (*NamespacePost, error) {
+	self := "namespacePostFromRequest"
+	create := new(NamespacePost)
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(create); err != nil {
+		return nil, fmt.Errorf("%s: invalid body: %v", self, err)
+	}
+	if problems := validation.IsDNS1123Label(create.Namespace); len(problems) > 0 {
+		return nil, fmt.Errorf("%s: invalid namespace name: %q: %s", self, create.Namespace, strings.Join(problems, "; "))
+	}
+	if create.ResourceQuota && NamespaceTemplateSaved.ResourceQuota == nil {
+		return nil, fmt.Errorf("%s: the namespace template has no resource_quota", self)
+	}
+	if create.LimitRange && NamespaceTemplateSaved.LimitRange == nil {
+		return nil, fmt.Errorf("%s: the namespace template has no limit_range", self)
+	}
+	return create, nil
+}
+
+// namespaceLabelsFrom returns the labels of a namespace created as asked
+// by create: its own, overridden by the template's.
+func namespaceLabelsFrom(create *NamespacePost, template *NamespaceTemplate) map[string]string {
+	labels := make(map[string]string, len(create.Labels)+len(template.Labels))
+	for key, value := range create.Labels {
+		labels[key] = value
+	}
+	for key, value := range template.Labels {
+		labels[key] = value
+	}
+	return labels
+}
+
+// namespaceDeleteToken returns the token that confirms deleting ni,
+// issued at issued.  It is an HMAC, keyed with the admin token, of the
+// namespace's name and creation time and of when it was issued, so that
+// every replica can check it, and it is good neither for a namespace
+// recreated under the same name nor after NamespaceDeleteTokenTTL.
+func namespaceDeleteToken(ni *NamespaceItem, issued time.Time) string {
+	mac := hmac.New(sha256.New, []byte(AdminToken))
+	fmt.Fprintf(mac, "%s\n%s\n%d", ni.Name, ni.CreationTime.UTC().Format(time.RFC3339), issued.Unix())
+	return strconv.FormatInt(issued.Unix(), 10) + "." + hex.EncodeToString(mac.Sum(nil))
+}
+
+// namespaceDeleteTokenCheck checks token, echoed by a request to delete
+// ni at now.
+func namespaceDeleteTokenCheck(ni *NamespaceItem, token string, now time.Time) error {
+	self := "namespaceDeleteTokenCheck"
+	issuedUnix, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return fmt.Errorf("%s: malformed token", self)
+	}
+	seconds, err := strconv.ParseInt(issuedUnix, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%s: malformed token", self)
+	}
+	issued := time.Unix(seconds, 0)
+	if !hmac.Equal([]byte(token), []byte(namespaceDeleteToken(ni, issued))) {
+		return fmt.Errorf("%s: token does not match namespace %q", self, ni.Name)
+	}
+	if age := now.Sub(issued); age > NamespaceDeleteTokenTTL || age < -time.Minute {
+		return fmt.Errorf("%s: token expired; get the namespace again", self)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNamespaceDeleteTokenCheck(t *testing.T) {
+	savedToken := AdminToken
+	defer func() { AdminToken = savedToken }()
+	AdminToken = "admin-secret"
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	issued := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	teamA := &NamespaceItem{Name: "team-a", CreationTime: created}
+	token := namespaceDeleteToken(teamA, issued)
+	issuedUnix, mac, _ := strings.Cut(token, ".")
+	alteredMAC := mac[:len(mac)-1] + "0"
+	if alteredMAC == mac {
+		alteredMAC = mac[:len(mac)-1] + "1"
+	}
+	tests := []struct {
+		name    string
+		ni      *NamespaceItem
+		token   string
+		now     time.Time
+		wantErr bool
+	}{
+		{name: "fresh", ni: teamA, token: token, now: issued},
+		{name: "within ttl", ni: teamA, token: token, now: issued.Add(NamespaceDeleteTokenTTL)},
+		{name: "expired", ni: teamA, token: token, now: issued.Add(NamespaceDeleteTokenTTL + time.Second), wantErr: true},
+		{name: "clock skew tolerated", ni: teamA, token: token, now: issued.Add(-time.Minute)},
+		{name: "issued in the future", ni: teamA, token: token, now: issued.Add(-time.Minute - time.Second),
+			wantErr: true},
+		{name: "other namespace", ni: &NamespaceItem{Name: "team-b", CreationTime: created}, token: token, now: issued,
+			wantErr: true},
+		{name: "recreated namespace", ni: &NamespaceItem{Name: "team-a", CreationTime: created.Add(time.Hour)},
+			token: token, now: issued, wantErr: true},
+		{name: "issue time altered", ni: teamA, token: "1" + issuedUnix + "." + mac, now: issued,
+			wantErr: true},
+		{name: "mac altered", ni: teamA, token: issuedUnix + "." + alteredMAC, now: issued, wantErr: true},
+		{name: "no separator", ni: teamA, token: issuedUnix, now: issued, wantErr: true},
+		{name: "issue time not a number", ni: teamA, token: "soon." + mac, now: issued,
+			wantErr: true},
+		{name: "empty", ni: teamA, token: "", now: issued, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := namespaceDeleteTokenCheck(tt.ni, tt.token, tt.now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("namespaceDeleteTokenCheck(%q, %q) = %v, want error %v", tt.ni.Name, tt.token, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestNamespaceDeleteTokenAdminToken checks that a token issued by a
+// server with another admin token, e.g. before it was rotated, is refused.
+func TestNamespaceDeleteTokenAdminToken(t *testing.T) {
+	savedToken := AdminToken
+	defer func() { AdminToken = savedToken }()
+	ni := &NamespaceItem{Name: "team-a", CreationTime: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+	issued := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	AdminToken = "old-secret"
+	token := namespaceDeleteToken(ni, issued)
+	AdminToken = "new-secret"
+	if err := namespaceDeleteTokenCheck(ni, token, issued); err == nil {
+		t.Errorf("namespaceDeleteTokenCheck() accepted a token issued under another admin token")
+	}
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	klog "k8s.io/klog/v2"
+)
+
+type NodeConditionItem struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type NodeTaintItem struct {
+	Key    string `json:"key"`
+	Value  string `json:"value,omitempty"`
+	Effect string `json:"effect"`
+}
+
+type NodePodItem struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"pod"`
+	Phase     string `json:"phase"`
+	Ready     bool   `json:"ready"`
+}
+
+// NodeItem is a node with the pods scheduled to it.  With --namespaces or
+// --namespace-selector, only pods in watched namespaces are listed.
+type NodeItem struct {
+	Name          string              `json:"node"`
+	Unschedulable bool                `json:"unschedulable"`
+	Zone          string              `json:"zone,omitempty"`
+	Region        string              `json:"region,omitempty"`
+	Capacity      map[string]string   `json:"capacity"`
+	Allocatable   map[string]string   `json:"allocatable"`
+	Conditions    []NodeConditionItem `json:"conditions"`
+	Taints        []NodeTaintItem     `json:"taints"`
+	Pods          []NodePodItem       `json:"pods"`
+}
+
+// DrainPodItem is a deployment's pod that draining its node would evict.
+// PDBs names the PodDisruptionBudgets that match the pod.
+type DrainPodItem struct {
+	Namespace       string   `json:"namespace"`
+	Name            string   `json:"pod"`
+	Deployment      string   `json:"deployment"`
+	Ready           bool     `json:"ready"`
+	PDBs            []string `json:"pdbs"`
+	EvictionAllowed bool     `json:"eviction_allowed"`
+	Reason          string   `json:"reason,omitempty"`
+}
+
+// NodeDrainPreview is what draining a node would do to deployments' pods.
+// Blocked counts the pods whose eviction would be refused; OtherPods those
+// on the node that no deployment owns.
+type NodeDrainPreview struct {
+	Name          string         `json:"node"`
+	Unschedulable bool           `json:"unschedulable"`
+	Pods          []DrainPodItem `json:"pods"`
+	Blocked       int            `json:"blocked"`
+	OtherPods     int            `json:"other_pods"`
+}
+
+// nodeInformerAdd adds the node informer and runs it.  Nodes are
+// cluster-scoped, so when namespaces are watched one by one the informer
+// gets a factory of its own, and is left out if this server may not list
+// and watch nodes.
+func nodeInformerAdd(App *AppX) error {
+	self := "nodeInformerAdd"
+	klog.Infof("%s: entry", self)
+	if !informersPermitted(App, metav1.NamespaceAll, "nodes") {
+		return nil
+	}
+	factory := App.Factories[metav1.NamespaceAll]
+	if WatchedNamespaces != nil {
+		factory = informers.NewSharedInformerFactory(App.Clientset, App.ResyncPeriod)
+	}
+	err := auxInformerAdd(App, informerName("nodes", metav1.NamespaceAll), factory.Core().V1().Nodes().Informer(),
+		nodeTransform, nil)
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "auxInformerAdd", err)
+	}
+	err = auxInformersRun(App, factory)
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "auxInformersRun", err)
+	}
+	return nil
+}
+
+// NodesWatched reports whether the node informer runs.  It does not before
+// the informers have synced, in role api, or when nodes may not be listed.
+func NodesWatched() bool {
+	return informerGet("nodes", metav1.NamespaceAll) != nil
+}
+
+func NodeListGet() ([]NodeItem, error) {
+	self := "NodeListGet"
+	klog.Infof("%s: entry", self)
+	informer := informerGet("nodes", metav1.NamespaceAll)
+	if informer == nil {
+		return nil, fmt.Errorf("%s: node informer not available", self)
+	}
+	nodeList := make([]NodeItem, 0)
+	for _, obj := range informer.GetStore().List() {
+		node := obj.(*corev1.Node)
+		pods, err := nodePodsGet(node.Name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "nodePodsGet", err)
+		}
+		nodeList = append(nodeList, nodeItemFrom(node, pods))
+	}
+	sort.Slice(nodeList, func(i, j int) bool { return nodeList[i].Name < nodeList[j].Name })
+	return nodeList, nil
+}
+
+// NodeGet returns node nName, or nil if there is no such node.
+func NodeGet(nName string) (*NodeItem, error) {
+	self := "NodeGet"
+	klog.Infof("%s: entry", self)
+	node, err := nodeGet(nName)
+	if node == nil || err != nil {
+		return nil, err
+	}
+	pods, err := nodePodsGet(nName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "nodePodsGet", err)
+	}
+	ni := nodeItemFrom(node, pods)
+	return &ni, nil
+}
+
+// NodeDrainPreviewGet works out which pods of deployments draining node
+// nName would evict, and whether their PodDisruptionBudgets let them go.
+// Pods are taken in order of namespace and name, each eviction using up
+// a disruption its budget allows, as "kubectl drain" would evict them.
+// It returns nil if there is no such node.
+func NodeDrainPreviewGet(nName string) (*NodeDrainPreview, error) {
+	self := "NodeDrainPreviewGet"
+	klog.Infof("%s: entry", self)
+	node, err := nodeGet(nName)
+	if node == nil || err != nil {
+		return nil, err
+	}
+	pods, err := nodePodsGet(nName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "nodePodsGet", err)
+	}
+	preview := &NodeDrainPreview{Name: node.Name, Unschedulable: node.Spec.Unschedulable, Pods: []DrainPodItem{}}
+	remaining := make(map[string]int)
+	for _, pod := range pods {
+		dName, err := podDeploymentGet(pod)
+		if err != nil {
+			return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "podDeploymentGet", err)
+		}
+		if dName == "" {
+			preview.OtherPods++
+			continue
+		}
+		pdbs, err := PodPDBsGet(pod)
+		if err != nil {
+			return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "PodPDBsGet", err)
+		}
+		dpi := DrainPodItem{Namespace: pod.Namespace, Name: pod.Name, Deployment: dName, Ready: podReady(pod),
+			PDBs: []string{}}
+		for _, pdb := range pdbs {
+			dpi.PDBs = append(dpi.PDBs, pdb.Name)
+		}
+		dpi.EvictionAllowed, dpi.Reason = podEvictionCheck(pod, pdbs, remaining)
+		if !dpi.EvictionAllowed {
+			preview.Blocked++
+		}
+		preview.Pods = append(preview.Pods, dpi)
+	}
+	return preview, nil
+}
+
+func nodeGet(nName string) (*corev1.Node, error) {
+	self := "nodeGet"
+	informer := informerGet("nodes", metav1.NamespaceAll)
+	if informer == nil {
+		return nil, fmt.Errorf("%s: node informer not available", self)
+	}
+	obj, exists, err := informer.GetStore().GetByKey(nName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Store).GetByKey", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+	return obj.(*corev1.Node), nil
+}
+
+// nodePodsGet returns the pods scheduled to node nName from every pod
+// informer, sorted by namespace and name.
+func nodePodsGet(nName string) ([]*corev1.Pod, error) {
+	self := "nodePodsGet"
+	App := InformersSavedApp
+	if App == nil {
+		return nil, fmt.Errorf("%s: informers not available", self)
+	}
+	pods := make([]*corev1.Pod, 0)
+	for nsName := range App.Factories {
+		informer, ok := informerLookup(App, informerName("pods", nsName))
+		if !ok {
+			continue
+		}
+		objs, err := informer.GetIndexer().ByIndex(nodeIndex, nName)
+		if err != nil {
+			return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Indexer).ByIndex", err)
+		}
+		for _, obj := range objs {
+			pods = append(pods, obj.(*corev1.Pod))
+		}
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+	return pods, nil
+}
+
+// podDeploymentGet returns the name of the deployment that owns pod
+// through a replicaset, or "" if none does.
+func podDeploymentGet(pod *corev1.Pod) (string, error) {
+	self := "podDeploymentGet"
+	owner := metav1.GetControllerOfNoCopy(pod)
+	if owner == nil || owner.Kind != "ReplicaSet" {
+		return "", nil
+	}
+	informer := informerGet("replicasets", pod.Namespace)
+	if informer == nil {
+		return "", fmt.Errorf("%s: informers not available for namespace: %q", self, pod.Namespace)
+	}
+	obj, exists, err := informer.GetStore().GetByKey(pod.Namespace + "/" + owner.Name)
+	if err != nil {
+		return "", fmt.Errorf("%s: call to %q failed: %#v", self, "(Store).GetByKey", err)
+	}
+	if !exists {
+		return "", nil
+	}
+	rsOwner := metav1.GetControllerOfNoCopy(obj.(*appsv1.ReplicaSet))
+	if rsOwner == nil || rsOwner.Kind != "Deployment" {
+		return "", nil
+	}
+	return rsOwner.Name, nil
+}
+
+func nodeItemFrom(node *corev1.Node, pods []*corev1.Pod) NodeItem {
+	ni := NodeItem{Name: node.Name, Unschedulable: node.Spec.Unschedulable,
+		Zone: node.Labels[corev1.LabelTopologyZone], Region: node.Labels[corev1.LabelTopologyRegion],
+		Capacity: resourceListStrings(node.Status.Capacity), Allocatable: resourceListStrings(node.Status.Allocatable),
+		Conditions: []NodeConditionItem{}, Taints: []NodeTaintItem{}, Pods: []NodePodItem{}}
+	for _, condition := range node.Status.Conditions {
+		ni.Conditions = append(ni.Conditions, NodeConditionItem{Type: string(condition.Type),
+			Status: string(condition.Status), Reason: condition.Reason})
+	}
+	for _, taint := range node.Spec.Taints {
+		ni.Taints = append(ni.Taints, NodeTaintItem{Key: taint.Key, Value: taint.Value, Effect: string(taint.Effect)})
+	}
+	for _, pod := range pods {
+		ni.Pods = append(ni.Pods, NodePodItem{Namespace: pod.Namespace, Name: pod.Name, Phase: string(pod.Status.Phase),
+			Ready: podReady(pod)})
+	}
+	return ni
+}
+
+func resourceListStrings(resources corev1.ResourceList) map[string]string {
+	m := make(map[string]string, len(resources))
+	for name, quantity := range resources {
+		m[string(name)] = quantity.String()
+	}
+	return m
+}
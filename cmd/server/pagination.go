@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+const (
+	SortByName      = "name"
+	SortByNamespace = "namespace"
+	SortByReplicas  = "replicas"
+)
+
+type ListOptions struct {
+	SortBy   string
+	Limit    int
+	Continue *listKey
+	Envelope bool
+}
+
+type ListPage struct {
+	Total    int    `json:"total"`
+	Continue string `json:"continue,omitempty"`
+}
+
+// ListEnvelope wraps the array that endpoints 2A, 11A and 14A return when
+// the request asks for envelope=true, so that clients that don't see
+// response headers can page through it.
+type ListEnvelope struct {
+	Items interface{} `json:"items"`
+	ListPage
+}
+
+// listKey identifies a list element by the values it can be sorted on.  A
+// continue token is the encoded listKey of the last element returned, so it
+// stays valid when elements are added or removed between calls.
+type listKey struct {
+	SortBy    string `json:"s"`
+	Namespace string `json:"n"`
+	Name      string `json:"d,omitempty"`
+	Replicas  int    `json:"r"`
+}
+
+func listOptionsFromRequest(r *http.Request) (*ListOptions, error) {
+	self := "listOptionsFromRequest"
+	query := r.URL.Query()
+	opts := &ListOptions{SortBy: SortByName}
+	switch sortBy := query.Get("sortBy"); sortBy {
+	case "":
+	case SortByName, SortByNamespace, SortByReplicas:
+		opts.SortBy = sortBy
+	default:
+		return nil, fmt.Errorf("%s: unknown sortBy value: %q", self, sortBy)
+	}
+	if limit := query.Get("limit"); limit != "" {
+		limitI, err := strconv.Atoi(limit)
+		if err != nil || limitI < 0 {
+			return nil, fmt.Errorf("%s: invalid limit value: %q", self, limit)
+		}
+		opts.Limit = limitI
+	}
+	if envelope := query.Get("envelope"); envelope != "" {
+		envelopeB, err := strconv.ParseBool(envelope)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid envelope value: %q", self, envelope)
+		}
+		opts.Envelope = envelopeB
+	}
+	if token := query.Get("continue"); token != "" {
+		raw, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid continue token: %q", self, token)
+		}
+		key := new(listKey)
+		if err = json.Unmarshal(raw, key); err != nil {
+			return nil, fmt.Errorf("%s: invalid continue token: %q", self, token)
+		}
+		if key.SortBy != opts.SortBy {
+			return nil, fmt.Errorf("%s: continue token was issued for sortBy=%q", self, key.SortBy)
+		}
+		opts.Continue = key
+	}
+	return opts, nil
+}
+
+// less orders keys by the requested field, then by namespace and name, so
+// that the order is total and repeatable.
+func (o *ListOptions) less(a, b listKey) bool {
+	sortBy := SortByName
+	if o != nil {
+		sortBy = o.SortBy
+	}
+	switch sortBy {
+	case SortByReplicas:
+		if a.Replicas != b.Replicas {
+			return a.Replicas < b.Replicas
+		}
+	case SortByName:
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
+
+// page sorts keys in place, applying the same permutation via swap, and
+// returns the bounds of the requested page along with its ListPage.
+func (o *ListOptions) page(keys []listKey, swap func(i, j int)) (int, int, ListPage) {
+	sort.Sort(&listKeySorter{keys: keys, opts: o, swap: swap})
+	result := ListPage{Total: len(keys)}
+	if o == nil {
+		return 0, len(keys), result
+	}
+	start := 0
+	if o.Continue != nil {
+		start = sort.Search(len(keys), func(i int) bool { return o.less(*o.Continue, keys[i]) })
+	}
+	end := len(keys)
+	if o.Limit > 0 && start+o.Limit < end {
+		end = start + o.Limit
+		last := keys[end-1]
+		last.SortBy = o.SortBy
+		raw, _ := json.Marshal(last)
+		result.Continue = base64.RawURLEncoding.EncodeToString(raw)
+	}
+	return start, end, result
+}
+
+type listKeySorter struct {
+	keys []listKey
+	opts *ListOptions
+	swap func(i, j int)
+}
+
+func (s *listKeySorter) Len() int           { return len(s.keys) }
+func (s *listKeySorter) Less(i, j int) bool { return s.opts.less(s.keys[i], s.keys[j]) }
+func (s *listKeySorter) Swap(i, j int) {
+	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+	s.swap(i, j)
+}
+
+func setListPageHeaders(w http.ResponseWriter, listPage ListPage) {
+	w.Header().Set("Access-Control-Expose-Headers", "X-Total-Count, X-Continue")
+	w.Header().Set("X-Total-Count", strconv.Itoa(listPage.Total))
+	if listPage.Continue != "" {
+		w.Header().Set("X-Continue", listPage.Continue)
+	}
+}
+
+// respondWithList answers a request for a page of an array list with
+// items, wrapped in a ListEnvelope if opts asks for one.
+func respondWithList(w http.ResponseWriter, opts *ListOptions, items interface{}, listPage ListPage) {
+	setListPageHeaders(w, listPage)
+	w.WriteHeader(http.StatusOK)
+	if opts != nil && opts.Envelope {
+		json.NewEncoder(w).Encode(ListEnvelope{Items: items, ListPage: listPage})
+		return
+	}
+	json.NewEncoder(w).Encode(items)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRespondWithList(t *testing.T) {
+	items := []NamespaceDeployments{{Namespace: "personal", Deployments: []string{"web"}}}
+	listPage := ListPage{Total: 3, Continue: "next"}
+	tests := []struct {
+		query    string
+		envelope bool
+		wantErr  bool
+	}{
+		{query: ""},
+		{query: "envelope=false"},
+		{query: "envelope=true", envelope: true},
+		{query: "envelope=maybe", wantErr: true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/namespaces/ANY/deployments?"+tt.query, nil)
+		opts, err := listOptionsFromRequest(r)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("listOptionsFromRequest(%q) = %+v, want an error", tt.query, opts)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("listOptionsFromRequest(%q) failed: %v", tt.query, err)
+		}
+		w := httptest.NewRecorder()
+		respondWithList(w, opts, items, listPage)
+		if got := w.Header().Get("X-Total-Count"); got != "3" {
+			t.Errorf("%q: X-Total-Count = %q, want %q", tt.query, got, "3")
+		}
+		if got := w.Header().Get("X-Continue"); got != "next" {
+			t.Errorf("%q: X-Continue = %q, want %q", tt.query, got, "next")
+		}
+		if got := w.Header().Get("Access-Control-Expose-Headers"); got != "X-Total-Count, X-Continue" {
+			t.Errorf("%q: Access-Control-Expose-Headers = %q", tt.query, got)
+		}
+		if !tt.envelope {
+			var got []NamespaceDeployments
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil || len(got) != 1 {
+				t.Errorf("%q: body %s is not the bare array: %v", tt.query, w.Body.String(), err)
+			}
+			continue
+		}
+		var got struct {
+			Items    []NamespaceDeployments `json:"items"`
+			Total    int                    `json:"total"`
+			Continue string                 `json:"continue"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("%q: body %s is not an envelope: %v", tt.query, w.Body.String(), err)
+		}
+		if len(got.Items) != 1 || got.Total != 3 || got.Continue != "next" {
+			t.Errorf("%q: envelope = %+v, want the items, total 3 and continue %q", tt.query, got, "next")
+		}
+	}
+}
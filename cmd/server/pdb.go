@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	klog "k8s.io/klog/v2"
+)
+
+// PDBViolation is the error returned when scaling a deployment down would
+// break one of its PodDisruptionBudgets.
+type PDBViolation struct {
+	PDB    string
+	Reason string
+}
+
+func (v *PDBViolation) Error() string {
+	return fmt.Sprintf("PodDisruptionBudget %q: %s", v.PDB, v.Reason)
+}
+
+func pdbInformerAdd(App *AppX, nsName string, factory informers.SharedInformerFactory) error {
+	self := "pdbInformerAdd"
+	klog.Infof("%s: entry", self)
+	err := auxInformerAdd(App, informerName("pdbs", nsName), factory.Policy().V1().PodDisruptionBudgets().Informer(),
+		metadataTrimTransform, nil)
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "auxInformerAdd", err)
+	}
+	return nil
+}
+
+// PodPDBsGet returns the PodDisruptionBudgets whose selector matches pod,
+// sorted by name.
+func PodPDBsGet(pod *corev1.Pod) ([]*policyv1.PodDisruptionBudget, error) {
+	self := "PodPDBsGet"
+	informer := informerGet("pdbs", pod.Namespace)
+	if informer == nil {
+		return nil, fmt.Errorf("%s: informers not available for namespace: %q", self, pod.Namespace)
+	}
+	objs, err := informer.GetIndexer().ByIndex(cache.NamespaceIndex, pod.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Indexer).ByIndex", err)
+	}
+	pdbs := make([]*policyv1.PodDisruptionBudget, 0, len(objs))
+	for _, obj := range objs {
+		pdbs = append(pdbs, obj.(*policyv1.PodDisruptionBudget))
+	}
+	return pdbsMatching(pdbs, pod.Labels), nil
+}
+
+// pdbsMatching returns those of pdbs whose selector matches podLabels,
+// sorted by name.  A budget with an empty selector matches every pod in
+// its namespace, and one with no selector matches none.
+func pdbsMatching(pdbs []*policyv1.PodDisruptionBudget, podLabels map[string]string) []*policyv1.PodDisruptionBudget {
+	self := "pdbsMatching"
+	matching := make([]*policyv1.PodDisruptionBudget, 0)
+	for _, pdb := range pdbs {
+		if pdb.Spec.Selector == nil {
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			klog.Errorf("%s: pdb %q: call to %q failed: %#v", self, pdb.Namespace+"/"+pdb.Name,
+				"metav1.LabelSelectorAsSelector", err)
+			continue
+		}
+		if selector.Matches(labels.Set(podLabels)) {
+			matching = append(matching, pdb)
+		}
+	}
+	sort.Slice(matching, func(i, j int) bool { return matching[i].Name < matching[j].Name })
+	return matching
+}
+
+// scaleDownPDBCheck returns the first PodDisruptionBudget, by name, that
+// scaling deployment from current replicas down to replicas would break,
+// or nil if none would.  The replicas removed count as disruptions
+// against the budget as it stands: the healthy pods left must still meet
+// a minAvailable budget, and must not fall more than maxUnavailable below
+// the pods the budget expects.  Percentages are of the pods the budget
+// expects, rounded up.  When its status is out of date, the budget is
+// taken to expect current pods, all of them healthy.  Budgets are
+// read from the informer when it runs and from the API server otherwise,
+// so that role api checks them too.
+func scaleDownPDBCheck(App *AppX, deployment *appsv1.Deployment, current int, replicas int) (*PDBViolation, error) {
+	self := "scaleDownPDBCheck"
+	var pdbs []*policyv1.PodDisruptionBudget
+	if informer := informerGet("pdbs", deployment.Namespace); informer != nil {
+		objs, err := informer.GetIndexer().ByIndex(cache.NamespaceIndex, deployment.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Indexer).ByIndex", err)
+		}
+		for _, obj := range objs {
+			pdbs = append(pdbs, obj.(*policyv1.PodDisruptionBudget))
+		}
+	} else {
+		pdbList, err := App.Clientset.PolicyV1().PodDisruptionBudgets(deployment.Namespace).List(context.TODO(),
+			metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("%s: call to %q failed: %#v", self,
+				"(clientset).PolicyV1().PodDisruptionBudgets().List()", err)
+		}
+		for i := range pdbList.Items {
+			pdbs = append(pdbs, &pdbList.Items[i])
+		}
+	}
+	removed := current - replicas
+	for _, pdb := range pdbsMatching(pdbs, deployment.Spec.Template.Labels) {
+		expected, healthy := current, current
+		if pdb.Status.ObservedGeneration >= pdb.Generation && pdb.Status.ExpectedPods > 0 {
+			expected, healthy = int(pdb.Status.ExpectedPods), int(pdb.Status.CurrentHealthy)
+		}
+		switch {
+		case pdb.Spec.MinAvailable != nil:
+			minAvailable, err := intstr.GetScaledValueFromIntOrPercent(pdb.Spec.MinAvailable, expected, true)
+			if err != nil {
+				return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "intstr.GetScaledValueFromIntOrPercent", err)
+			}
+			if healthy-removed < minAvailable {
+				return &PDBViolation{PDB: pdb.Name, Reason: fmt.Sprintf(
+					"minAvailable %s needs %d of %d pods; scaling from %d to %d replicas leaves %d of %d healthy",
+					pdb.Spec.MinAvailable.String(), minAvailable, expected, current, replicas, healthy-removed, healthy)}, nil
+			}
+		case pdb.Spec.MaxUnavailable != nil:
+			maxUnavailable, err := intstr.GetScaledValueFromIntOrPercent(pdb.Spec.MaxUnavailable, expected, true)
+			if err != nil {
+				return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "intstr.GetScaledValueFromIntOrPercent", err)
+			}
+			if healthy-removed < expected-maxUnavailable {
+				return &PDBViolation{PDB: pdb.Name, Reason: fmt.Sprintf(
+					"maxUnavailable %s lets %d of %d pods be unavailable; %d are healthy and scaling from %d to %d replicas removes %d",
+					pdb.Spec.MaxUnavailable.String(), maxUnavailable, expected, healthy, current, replicas, removed)}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// podEvictionCheck decides, as the API server's eviction handler does,
+// whether pod may be evicted given pdbs, the budgets that match it.
+// remaining holds what is left of each budget's disruptionsAllowed, keyed
+// by namespace and name, and is decremented when the eviction would use
+// up a disruption, so that checking several pods in turn shows which of
+// them could be evicted together.  The reason says why an eviction is
+// refused.
+func podEvictionCheck(pod *corev1.Pod, pdbs []*policyv1.PodDisruptionBudget, remaining map[string]int) (bool, string) {
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed ||
+		pod.Status.Phase == corev1.PodPending || pod.DeletionTimestamp != nil {
+		return true, ""
+	}
+	if len(pdbs) == 0 {
+		return true, ""
+	}
+	if len(pdbs) > 1 {
+		return false, "pod has more than one PodDisruptionBudget"
+	}
+	pdb := pdbs[0]
+	if !podReady(pod) {
+		if pdb.Spec.UnhealthyPodEvictionPolicy != nil &&
+			*pdb.Spec.UnhealthyPodEvictionPolicy == policyv1.AlwaysAllow {
+			return true, ""
+		}
+		if pdb.Status.CurrentHealthy >= pdb.Status.DesiredHealthy && pdb.Status.DesiredHealthy > 0 {
+			return true, ""
+		}
+	}
+	if pdb.Status.ObservedGeneration < pdb.Generation {
+		return false, fmt.Sprintf("PodDisruptionBudget %q not yet processed by the disruption controller", pdb.Name)
+	}
+	key := pdb.Namespace + "/" + pdb.Name
+	if _, ok := remaining[key]; !ok {
+		remaining[key] = int(pdb.Status.DisruptionsAllowed)
+	}
+	if remaining[key] <= 0 {
+		return false, fmt.Sprintf("PodDisruptionBudget %q allows no more disruptions", pdb.Name)
+	}
+	remaining[key]--
+	return true, ""
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testPDB(name string, selector *metav1.LabelSelector) *policyv1.PodDisruptionBudget {
+	return &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "personal"},
+		Spec:       policyv1.PodDisruptionBudgetSpec{Selector: selector},
+	}
+}
+
+func TestPDBsMatching(t *testing.T) {
+	appWeb := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}
+	tierIn := &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+		{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"front", "edge"}},
+	}}
+	invalid := &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+		{Key: "tier", Operator: "Near", Values: []string{"front"}},
+	}}
+	tests := []struct {
+		name      string
+		pdbs      []*policyv1.PodDisruptionBudget
+		podLabels map[string]string
+		want      []string
+	}{
+		{name: "no budgets", podLabels: map[string]string{"app": "web"}, want: []string{}},
+		{name: "nil selector matches nothing", pdbs: []*policyv1.PodDisruptionBudget{testPDB("none", nil)},
+			podLabels: map[string]string{"app": "web"}, want: []string{}},
+		{name: "empty selector matches everything",
+			pdbs:      []*policyv1.PodDisruptionBudget{testPDB("all", &metav1.LabelSelector{})},
+			podLabels: map[string]string{"app": "web"}, want: []string{"all"}},
+		{name: "empty selector matches unlabeled pods",
+			pdbs: []*policyv1.PodDisruptionBudget{testPDB("all", &metav1.LabelSelector{})}, want: []string{"all"}},
+		{name: "match labels", pdbs: []*policyv1.PodDisruptionBudget{testPDB("web", appWeb)},
+			podLabels: map[string]string{"app": "web", "tier": "front"}, want: []string{"web"}},
+		{name: "match labels mismatch", pdbs: []*policyv1.PodDisruptionBudget{testPDB("web", appWeb)},
+			podLabels: map[string]string{"app": "api"}, want: []string{}},
+		{name: "match expressions", pdbs: []*policyv1.PodDisruptionBudget{testPDB("tier", tierIn)},
+			podLabels: map[string]string{"tier": "edge"}, want: []string{"tier"}},
+		{name: "invalid selector skipped", pdbs: []*policyv1.PodDisruptionBudget{testPDB("bad", invalid)},
+			podLabels: map[string]string{"tier": "front"}, want: []string{}},
+		{name: "sorted by name",
+			pdbs: []*policyv1.PodDisruptionBudget{testPDB("web", appWeb), testPDB("none", nil),
+				testPDB("all", &metav1.LabelSelector{}), testPDB("tier", tierIn)},
+			podLabels: map[string]string{"app": "web", "tier": "front"}, want: []string{"all", "tier", "web"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, pdb := range pdbsMatching(tt.pdbs, tt.podLabels) {
+				got = append(got, pdb.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pdbsMatching() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScaleDownPDBCheck(t *testing.T) {
+	savedApp := InformersSavedApp
+	defer func() { InformersSavedApp = savedApp }()
+	// Without informers the budgets are read from the API server.
+	InformersSavedApp = nil
+
+	appWeb := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}
+	minAvailable := func(name string, v intstr.IntOrString) *policyv1.PodDisruptionBudget {
+		pdb := testPDB(name, appWeb)
+		pdb.Spec.MinAvailable = &v
+		return pdb
+	}
+	maxUnavailable := func(name string, v intstr.IntOrString) *policyv1.PodDisruptionBudget {
+		pdb := testPDB(name, appWeb)
+		pdb.Spec.MaxUnavailable = &v
+		return pdb
+	}
+	// expecting marks pdb as processed by the disruption controller, which
+	// expects expectedPods pods and sees healthy of them, unless stale.
+	expecting := func(pdb *policyv1.PodDisruptionBudget, expectedPods int32, healthy int32,
+		stale bool) *policyv1.PodDisruptionBudget {
+		pdb.Generation = 2
+		pdb.Status.ObservedGeneration = 2
+		if stale {
+			pdb.Status.ObservedGeneration = 1
+		}
+		pdb.Status.ExpectedPods, pdb.Status.CurrentHealthy = expectedPods, healthy
+		return pdb
+	}
+	tests := []struct {
+		name     string
+		pdbs     []*policyv1.PodDisruptionBudget
+		current  int
+		replicas int
+		want     string
+	}{
+		{name: "no budgets", current: 3, replicas: 0},
+		{name: "minAvailable met", pdbs: []*policyv1.PodDisruptionBudget{minAvailable("min", intstr.FromInt(2))},
+			current: 3, replicas: 2},
+		{name: "minAvailable broken", pdbs: []*policyv1.PodDisruptionBudget{minAvailable("min", intstr.FromInt(2))},
+			current: 3, replicas: 1, want: "min"},
+		{name: "minAvailable percent exact",
+			pdbs:    []*policyv1.PodDisruptionBudget{minAvailable("min", intstr.FromString("50%"))},
+			current: 4, replicas: 2},
+		{name: "minAvailable percent rounds up",
+			pdbs:    []*policyv1.PodDisruptionBudget{minAvailable("min", intstr.FromString("50%"))},
+			current: 3, replicas: 1, want: "min"},
+		{name: "minAvailable percent rounded up still met",
+			pdbs:    []*policyv1.PodDisruptionBudget{minAvailable("min", intstr.FromString("50%"))},
+			current: 3, replicas: 2},
+		{name: "minAvailable counts expected pods",
+			pdbs:    []*policyv1.PodDisruptionBudget{expecting(minAvailable("min", intstr.FromInt(2)), 5, 5, false)},
+			current: 3, replicas: 1},
+		{name: "minAvailable ignores expected pods of a stale status",
+			pdbs:    []*policyv1.PodDisruptionBudget{expecting(minAvailable("min", intstr.FromInt(2)), 5, 5, true)},
+			current: 3, replicas: 1, want: "min"},
+		{name: "minAvailable percent of expected pods",
+			pdbs:    []*policyv1.PodDisruptionBudget{expecting(minAvailable("min", intstr.FromString("50%")), 10, 10, false)},
+			current: 6, replicas: 1},
+		{name: "minAvailable percent of expected pods broken",
+			pdbs:    []*policyv1.PodDisruptionBudget{expecting(minAvailable("min", intstr.FromString("50%")), 10, 10, false)},
+			current: 6, replicas: 0, want: "min"},
+		{name: "maxUnavailable met", pdbs: []*policyv1.PodDisruptionBudget{maxUnavailable("max", intstr.FromInt(1))},
+			current: 3, replicas: 2},
+		{name: "maxUnavailable broken", pdbs: []*policyv1.PodDisruptionBudget{maxUnavailable("max", intstr.FromInt(1))},
+			current: 3, replicas: 1, want: "max"},
+		{name: "maxUnavailable percent",
+			pdbs:    []*policyv1.PodDisruptionBudget{maxUnavailable("max", intstr.FromString("25%"))},
+			current: 4, replicas: 2, want: "max"},
+		{name: "maxUnavailable percent rounds up",
+			pdbs:    []*policyv1.PodDisruptionBudget{maxUnavailable("max", intstr.FromString("25%"))},
+			current: 5, replicas: 3},
+		{name: "maxUnavailable percent of expected pods",
+			pdbs:    []*policyv1.PodDisruptionBudget{expecting(maxUnavailable("max", intstr.FromString("34%")), 10, 10, false)},
+			current: 6, replicas: 2},
+		{name: "maxUnavailable percent of current when stale",
+			pdbs:    []*policyv1.PodDisruptionBudget{expecting(maxUnavailable("max", intstr.FromString("34%")), 10, 10, true)},
+			current: 6, replicas: 2, want: "max"},
+		{name: "minAvailable counts unhealthy pods",
+			pdbs:    []*policyv1.PodDisruptionBudget{expecting(minAvailable("min", intstr.FromInt(4)), 5, 3, false)},
+			current: 5, replicas: 4, want: "min"},
+		{name: "minAvailable met by healthy pods",
+			pdbs:    []*policyv1.PodDisruptionBudget{expecting(minAvailable("min", intstr.FromInt(3)), 5, 4, false)},
+			current: 5, replicas: 4},
+		{name: "minAvailable takes all pods healthy when stale",
+			pdbs:    []*policyv1.PodDisruptionBudget{expecting(minAvailable("min", intstr.FromInt(4)), 5, 3, true)},
+			current: 5, replicas: 4},
+		{name: "maxUnavailable counts unhealthy pods",
+			pdbs:    []*policyv1.PodDisruptionBudget{expecting(maxUnavailable("max", intstr.FromInt(1)), 5, 4, false)},
+			current: 5, replicas: 4, want: "max"},
+		{name: "maxUnavailable met by healthy pods",
+			pdbs:    []*policyv1.PodDisruptionBudget{expecting(maxUnavailable("max", intstr.FromInt(2)), 5, 4, false)},
+			current: 5, replicas: 4},
+		{name: "other pods' budget ignored",
+			pdbs: []*policyv1.PodDisruptionBudget{func() *policyv1.PodDisruptionBudget {
+				pdb := testPDB("api", &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}})
+				v := intstr.FromInt(3)
+				pdb.Spec.MinAvailable = &v
+				return pdb
+			}()},
+			current: 3, replicas: 0},
+		{name: "nil selector ignored",
+			pdbs: []*policyv1.PodDisruptionBudget{func() *policyv1.PodDisruptionBudget {
+				pdb := testPDB("none", nil)
+				v := intstr.FromInt(3)
+				pdb.Spec.MinAvailable = &v
+				return pdb
+			}()},
+			current: 3, replicas: 0},
+		{name: "empty selector applies",
+			pdbs: []*policyv1.PodDisruptionBudget{func() *policyv1.PodDisruptionBudget {
+				pdb := testPDB("all", &metav1.LabelSelector{})
+				v := intstr.FromInt(3)
+				pdb.Spec.MinAvailable = &v
+				return pdb
+			}()},
+			current: 3, replicas: 2, want: "all"},
+		{name: "first broken budget by name",
+			pdbs: []*policyv1.PodDisruptionBudget{maxUnavailable("zeta", intstr.FromInt(0)),
+				minAvailable("beta", intstr.FromInt(3)), maxUnavailable("alpha", intstr.FromInt(2))},
+			current: 3, replicas: 2, want: "beta"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := make([]runtime.Object, 0, len(tt.pdbs))
+			for _, pdb := range tt.pdbs {
+				objects = append(objects, pdb)
+			}
+			App := &AppX{Clientset: fake.NewSimpleClientset(objects...)}
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "personal"},
+				Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+				}},
+			}
+			violation, err := scaleDownPDBCheck(App, deployment, tt.current, tt.replicas)
+			if err != nil {
+				t.Fatalf("scaleDownPDBCheck() failed: %v", err)
+			}
+			got := ""
+			if violation != nil {
+				got = violation.PDB
+			}
+			if got != tt.want {
+				t.Errorf("scaleDownPDBCheck(%d -> %d) = %v, want budget %q", tt.current, tt.replicas, violation, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	klog "k8s.io/klog/v2"
+)
+
+const (
+	ownerIndex = "owner"
+	nodeIndex  = "node"
+)
+
+// ContainerItem is the state of one of a pod's containers.  Reason is set
+// for waiting and terminated containers, e.g. "CrashLoopBackOff" or
+// "OOMKilled"; LastReason is why the container last terminated.
+type ContainerItem struct {
+	Name       string `json:"container"`
+	Init       bool   `json:"init,omitempty"`
+	State      string `json:"state"`
+	Reason     string `json:"reason,omitempty"`
+	ExitCode   *int   `json:"exit_code,omitempty"`
+	LastReason string `json:"last_terminated_reason,omitempty"`
+	Ready      bool   `json:"ready"`
+	Restarts   int    `json:"restart_count"`
+}
+
+type PodItem struct {
+	Name       string          `json:"pod"`
+	Phase      string          `json:"phase"`
+	Ready      bool            `json:"ready"`
+	Restarts   int             `json:"restart_count"`
+	Node       string          `json:"node,omitempty"`
+	PodIP      string          `json:"pod_ip,omitempty"`
+	Created    time.Time       `json:"created"`
+	Age        string          `json:"age"`
+	Containers []ContainerItem `json:"containers"`
+}
+
+// ownerIndexFunc indexes objects by the UID of their controller, so that
+// the replicasets of a deployment, and the pods of a replicaset, can be
+// found without listing the namespace.
+func ownerIndexFunc(obj interface{}) ([]string, error) {
+	object, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	owner := metav1.GetControllerOfNoCopy(object)
+	if owner == nil {
+		return []string{}, nil
+	}
+	return []string{string(owner.UID)}, nil
+}
+
+// nodeIndexFunc indexes pods by the node they are scheduled to.
+func nodeIndexFunc(obj interface{}) ([]string, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok || pod.Spec.NodeName == "" {
+		return []string{}, nil
+	}
+	return []string{pod.Spec.NodeName}, nil
+}
+
+// podInformersAdd adds the replicaset and pod informers to factory, both
+// indexed by owner, and pods also by node.
+func podInformersAdd(App *AppX, nsName string, factory informers.SharedInformerFactory) error {
+	self := "podInformersAdd"
+	klog.Infof("%s: entry", self)
+	err := auxInformerAdd(App, informerName("replicasets", nsName), factory.Apps().V1().ReplicaSets().Informer(),
+		replicaSetTransform, cache.Indexers{ownerIndex: ownerIndexFunc})
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "auxInformerAdd", err)
+	}
+	err = auxInformerAdd(App, informerName("pods", nsName), factory.Core().V1().Pods().Informer(),
+		podTransform, cache.Indexers{ownerIndex: ownerIndexFunc, nodeIndex: nodeIndexFunc})
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "auxInformerAdd", err)
+	}
+	return nil
+}
+
+// DeploymentPodsGet returns the pods of deployment nsName/dName, found
+// through the replicasets the deployment controls and narrowed to those
+// matching the deployment's selector.
+func DeploymentPodsGet(nsName string, dName string) ([]PodItem, error) {
+	self := "DeploymentPodsGet"
+	klog.Infof("%s: entry", self)
+	_, _, pods, err := deploymentObjectsGet(nsName, dName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "deploymentObjectsGet", err)
+	}
+	now := time.Now()
+	podList := make([]PodItem, 0, len(pods))
+	for _, pod := range pods {
+		podList = append(podList, podItemFrom(pod, now))
+	}
+	sort.Slice(podList, func(i, j int) bool { return podList[i].Name < podList[j].Name })
+	return podList, nil
+}
+
+// deploymentObjectsGet returns deployment nsName/dName from its informer,
+// the replicasets it controls and their pods that match its selector.
+func deploymentObjectsGet(nsName string, dName string) (*appsv1.Deployment, []*appsv1.ReplicaSet, []*corev1.Pod, error) {
+	self := "deploymentObjectsGet"
+	deploymentInformer, replicaSetInformer, podInformer :=
+		informerGet("deployments", nsName), informerGet("replicasets", nsName), informerGet("pods", nsName)
+	if deploymentInformer == nil || replicaSetInformer == nil || podInformer == nil {
+		return nil, nil, nil, fmt.Errorf("%s: informers not available for namespace: %q", self, nsName)
+	}
+	obj, exists, err := deploymentInformer.GetStore().GetByKey(nsName + "/" + dName)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Store).GetByKey", err)
+	}
+	if !exists {
+		return nil, nil, nil, fmt.Errorf("unknown %q arg value: %q", "dName", dName)
+	}
+	deployment := obj.(*appsv1.Deployment)
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: call to %q failed: %#v", self, "metav1.LabelSelectorAsSelector", err)
+	}
+	objs, err := replicaSetInformer.GetIndexer().ByIndex(ownerIndex, string(deployment.UID))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Indexer).ByIndex", err)
+	}
+	replicaSets := make([]*appsv1.ReplicaSet, 0, len(objs))
+	pods := make([]*corev1.Pod, 0)
+	for _, obj := range objs {
+		replicaSet := obj.(*appsv1.ReplicaSet)
+		replicaSets = append(replicaSets, replicaSet)
+		podObjs, err := podInformer.GetIndexer().ByIndex(ownerIndex, string(replicaSet.UID))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Indexer).ByIndex", err)
+		}
+		for _, podObj := range podObjs {
+			pod := podObj.(*corev1.Pod)
+			if selector.Matches(labels.Set(pod.Labels)) {
+				pods = append(pods, pod)
+			}
+		}
+	}
+	return deployment, replicaSets, pods, nil
+}
+
+func podItemFrom(pod *corev1.Pod, now time.Time) PodItem {
+	pi := PodItem{Name: pod.Name, Phase: string(pod.Status.Phase), Node: pod.Spec.NodeName, PodIP: pod.Status.PodIP,
+		Created: pod.CreationTimestamp.UTC(), Age: duration.HumanDuration(now.Sub(pod.CreationTimestamp.Time)),
+		Ready: podReady(pod), Containers: []ContainerItem{}}
+	for _, status := range pod.Status.InitContainerStatuses {
+		ci := containerItemFrom(status)
+		ci.Init = true
+		pi.Containers = append(pi.Containers, ci)
+		pi.Restarts += ci.Restarts
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		ci := containerItemFrom(status)
+		pi.Containers = append(pi.Containers, ci)
+		pi.Restarts += ci.Restarts
+	}
+	return pi
+}
+
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func containerItemFrom(status corev1.ContainerStatus) ContainerItem {
+	ci := ContainerItem{Name: status.Name, Ready: status.Ready, Restarts: int(status.RestartCount)}
+	switch {
+	case status.State.Waiting != nil:
+		ci.State, ci.Reason = "waiting", status.State.Waiting.Reason
+	case status.State.Terminated != nil:
+		exitCode := int(status.State.Terminated.ExitCode)
+		ci.State, ci.Reason, ci.ExitCode = "terminated", status.State.Terminated.Reason, &exitCode
+	case status.State.Running != nil:
+		ci.State = "running"
+	default:
+		ci.State = "unknown"
+	}
+	if status.LastTerminationState.Terminated != nil {
+		ci.LastReason = status.LastTerminationState.Terminated.Reason
+	}
+	return ci
+}
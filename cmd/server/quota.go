@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	klog "k8s.io/klog/v2"
+)
+
+// QuotaResourceItem is one resource of a ResourceQuota.  Headroom is hard
+// less used, and negative when the quota was lowered below its usage.
+type QuotaResourceItem struct {
+	Hard     string `json:"hard"`
+	Used     string `json:"used"`
+	Headroom string `json:"headroom"`
+}
+
+// QuotaItem is a ResourceQuota.  Scoped quotas count only some pods, e.g.
+// those of a priority class, and are not used to check scale-ups.
+type QuotaItem struct {
+	Name      string                       `json:"quota"`
+	Scoped    bool                         `json:"scoped"`
+	Resources map[string]QuotaResourceItem `json:"resources"`
+}
+
+// QuotaViolation is the error returned when the pods a deployment
+// scale-up adds would not fit in one of the namespace's ResourceQuotas.
+type QuotaViolation struct {
+	Quota    string
+	Resource string
+	Reason   string
+}
+
+func (v *QuotaViolation) Error() string {
+	return fmt.Sprintf("ResourceQuota %q: %s: %s", v.Quota, v.Resource, v.Reason)
+}
+
+func quotaInformersAdd(App *AppX, nsName string, factory informers.SharedInformerFactory) error {
+	self := "quotaInformersAdd"
+	klog.Infof("%s: entry", self)
+	err := auxInformerAdd(App, informerName("resourcequotas", nsName), factory.Core().V1().ResourceQuotas().Informer(),
+		metadataTrimTransform, nil)
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "auxInformerAdd", err)
+	}
+	err = auxInformerAdd(App, informerName("limitranges", nsName), factory.Core().V1().LimitRanges().Informer(),
+		metadataTrimTransform, nil)
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "auxInformerAdd", err)
+	}
+	return nil
+}
+
+func QuotaListGet(nsName string) ([]QuotaItem, error) {
+	self := "QuotaListGet"
+	klog.Infof("%s: entry", self)
+	informer := informerGet("resourcequotas", nsName)
+	if informer == nil {
+		return nil, fmt.Errorf("%s: informers not available for namespace: %q", self, nsName)
+	}
+	objs, err := informer.GetIndexer().ByIndex(cache.NamespaceIndex, nsName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Indexer).ByIndex", err)
+	}
+	quotaList := make([]QuotaItem, 0, len(objs))
+	for _, obj := range objs {
+		quotaList = append(quotaList, quotaItemFrom(obj.(*corev1.ResourceQuota)))
+	}
+	sort.Slice(quotaList, func(i, j int) bool { return quotaList[i].Name < quotaList[j].Name })
+	return quotaList, nil
+}
+
+func quotaItemFrom(quota *corev1.ResourceQuota) QuotaItem {
+	qi := QuotaItem{Name: quota.Name, Scoped: quotaScoped(quota), Resources: make(map[string]QuotaResourceItem)}
+	for name, hard := range quota.Status.Hard {
+		used := quota.Status.Used[name]
+		headroom := hard.DeepCopy()
+		headroom.Sub(used)
+		qi.Resources[string(name)] = QuotaResourceItem{Hard: hard.String(), Used: used.String(), Headroom: headroom.String()}
+	}
+	return qi
+}
+
+func quotaScoped(quota *corev1.ResourceQuota) bool {
+	return len(quota.Spec.Scopes) > 0 || quota.Spec.ScopeSelector != nil
+}
+
+// scaleUpQuotaCheck returns the first ResourceQuota, by name, that the
+// pods added by scaling deployment from current replicas up to replicas
+// would not fit in, or nil if all fit.  Each pod is taken to request what
+// its template does, with LimitRange defaults filled in as admission
+// would, and a quota on a resource that the pods neither request nor get
+// a default for refuses them outright.  Scoped quotas are skipped.
+// Quotas and LimitRanges are read from the informers when they run and
+// from the API server otherwise.
+func scaleUpQuotaCheck(App *AppX, deployment *appsv1.Deployment, current int, replicas int) (*QuotaViolation, error) {
+	self := "scaleUpQuotaCheck"
+	quotas, limitRanges, err := namespaceQuotasGet(App, deployment.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "namespaceQuotasGet", err)
+	}
+	perPod := podTemplateUsage(&deployment.Spec.Template.Spec, limitRanges)
+	added := int64(replicas - current)
+	for _, quota := range quotas {
+		if quotaScoped(quota) {
+			continue
+		}
+		names := make([]string, 0, len(quota.Status.Hard))
+		for name := range quota.Status.Hard {
+			names = append(names, string(name))
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			hard := quota.Status.Hard[corev1.ResourceName(name)]
+			podUsage, known := perPod[corev1.ResourceName(name)]
+			if !known {
+				continue
+			}
+			if podUsage.IsZero() {
+				return &QuotaViolation{Quota: quota.Name, Resource: name, Reason: fmt.Sprintf(
+					"the quota requires pods to set %s, which the pod template does not and no LimitRange defaults",
+					name)}, nil
+			}
+			extra := resource.NewMilliQuantity(podUsage.MilliValue()*added, podUsage.Format)
+			headroom := hard.DeepCopy()
+			headroom.Sub(quota.Status.Used[corev1.ResourceName(name)])
+			if extra.Cmp(headroom) > 0 {
+				return &QuotaViolation{Quota: quota.Name, Resource: name, Reason: fmt.Sprintf(
+					"scaling from %d to %d replicas needs %s more, but only %s of %s is left",
+					current, replicas, extra.String(), headroom.String(), hard.String())}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// namespaceQuotasGet returns the ResourceQuotas and the LimitRanges of
+// namespace nsName, each sorted by name.
+func namespaceQuotasGet(App *AppX, nsName string) ([]*corev1.ResourceQuota, []*corev1.LimitRange, error) {
+	self := "namespaceQuotasGet"
+	quotas, limitRanges := make([]*corev1.ResourceQuota, 0), make([]*corev1.LimitRange, 0)
+	quotaInformer, limitRangeInformer := informerGet("resourcequotas", nsName), informerGet("limitranges", nsName)
+	if quotaInformer != nil && limitRangeInformer != nil {
+		objs, err := quotaInformer.GetIndexer().ByIndex(cache.NamespaceIndex, nsName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Indexer).ByIndex", err)
+		}
+		for _, obj := range objs {
+			quotas = append(quotas, obj.(*corev1.ResourceQuota))
+		}
+		objs, err = limitRangeInformer.GetIndexer().ByIndex(cache.NamespaceIndex, nsName)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Indexer).ByIndex", err)
+		}
+		for _, obj := range objs {
+			limitRanges = append(limitRanges, obj.(*corev1.LimitRange))
+		}
+	} else {
+		quotaList, err := App.Clientset.CoreV1().ResourceQuotas(nsName).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(clientset).CoreV1().ResourceQuotas().List()", err)
+		}
+		for i := range quotaList.Items {
+			quotas = append(quotas, &quotaList.Items[i])
+		}
+		limitRangeList, err := App.Clientset.CoreV1().LimitRanges(nsName).List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(clientset).CoreV1().LimitRanges().List()", err)
+		}
+		for i := range limitRangeList.Items {
+			limitRanges = append(limitRanges, &limitRangeList.Items[i])
+		}
+	}
+	sort.Slice(quotas, func(i, j int) bool { return quotas[i].Name < quotas[j].Name })
+	sort.Slice(limitRanges, func(i, j int) bool { return limitRanges[i].Name < limitRanges[j].Name })
+	return quotas, limitRanges, nil
+}
+
+// podTemplateUsage returns what one pod of spec counts against a quota,
+// under each quota resource name this server checks.  A container's
+// request is its own, else its limit, else the first LimitRange default
+// request, else the default limit; its limit is its own, else the default
+// limit.  As for the quota controller, a pod requests the larger of its
+// containers' sum and its largest init container, plus its overhead.
+func podTemplateUsage(spec *corev1.PodSpec, limitRanges []*corev1.LimitRange) corev1.ResourceList {
+	defaultRequests, defaultLimits := corev1.ResourceList{}, corev1.ResourceList{}
+	for _, limitRange := range limitRanges {
+		for _, item := range limitRange.Spec.Limits {
+			if item.Type != corev1.LimitTypeContainer {
+				continue
+			}
+			for name, quantity := range item.Default {
+				if _, ok := defaultLimits[name]; !ok {
+					defaultLimits[name] = quantity
+				}
+			}
+			for name, quantity := range item.DefaultRequest {
+				if _, ok := defaultRequests[name]; !ok {
+					defaultRequests[name] = quantity
+				}
+			}
+		}
+	}
+	containerUsage := func(container *corev1.Container, name corev1.ResourceName) (resource.Quantity, resource.Quantity) {
+		limit, ok := container.Resources.Limits[name]
+		if !ok {
+			limit = defaultLimits[name]
+		}
+		request, ok := container.Resources.Requests[name]
+		ownLimit, defaultRequest := container.Resources.Limits[name], defaultRequests[name]
+		switch {
+		case ok:
+		case !ownLimit.IsZero():
+			request = ownLimit
+		case !defaultRequest.IsZero():
+			request = defaultRequest
+		default:
+			request = defaultLimits[name]
+		}
+		return request, limit
+	}
+	usage := corev1.ResourceList{
+		corev1.ResourcePods:               resource.MustParse("1"),
+		corev1.ResourceName("count/pods"): resource.MustParse("1"),
+	}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		var requests, limits, initRequests, initLimits resource.Quantity
+		for i := range spec.Containers {
+			request, limit := containerUsage(&spec.Containers[i], name)
+			requests.Add(request)
+			limits.Add(limit)
+		}
+		for i := range spec.InitContainers {
+			request, limit := containerUsage(&spec.InitContainers[i], name)
+			if request.Cmp(initRequests) > 0 {
+				initRequests = request
+			}
+			if limit.Cmp(initLimits) > 0 {
+				initLimits = limit
+			}
+		}
+		if initRequests.Cmp(requests) > 0 {
+			requests = initRequests
+		}
+		if initLimits.Cmp(limits) > 0 {
+			limits = initLimits
+		}
+		if overhead, ok := spec.Overhead[name]; ok {
+			requests.Add(overhead)
+			limits.Add(overhead)
+		}
+		usage[name] = requests
+		usage[corev1.ResourceName("requests."+string(name))] = requests
+		usage[corev1.ResourceName("limits."+string(name))] = limits
+	}
+	return usage
+}
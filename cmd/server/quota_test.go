@@ -0,0 +1,252 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// testResources builds a ResourceList from name/quantity pairs.
+func testResources(pairs ...string) corev1.ResourceList {
+	list := corev1.ResourceList{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		list[corev1.ResourceName(pairs[i])] = resource.MustParse(pairs[i+1])
+	}
+	return list
+}
+
+func testContainer(requests corev1.ResourceList, limits corev1.ResourceList) corev1.Container {
+	return corev1.Container{Name: "c", Resources: corev1.ResourceRequirements{Requests: requests, Limits: limits}}
+}
+
+func testLimitRange(name string, limitType corev1.LimitType, defaultRequest corev1.ResourceList,
+	defaultLimit corev1.ResourceList) *corev1.LimitRange {
+	return &corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "personal"},
+		Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{
+			{Type: limitType, DefaultRequest: defaultRequest, Default: defaultLimit},
+		}},
+	}
+}
+
+func TestPodTemplateUsage(t *testing.T) {
+	tests := []struct {
+		name        string
+		spec        corev1.PodSpec
+		limitRanges []*corev1.LimitRange
+		want        corev1.ResourceList
+	}{
+		{name: "own request and limit",
+			spec: corev1.PodSpec{Containers: []corev1.Container{
+				testContainer(testResources("cpu", "100m"), testResources("cpu", "500m"))}},
+			limitRanges: []*corev1.LimitRange{testLimitRange("lr", corev1.LimitTypeContainer,
+				testResources("cpu", "200m"), testResources("cpu", "400m"))},
+			want: testResources("cpu", "100m", "requests.cpu", "100m", "limits.cpu", "500m", "pods", "1", "count/pods", "1")},
+		{name: "request falls back to own limit",
+			spec: corev1.PodSpec{Containers: []corev1.Container{
+				testContainer(nil, testResources("cpu", "300m"))}},
+			limitRanges: []*corev1.LimitRange{testLimitRange("lr", corev1.LimitTypeContainer,
+				testResources("cpu", "200m"), testResources("cpu", "400m"))},
+			want: testResources("requests.cpu", "300m", "limits.cpu", "300m")},
+		{name: "request falls back to default request",
+			spec: corev1.PodSpec{Containers: []corev1.Container{testContainer(nil, nil)}},
+			limitRanges: []*corev1.LimitRange{testLimitRange("lr", corev1.LimitTypeContainer,
+				testResources("cpu", "200m"), testResources("cpu", "400m"))},
+			want: testResources("requests.cpu", "200m", "limits.cpu", "400m")},
+		{name: "request falls back to default limit",
+			spec: corev1.PodSpec{Containers: []corev1.Container{testContainer(nil, nil)}},
+			limitRanges: []*corev1.LimitRange{testLimitRange("lr", corev1.LimitTypeContainer,
+				nil, testResources("memory", "256Mi"))},
+			want: testResources("memory", "256Mi", "requests.memory", "256Mi", "limits.memory", "256Mi")},
+		{name: "own request keeps default limit",
+			spec: corev1.PodSpec{Containers: []corev1.Container{
+				testContainer(testResources("memory", "64Mi"), nil)}},
+			limitRanges: []*corev1.LimitRange{testLimitRange("lr", corev1.LimitTypeContainer,
+				nil, testResources("memory", "256Mi"))},
+			want: testResources("requests.memory", "64Mi", "limits.memory", "256Mi")},
+		{name: "nothing set",
+			spec: corev1.PodSpec{Containers: []corev1.Container{testContainer(nil, nil)}},
+			want: testResources("requests.cpu", "0", "limits.cpu", "0", "requests.memory", "0", "limits.memory", "0")},
+		{name: "pod limit ranges ignored",
+			spec: corev1.PodSpec{Containers: []corev1.Container{testContainer(nil, nil)}},
+			limitRanges: []*corev1.LimitRange{testLimitRange("lr", corev1.LimitTypePod,
+				testResources("cpu", "200m"), testResources("cpu", "400m"))},
+			want: testResources("requests.cpu", "0", "limits.cpu", "0")},
+		{name: "first limit range default wins",
+			spec: corev1.PodSpec{Containers: []corev1.Container{testContainer(nil, nil)}},
+			limitRanges: []*corev1.LimitRange{
+				testLimitRange("a", corev1.LimitTypeContainer, testResources("cpu", "100m"), nil),
+				testLimitRange("b", corev1.LimitTypeContainer, testResources("cpu", "200m"), testResources("cpu", "1"))},
+			want: testResources("requests.cpu", "100m", "limits.cpu", "1")},
+		{name: "containers summed",
+			spec: corev1.PodSpec{Containers: []corev1.Container{
+				testContainer(testResources("cpu", "100m"), testResources("cpu", "200m")),
+				testContainer(testResources("cpu", "200m"), testResources("cpu", "300m"))}},
+			want: testResources("requests.cpu", "300m", "limits.cpu", "500m")},
+		{name: "init container larger than the sum",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					testContainer(testResources("cpu", "100m"), testResources("cpu", "200m")),
+					testContainer(testResources("cpu", "200m"), testResources("cpu", "300m"))},
+				InitContainers: []corev1.Container{
+					testContainer(testResources("cpu", "250m"), testResources("cpu", "250m")),
+					testContainer(testResources("cpu", "500m"), testResources("cpu", "400m"))}},
+			want: testResources("requests.cpu", "500m", "limits.cpu", "500m")},
+		{name: "init containers smaller than the sum",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					testContainer(testResources("cpu", "100m"), nil),
+					testContainer(testResources("cpu", "200m"), nil)},
+				InitContainers: []corev1.Container{
+					testContainer(testResources("cpu", "250m"), nil)}},
+			want: testResources("requests.cpu", "300m")},
+		{name: "init container takes defaults",
+			spec: corev1.PodSpec{
+				Containers:     []corev1.Container{testContainer(testResources("cpu", "100m"), nil)},
+				InitContainers: []corev1.Container{testContainer(nil, nil)}},
+			limitRanges: []*corev1.LimitRange{testLimitRange("lr", corev1.LimitTypeContainer,
+				testResources("cpu", "200m"), nil)},
+			want: testResources("requests.cpu", "200m")},
+		{name: "overhead added",
+			spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					testContainer(testResources("cpu", "300m", "memory", "128Mi"), testResources("cpu", "400m"))},
+				Overhead: testResources("cpu", "50m", "memory", "32Mi")},
+			want: testResources("requests.cpu", "350m", "limits.cpu", "450m", "requests.memory", "160Mi",
+				"limits.memory", "32Mi")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usage := podTemplateUsage(&tt.spec, tt.limitRanges)
+			for name, want := range tt.want {
+				got, ok := usage[name]
+				if !ok {
+					t.Errorf("podTemplateUsage()[%q] missing, want %s", name, want.String())
+					continue
+				}
+				if got.Cmp(want) != 0 {
+					t.Errorf("podTemplateUsage()[%q] = %s, want %s", name, got.String(), want.String())
+				}
+			}
+		})
+	}
+}
+
+func testQuota(name string, hard corev1.ResourceList, used corev1.ResourceList) *corev1.ResourceQuota {
+	return &corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "personal"},
+		Spec:       corev1.ResourceQuotaSpec{Hard: hard},
+		Status:     corev1.ResourceQuotaStatus{Hard: hard, Used: used},
+	}
+}
+
+func TestScaleUpQuotaCheck(t *testing.T) {
+	savedApp := InformersSavedApp
+	defer func() { InformersSavedApp = savedApp }()
+	// Without informers quotas and LimitRanges are read from the API server.
+	InformersSavedApp = nil
+
+	cpuRequest := corev1.PodSpec{Containers: []corev1.Container{
+		testContainer(testResources("cpu", "100m"), nil)}}
+	scoped := testQuota("best-effort", testResources("pods", "0"), testResources("pods", "0"))
+	scoped.Spec.Scopes = []corev1.ResourceQuotaScope{corev1.ResourceQuotaScopeBestEffort}
+	scopeSelected := testQuota("priority", testResources("requests.cpu", "0"), nil)
+	scopeSelected.Spec.ScopeSelector = &corev1.ScopeSelector{MatchExpressions: []corev1.ScopedResourceSelectorRequirement{
+		{ScopeName: corev1.ResourceQuotaScopePriorityClass, Operator: corev1.ScopeSelectorOpIn, Values: []string{"high"}},
+	}}
+	tests := []struct {
+		name         string
+		spec         corev1.PodSpec
+		objects      []runtime.Object
+		current      int
+		replicas     int
+		wantQuota    string
+		wantResource string
+		wantReason   string
+	}{
+		{name: "no quotas", spec: cpuRequest, current: 1, replicas: 100},
+		{name: "fits",
+			spec:    cpuRequest,
+			objects: []runtime.Object{testQuota("compute", testResources("requests.cpu", "1"), testResources("requests.cpu", "500m"))},
+			current: 3, replicas: 8},
+		{name: "exceeds headroom",
+			spec:    cpuRequest,
+			objects: []runtime.Object{testQuota("compute", testResources("requests.cpu", "1"), testResources("requests.cpu", "500m"))},
+			current: 3, replicas: 9, wantQuota: "compute", wantResource: "requests.cpu", wantReason: "needs 600m more"},
+		{name: "quota lowered below usage",
+			spec:    cpuRequest,
+			objects: []runtime.Object{testQuota("compute", testResources("cpu", "1"), testResources("cpu", "2"))},
+			current: 3, replicas: 4, wantQuota: "compute", wantResource: "cpu"},
+		{name: "pod count",
+			spec:    cpuRequest,
+			objects: []runtime.Object{testQuota("count", testResources("count/pods", "5"), testResources("count/pods", "4"))},
+			current: 4, replicas: 6, wantQuota: "count", wantResource: "count/pods"},
+		{name: "requires pods to set a limit",
+			spec:    cpuRequest,
+			objects: []runtime.Object{testQuota("memory", testResources("limits.memory", "1Gi"), nil)},
+			current: 1, replicas: 2, wantQuota: "memory", wantResource: "limits.memory",
+			wantReason: "the quota requires pods to set limits.memory"},
+		{name: "limit range default satisfies the quota",
+			spec: cpuRequest,
+			objects: []runtime.Object{testQuota("memory", testResources("limits.memory", "1Gi"), nil),
+				testLimitRange("lr", corev1.LimitTypeContainer, nil, testResources("memory", "256Mi"))},
+			current: 1, replicas: 4},
+		{name: "limit range default counted against the quota",
+			spec: cpuRequest,
+			objects: []runtime.Object{testQuota("memory", testResources("limits.memory", "1Gi"), nil),
+				testLimitRange("lr", corev1.LimitTypeContainer, nil, testResources("memory", "256Mi"))},
+			current: 1, replicas: 6, wantQuota: "memory", wantResource: "limits.memory"},
+		{name: "scoped quota skipped",
+			spec:    cpuRequest,
+			objects: []runtime.Object{scoped, scopeSelected},
+			current: 1, replicas: 10},
+		{name: "resources the server does not check ignored",
+			spec:    cpuRequest,
+			objects: []runtime.Object{testQuota("objects", testResources("services", "0", "requests.storage", "0"), nil)},
+			current: 1, replicas: 10},
+		{name: "first quota by name",
+			spec: cpuRequest,
+			objects: []runtime.Object{testQuota("zeta", testResources("pods", "1"), testResources("pods", "1")),
+				testQuota("alpha", testResources("requests.cpu", "100m"), nil)},
+			current: 1, replicas: 3, wantQuota: "alpha", wantResource: "requests.cpu"},
+		{name: "first resource by name",
+			spec: cpuRequest,
+			objects: []runtime.Object{testQuota("compute",
+				testResources("requests.cpu", "100m", "pods", "1"), testResources("pods", "1"))},
+			current: 1, replicas: 3, wantQuota: "compute", wantResource: "pods"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			App := &AppX{Clientset: fake.NewSimpleClientset(tt.objects...)}
+			deployment := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "personal"},
+				Spec:       appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: tt.spec}},
+			}
+			violation, err := scaleUpQuotaCheck(App, deployment, tt.current, tt.replicas)
+			if err != nil {
+				t.Fatalf("scaleUpQuotaCheck() failed: %v", err)
+			}
+			if tt.wantQuota == "" {
+				if violation != nil {
+					t.Errorf("scaleUpQuotaCheck(%d -> %d) = %v, want nil", tt.current, tt.replicas, violation)
+				}
+				return
+			}
+			if violation == nil {
+				t.Fatalf("scaleUpQuotaCheck(%d -> %d) = nil, want a violation of %q", tt.current, tt.replicas, tt.wantQuota)
+			}
+			if violation.Quota != tt.wantQuota || violation.Resource != tt.wantResource {
+				t.Errorf("scaleUpQuotaCheck() = %v, want quota %q resource %q", violation, tt.wantQuota, tt.wantResource)
+			}
+			if !strings.Contains(violation.Reason, tt.wantReason) {
+				t.Errorf("scaleUpQuotaCheck() reason = %q, want it to contain %q", violation.Reason, tt.wantReason)
+			}
+		})
+	}
+}
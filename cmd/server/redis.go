@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// This file holds just enough of the Redis protocol (RESP2) for
+// redisCache: a pipelining client.  The tests run it against an
+// in-process fake server.
+
+const (
+	redisDialTimeout = time.Second * 5
+	redisIOTimeout   = time.Second * 10
+)
+
+type redisError string
+
+func (e redisError) Error() string {
+	return string(e)
+}
+
+type redisConn struct {
+	addr   string
+	lock   sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func newRedisConn(addr string) *redisConn {
+	return &redisConn{addr: addr}
+}
+
+// Pipeline sends cmds in one round trip and returns their replies, each
+// one a string, int64, nil or []interface{}.  If any command fails, the
+// first failure is returned.  The connection is redialed after I/O errors.
+func (c *redisConn) Pipeline(cmds ...[]string) ([]interface{}, error) {
+	self := "(redisConn).Pipeline"
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.conn == nil {
+		conn, err := net.DialTimeout("tcp", c.addr, redisDialTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "net.DialTimeout", err)
+		}
+		c.conn, c.reader = conn, bufio.NewReader(conn)
+	}
+	c.conn.SetDeadline(time.Now().Add(redisIOTimeout))
+	writer := bufio.NewWriter(c.conn)
+	for _, cmd := range cmds {
+		redisWriteCommand(writer, cmd)
+	}
+	err := writer.Flush()
+	replies := make([]interface{}, len(cmds))
+	var replyErr error
+	for i := 0; err == nil && i < len(cmds); i++ {
+		replies[i], err = redisReadReply(c.reader)
+		if re, ok := err.(redisError); ok {
+			if replyErr == nil {
+				replyErr = re
+			}
+			err = nil
+		}
+	}
+	if err != nil {
+		c.conn.Close()
+		c.conn, c.reader = nil, nil
+		return nil, fmt.Errorf("%s: error while talking to redis at %q: %#v", self, c.addr, err)
+	}
+	if replyErr != nil {
+		return nil, fmt.Errorf("%s: redis replied: %v", self, replyErr)
+	}
+	return replies, nil
+}
+
+func (c *redisConn) Do(args ...string) (interface{}, error) {
+	replies, err := c.Pipeline(args)
+	if err != nil {
+		return nil, err
+	}
+	return replies[0], nil
+}
+
+func redisWriteCommand(writer *bufio.Writer, args []string) {
+	fmt.Fprintf(writer, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(writer, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+}
+
+func redisReadLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasSuffix(line, "\r\n") {
+		return "", fmt.Errorf("malformed line: %q", line)
+	}
+	return line[:len(line)-2], nil
+}
+
+// redisReadReply reads one reply.  An error reply is returned as a
+// redisError, after which the stream is still usable.
+func redisReadReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := redisReadLine(reader)
+	if err != nil {
+		return nil, err
+	}
+	if line == "" {
+		return nil, fmt.Errorf("empty reply line")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err = io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		elements := make([]interface{}, n)
+		for i := range elements {
+			if elements[i], err = redisReadReply(reader); err != nil {
+				return nil, err
+			}
+		}
+		return elements, nil
+	}
+	return nil, fmt.Errorf("unknown reply type: %q", line)
+}
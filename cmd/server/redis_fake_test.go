@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRedis serves the handful of commands redisCache uses, from memory.
+type fakeRedis struct {
+	lock    sync.Mutex
+	strings map[string]string
+	hashes  map[string]map[string]string
+	sets    map[string]map[string]bool
+}
+
+// startFakeRedis starts a fakeRedis on a loopback port for the duration
+// of test t and returns its address.
+func startFakeRedis(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen failed: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	f := &fakeRedis{
+		strings: make(map[string]string),
+		hashes:  make(map[string]map[string]string),
+		sets:    make(map[string]map[string]bool),
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader, writer := bufio.NewReader(conn), bufio.NewWriter(conn)
+	for {
+		request, err := redisReadReply(reader)
+		if err != nil {
+			return
+		}
+		elements, _ := request.([]interface{})
+		args := make([]string, 0, len(elements))
+		for _, element := range elements {
+			arg, _ := element.(string)
+			args = append(args, arg)
+		}
+		f.exec(writer, args)
+		if reader.Buffered() == 0 {
+			if writer.Flush() != nil {
+				return
+			}
+		}
+	}
+}
+
+func (f *fakeRedis) exec(writer *bufio.Writer, args []string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if len(args) == 0 {
+		fmt.Fprintf(writer, "-ERR empty command\r\n")
+		return
+	}
+	argc := map[string]int{"PING": 1, "GET": 2, "SET": 3, "DEL": 2, "HGET": 3, "HSET": 4,
+		"HDEL": 3, "HGETALL": 2, "SADD": 3, "SREM": 3, "SMEMBERS": 2}
+	cmd := strings.ToUpper(args[0])
+	if n, ok := argc[cmd]; !ok || len(args) < n {
+		fmt.Fprintf(writer, "-ERR unknown command or wrong number of arguments for %q\r\n", args[0])
+		return
+	}
+	switch cmd {
+	case "PING":
+		fmt.Fprintf(writer, "+PONG\r\n")
+	case "GET":
+		value, ok := f.strings[args[1]]
+		fakeRedisWriteBulk(writer, value, ok)
+	case "SET":
+		f.strings[args[1]] = args[2]
+		fmt.Fprintf(writer, "+OK\r\n")
+	case "DEL":
+		n := 0
+		for _, key := range args[1:] {
+			_, isString := f.strings[key]
+			_, isHash := f.hashes[key]
+			_, isSet := f.sets[key]
+			if isString || isHash || isSet {
+				n++
+			}
+			delete(f.strings, key)
+			delete(f.hashes, key)
+			delete(f.sets, key)
+		}
+		fmt.Fprintf(writer, ":%d\r\n", n)
+	case "HGET":
+		value, ok := f.hashes[args[1]][args[2]]
+		fakeRedisWriteBulk(writer, value, ok)
+	case "HSET":
+		if len(args)%2 != 0 {
+			fmt.Fprintf(writer, "-ERR wrong number of arguments for %q\r\n", args[0])
+			return
+		}
+		hash, ok := f.hashes[args[1]]
+		if !ok {
+			hash = make(map[string]string)
+			f.hashes[args[1]] = hash
+		}
+		n := 0
+		for i := 2; i < len(args); i += 2 {
+			if _, ok := hash[args[i]]; !ok {
+				n++
+			}
+			hash[args[i]] = args[i+1]
+		}
+		fmt.Fprintf(writer, ":%d\r\n", n)
+	case "HDEL":
+		n := 0
+		for _, field := range args[2:] {
+			if _, ok := f.hashes[args[1]][field]; ok {
+				delete(f.hashes[args[1]], field)
+				n++
+			}
+		}
+		if len(f.hashes[args[1]]) == 0 {
+			delete(f.hashes, args[1])
+		}
+		fmt.Fprintf(writer, ":%d\r\n", n)
+	case "HGETALL":
+		hash := f.hashes[args[1]]
+		fmt.Fprintf(writer, "*%d\r\n", len(hash)*2)
+		for field, value := range hash {
+			fakeRedisWriteBulk(writer, field, true)
+			fakeRedisWriteBulk(writer, value, true)
+		}
+	case "SADD":
+		set, ok := f.sets[args[1]]
+		if !ok {
+			set = make(map[string]bool)
+			f.sets[args[1]] = set
+		}
+		n := 0
+		for _, member := range args[2:] {
+			if !set[member] {
+				set[member] = true
+				n++
+			}
+		}
+		fmt.Fprintf(writer, ":%d\r\n", n)
+	case "SREM":
+		n := 0
+		for _, member := range args[2:] {
+			if f.sets[args[1]][member] {
+				delete(f.sets[args[1]], member)
+				n++
+			}
+		}
+		if len(f.sets[args[1]]) == 0 {
+			delete(f.sets, args[1])
+		}
+		fmt.Fprintf(writer, ":%d\r\n", n)
+	case "SMEMBERS":
+		set := f.sets[args[1]]
+		fmt.Fprintf(writer, "*%d\r\n", len(set))
+		for member := range set {
+			fakeRedisWriteBulk(writer, member, true)
+		}
+	}
+}
+
+func fakeRedisWriteBulk(writer *bufio.Writer, value string, ok bool) {
+	if !ok {
+		fmt.Fprintf(writer, "$-1\r\n")
+		return
+	}
+	fmt.Fprintf(writer, "$%d\r\n%s\r\n", len(value), value)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	klog "k8s.io/klog/v2"
+)
+
+var (
+	deploymentsResource = schema.GroupResource{Group: "apps", Resource: "deployments"}
+)
+
+// scalableResourcesList asks discovery for every namespaced resource with
+// a scale subresource, such as deployments, replicasets and custom
+// resources like Argo Rollouts, sorted by group and name.  Groups whose
+// discovery fails, e.g. an aggregated API that is down, are left out.
+func scalableResourcesList(App *AppX) ([]schema.GroupResource, error) {
+	self := "scalableResourcesList"
+	_, resourceLists, err := App.DiscoveryClient.ServerGroupsAndResources()
+	if err != nil {
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(discovery).ServerGroupsAndResources()", err)
+		}
+		klog.Warningf("%s: discovery incomplete: %v", self, err)
+	}
+	found := make(map[schema.GroupResource]bool)
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			klog.Errorf("%s: call to %q failed: %#v", self, "schema.ParseGroupVersion", err)
+			continue
+		}
+		namespaced := make(map[string]bool)
+		for _, apiResource := range resourceList.APIResources {
+			if !strings.Contains(apiResource.Name, "/") {
+				namespaced[apiResource.Name] = apiResource.Namespaced
+			}
+		}
+		for _, apiResource := range resourceList.APIResources {
+			name, ok := strings.CutSuffix(apiResource.Name, "/scale")
+			if ok && namespaced[name] {
+				found[schema.GroupResource{Group: gv.Group, Resource: name}] = true
+			}
+		}
+	}
+	resources := make([]schema.GroupResource, 0, len(found))
+	for gr := range found {
+		resources = append(resources, gr)
+	}
+	sort.Slice(resources, func(i, j int) bool {
+		if resources[i].Group != resources[j].Group {
+			return resources[i].Group < resources[j].Group
+		}
+		return resources[i].Resource < resources[j].Resource
+	})
+	return resources, nil
+}
+
+// scalableResourceGet resolves resource, a plural resource name such as
+// "rollouts", or one qualified with its group such as
+// "rollouts.argoproj.io", to a scalable resource.  It returns nil if there
+// is none, and with it the qualified names of the candidates if an
+// unqualified name is served by more than one group.  Discovery is cached,
+// and refreshed once when resource is not found, so that CRDs installed
+// since are picked up.
+func scalableResourceGet(App *AppX, resource string) (*schema.GroupResource, []string, error) {
+	self := "scalableResourceGet"
+	for attempt := 0; attempt < 2; attempt++ {
+		if attempt > 0 {
+			App.RESTMapper.Reset()
+		}
+		resources, err := scalableResourcesList(App)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: call to %q failed: %#v", self, "scalableResourcesList", err)
+		}
+		matching := make([]schema.GroupResource, 0)
+		for _, gr := range resources {
+			if gr.Resource == resource || gr.String() == resource {
+				matching = append(matching, gr)
+			}
+		}
+		switch {
+		case len(matching) == 1:
+			return &matching[0], nil, nil
+		case len(matching) > 1:
+			candidates := make([]string, 0, len(matching))
+			for _, gr := range matching {
+				candidates = append(candidates, gr.String())
+			}
+			return nil, candidates, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+// ScaleReplicasGet returns the replica count in the scale subresource of
+// object nsName/name of resource gr.
+func ScaleReplicasGet(App *AppX, nsName string, gr schema.GroupResource, name string) (int, error) {
+	self := "ScaleReplicasGet"
+	s, err := App.ScaleClient.Scales(nsName).Get(context.TODO(), gr, name, metav1.GetOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("%s: call to %q failed: %w", self, "(scaleclient).Scales().Get()", err)
+	}
+	return int(s.Spec.Replicas), nil
+}
+
+// ScaleReplicasSet scales object nsName/name of resource gr to replicas by
+// merge-patching its scale subresource through the dynamic client, and
+// returns the replica count the API server reports back.
+func ScaleReplicasSet(App *AppX, nsName string, gr schema.GroupResource, name string, replicas int) (int, error) {
+	self := "ScaleReplicasSet"
+	gvr, err := App.RESTMapper.ResourceFor(gr.WithVersion(""))
+	if err != nil {
+		return 0, fmt.Errorf("%s: call to %q failed: %#v", self, "(restmapper).ResourceFor()", err)
+	}
+	patch := []byte(fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas))
+	obj, err := App.DynamicClient.Resource(gvr).Namespace(nsName).Patch(context.TODO(), name, types.MergePatchType, patch,
+		metav1.PatchOptions{}, "scale")
+	if err != nil {
+		return 0, fmt.Errorf("%s: error while scaling %s: \"%s/%s\" to replicas=%d: %w",
+			self, gr.String(), nsName, name, replicas, err)
+	}
+	scaled, _, err := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if err != nil {
+		return 0, fmt.Errorf("%s: call to %q failed: %#v", self, "unstructured.NestedInt64", err)
+	}
+	return int(scaled), nil
+}
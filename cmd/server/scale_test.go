@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery/cached/memory"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/restmapper"
+)
+
+// scaleTestApp returns an App whose discovery serves resources.
+func scaleTestApp(resources []*metav1.APIResourceList) (*AppX, *fakediscovery.FakeDiscovery) {
+	discoveryClient := fake.NewSimpleClientset().Discovery().(*fakediscovery.FakeDiscovery)
+	discoveryClient.Resources = resources
+	App := &AppX{DiscoveryClient: memory.NewMemCacheClient(discoveryClient)}
+	App.RESTMapper = restmapper.NewDeferredDiscoveryRESTMapper(App.DiscoveryClient)
+	return App, discoveryClient
+}
+
+var (
+	scaleTestApps = &metav1.APIResourceList{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{
+		{Name: "deployments", Namespaced: true}, {Name: "deployments/scale", Namespaced: true},
+		{Name: "deployments/status", Namespaced: true},
+		{Name: "statefulsets", Namespaced: true}, {Name: "statefulsets/scale", Namespaced: true},
+		{Name: "daemonsets", Namespaced: true},
+	}}
+	scaleTestRollouts = &metav1.APIResourceList{GroupVersion: "argoproj.io/v1alpha1", APIResources: []metav1.APIResource{
+		{Name: "rollouts", Namespaced: true}, {Name: "rollouts/scale", Namespaced: true},
+	}}
+	scaleTestOtherRollouts = &metav1.APIResourceList{GroupVersion: "rollouts.example.com/v1", APIResources: []metav1.APIResource{
+		{Name: "rollouts", Namespaced: true}, {Name: "rollouts/scale", Namespaced: true},
+	}}
+	scaleTestClusterScoped = &metav1.APIResourceList{GroupVersion: "example.com/v1", APIResources: []metav1.APIResource{
+		{Name: "pools", Namespaced: false}, {Name: "pools/scale", Namespaced: false},
+	}}
+)
+
+func TestScalableResourcesList(t *testing.T) {
+	App, _ := scaleTestApp([]*metav1.APIResourceList{scaleTestRollouts, scaleTestApps, scaleTestClusterScoped})
+	got, err := scalableResourcesList(App)
+	if err != nil {
+		t.Fatalf("scalableResourcesList() failed: %v", err)
+	}
+	want := []schema.GroupResource{{Group: "apps", Resource: "deployments"}, {Group: "apps", Resource: "statefulsets"},
+		{Group: "argoproj.io", Resource: "rollouts"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("scalableResourcesList() = %v, want %v", got, want)
+	}
+}
+
+func TestScalableResourceGet(t *testing.T) {
+	tests := []struct {
+		name           string
+		resources      []*metav1.APIResourceList
+		resource       string
+		want           *schema.GroupResource
+		wantCandidates []string
+	}{
+		{name: "plural name", resources: []*metav1.APIResourceList{scaleTestApps, scaleTestRollouts},
+			resource: "rollouts", want: &schema.GroupResource{Group: "argoproj.io", Resource: "rollouts"}},
+		{name: "qualified name", resources: []*metav1.APIResourceList{scaleTestApps, scaleTestRollouts},
+			resource: "deployments.apps", want: &schema.GroupResource{Group: "apps", Resource: "deployments"}},
+		{name: "wrong group", resources: []*metav1.APIResourceList{scaleTestApps, scaleTestRollouts},
+			resource: "deployments.argoproj.io"},
+		{name: "no scale subresource", resources: []*metav1.APIResourceList{scaleTestApps},
+			resource: "daemonsets"},
+		{name: "cluster-scoped", resources: []*metav1.APIResourceList{scaleTestClusterScoped},
+			resource: "pools"},
+		{name: "unknown", resources: []*metav1.APIResourceList{scaleTestApps}, resource: "widgets"},
+		{name: "ambiguous", resources: []*metav1.APIResourceList{scaleTestRollouts, scaleTestOtherRollouts},
+			resource: "rollouts", wantCandidates: []string{"rollouts.argoproj.io", "rollouts.rollouts.example.com"}},
+		{name: "ambiguous qualified", resources: []*metav1.APIResourceList{scaleTestRollouts, scaleTestOtherRollouts},
+			resource: "rollouts.argoproj.io", want: &schema.GroupResource{Group: "argoproj.io", Resource: "rollouts"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			App, _ := scaleTestApp(tt.resources)
+			got, candidates, err := scalableResourceGet(App, tt.resource)
+			if err != nil {
+				t.Fatalf("scalableResourceGet(%q) failed: %v", tt.resource, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) || !reflect.DeepEqual(candidates, tt.wantCandidates) {
+				t.Errorf("scalableResourceGet(%q) = %v, %v; want %v, %v", tt.resource, got, candidates,
+					tt.want, tt.wantCandidates)
+			}
+		})
+	}
+}
+
+// TestScalableResourceGetRefresh installs a CRD after discovery was cached
+// and expects a lookup of it to refresh discovery.
+func TestScalableResourceGetRefresh(t *testing.T) {
+	App, discoveryClient := scaleTestApp([]*metav1.APIResourceList{scaleTestApps})
+	if got, _, err := scalableResourceGet(App, "rollouts"); err != nil || got != nil {
+		t.Fatalf("scalableResourceGet(%q) = %v, %v; want nothing before the CRD", "rollouts", got, err)
+	}
+	discoveryClient.Resources = append(discoveryClient.Resources, scaleTestRollouts)
+	got, _, err := scalableResourceGet(App, "rollouts")
+	want := &schema.GroupResource{Group: "argoproj.io", Resource: "rollouts"}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("scalableResourceGet(%q) = %v, %v; want %v", "rollouts", got, err, want)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+var (
+	reFieldRequirement = regexp.MustCompile(`^\s*([a-z]+(?:\.[a-z]+)?)\s*(==|!=|>=|<=|=|>|<)\s*([-a-z0-9.]*)\s*$`)
+
+	// fieldAliases maps the object field paths kubectl users type to the
+	// fields they select on.
+	fieldAliases = map[string]string{
+		"metadata.name":      "name",
+		"metadata.namespace": "namespace",
+	}
+
+	// namespaceFields and workloadFields are the fields that the
+	// namespace and workload lists can be filtered on.
+	namespaceFields = []string{"name", "namespace"}
+	workloadFields  = []string{"name", "namespace", "replicas"}
+)
+
+type FieldRequirement struct {
+	Field    string
+	Operator string
+	Value    string
+}
+
+type ListFilter struct {
+	Labels labels.Selector
+	Fields []FieldRequirement
+}
+
+// listFilterFromRequest builds a ListFilter from the "labelSelector" and
+// "fieldSelector" query parameters.  labelSelector uses Kubernetes selector
+// syntax.  fieldSelector is a comma-separated list of <field><op><value>,
+// where field is one of ( name namespace replicas ), or metadata.name or
+// metadata.namespace for the first two, and op is one of ( = == != > >=
+// < <= ).  Ordering operators apply to replicas only.  A field that is not
+// in fields, those of the list being filtered, is an error.
+func listFilterFromRequest(r *http.Request, fields []string) (*ListFilter, error) {
+	self := "listFilterFromRequest"
+	filter := &ListFilter{Labels: labels.Everything()}
+	query := r.URL.Query()
+	if labelSelector := query.Get("labelSelector"); labelSelector != "" {
+		selector, err := labels.Parse(labelSelector)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid labelSelector: %q: %v", self, labelSelector, err)
+		}
+		filter.Labels = selector
+	}
+	fieldSelector := query.Get("fieldSelector")
+	if fieldSelector == "" {
+		return filter, nil
+	}
+	for _, term := range strings.Split(fieldSelector, ",") {
+		matches := reFieldRequirement.FindStringSubmatch(term)
+		if len(matches) < 4 {
+			return nil, fmt.Errorf("%s: invalid fieldSelector term: %q", self, term)
+		}
+		req := FieldRequirement{Field: matches[1], Operator: matches[2], Value: matches[3]}
+		if field, ok := fieldAliases[req.Field]; ok {
+			req.Field = field
+		}
+		switch req.Field {
+		case "name", "namespace":
+			if req.Operator != "=" && req.Operator != "==" && req.Operator != "!=" {
+				return nil, fmt.Errorf("%s: operator %q not supported for field %q", self, req.Operator, req.Field)
+			}
+		case "replicas":
+			if _, err := strconv.Atoi(req.Value); err != nil {
+				return nil, fmt.Errorf("%s: field %q needs an integer value: %q", self, req.Field, req.Value)
+			}
+		default:
+			return nil, fmt.Errorf("%s: unknown field: %q", self, req.Field)
+		}
+		if !slices.Contains(fields, req.Field) {
+			return nil, fmt.Errorf("%s: field %q not supported by this list", self, req.Field)
+		}
+		filter.Fields = append(filter.Fields, req)
+	}
+	return filter, nil
+}
+
+func (req FieldRequirement) matchesString(actual string) bool {
+	if req.Operator == "!=" {
+		return actual != req.Value
+	}
+	return actual == req.Value
+}
+
+func (req FieldRequirement) matchesInt(actual int) bool {
+	value, _ := strconv.Atoi(req.Value)
+	switch req.Operator {
+	case "=", "==":
+		return actual == value
+	case "!=":
+		return actual != value
+	case ">":
+		return actual > value
+	case ">=":
+		return actual >= value
+	case "<":
+		return actual < value
+	case "<=":
+		return actual <= value
+	}
+	return false
+}
+
+// MatchesNamespace reports whether a cached namespace passes the filter.
+// listFilterFromRequest refuses "replicas" for namespaces; were it there,
+// it would match none.
+func (f *ListFilter) MatchesNamespace(ni *NamespaceItem) bool {
+	if f == nil {
+		return true
+	}
+	if !f.Labels.Matches(labels.Set(ni.Labels)) {
+		return false
+	}
+	for _, req := range f.Fields {
+		switch req.Field {
+		case "name", "namespace":
+			if !req.matchesString(ni.Name) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesDeployment reports whether a cached deployment in namespace nsName
+// passes the filter.
+func (f *ListFilter) MatchesDeployment(nsName string, di *DeploymentItem) bool {
+	return f.matchesWorkload(nsName, di.Name, di.Replicas, di.Labels)
+}
+
+// MatchesStatefulSet reports whether a cached statefulset in namespace
+// nsName passes the filter.
+func (f *ListFilter) MatchesStatefulSet(nsName string, si *StatefulSetItem) bool {
+	return f.matchesWorkload(nsName, si.Name, si.Replicas, si.Labels)
+}
+
+// MatchesDaemonSet reports whether a cached daemonset in namespace nsName
+// passes the filter.  A daemonset's replicas are its desired count.
+func (f *ListFilter) MatchesDaemonSet(nsName string, dsi *DaemonSetItem) bool {
+	return f.matchesWorkload(nsName, dsi.Name, dsi.Desired, dsi.Labels)
+}
+
+func (f *ListFilter) matchesWorkload(nsName string, name string, replicas int, workloadLabels map[string]string) bool {
+	if f == nil {
+		return true
+	}
+	if !f.Labels.Matches(labels.Set(workloadLabels)) {
+		return false
+	}
+	for _, req := range f.Fields {
+		var ok bool
+		switch req.Field {
+		case "name":
+			ok = req.matchesString(name)
+		case "namespace":
+			ok = req.matchesString(nsName)
+		case "replicas":
+			ok = req.matchesInt(replicas)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
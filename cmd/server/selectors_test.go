@@ -0,0 +1,98 @@
+package main
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestListFilterFromRequest(t *testing.T) {
+	web := &DeploymentItem{Name: "web", Replicas: 2, Labels: map[string]string{"app": "web"}}
+	dotted := &DeploymentItem{Name: "my.app", Replicas: 0, Labels: map[string]string{"app": "my"}}
+	tests := []struct {
+		name          string
+		fieldSelector string
+		labelSelector string
+		wantErr       bool
+		wantWeb       bool
+		wantDotted    bool
+	}{
+		{name: "no selectors", wantWeb: true, wantDotted: true},
+		{name: "name with dots", fieldSelector: "name=my.app", wantDotted: true},
+		{name: "metadata.name alias", fieldSelector: "metadata.name=my.app", wantDotted: true},
+		{name: "metadata.name not equal", fieldSelector: "metadata.name!=my.app", wantWeb: true},
+		{name: "metadata.namespace alias", fieldSelector: "metadata.namespace==personal", wantWeb: true, wantDotted: true},
+		{name: "other namespace", fieldSelector: "metadata.namespace=kube-system"},
+		{name: "replicas above zero", fieldSelector: "replicas>0", wantWeb: true},
+		{name: "replicas at most zero", fieldSelector: "replicas<=0", wantDotted: true},
+		{name: "terms combined", fieldSelector: "replicas>0, name=web", wantWeb: true},
+		{name: "labels and fields", fieldSelector: "replicas>=0", labelSelector: "app=my", wantDotted: true},
+		{name: "unknown field", fieldSelector: "metadata.labels=x", wantErr: true},
+		{name: "unknown path", fieldSelector: "spec.name=x", wantErr: true},
+		{name: "ordering on name", fieldSelector: "name>web", wantErr: true},
+		{name: "ordering on alias", fieldSelector: "metadata.name>web", wantErr: true},
+		{name: "replicas not an integer", fieldSelector: "replicas>two", wantErr: true},
+		{name: "upper case value", fieldSelector: "name=Web", wantErr: true},
+		{name: "bad label selector", labelSelector: "app in (", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := url.Values{}
+			if tt.fieldSelector != "" {
+				query.Set("fieldSelector", tt.fieldSelector)
+			}
+			if tt.labelSelector != "" {
+				query.Set("labelSelector", tt.labelSelector)
+			}
+			r := httptest.NewRequest("GET", "/namespaces/personal/deployments?"+query.Encode(), nil)
+			filter, err := listFilterFromRequest(r, workloadFields)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("listFilterFromRequest() = %+v, want an error", filter)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("listFilterFromRequest() failed: %v", err)
+			}
+			if got := filter.MatchesDeployment("personal", web); got != tt.wantWeb {
+				t.Errorf("MatchesDeployment(web) = %t, want %t", got, tt.wantWeb)
+			}
+			if got := filter.MatchesDeployment("personal", dotted); got != tt.wantDotted {
+				t.Errorf("MatchesDeployment(my.app) = %t, want %t", got, tt.wantDotted)
+			}
+		})
+	}
+}
+
+func TestListFilterMatchesNamespace(t *testing.T) {
+	ni := &NamespaceItem{Name: "team.a", Labels: map[string]string{"team": "a"}}
+	tests := []struct {
+		fieldSelector string
+		wantErr       bool
+		want          bool
+	}{
+		{fieldSelector: "name=team.a", want: true},
+		{fieldSelector: "metadata.name=team.a", want: true},
+		{fieldSelector: "metadata.namespace=team.a", want: true},
+		{fieldSelector: "metadata.name=team-a", want: false},
+		{fieldSelector: "replicas>0", wantErr: true},
+		{fieldSelector: "name=team.a,replicas=1", wantErr: true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/namespaces?"+url.Values{"fieldSelector": {tt.fieldSelector}}.Encode(), nil)
+		filter, err := listFilterFromRequest(r, namespaceFields)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("listFilterFromRequest(%q) = %+v, want an error", tt.fieldSelector, filter)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("listFilterFromRequest(%q) failed: %v", tt.fieldSelector, err)
+		}
+		if got := filter.MatchesNamespace(ni); got != tt.want {
+			t.Errorf("MatchesNamespace() with %q = %t, want %t", tt.fieldSelector, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+	klog "k8s.io/klog/v2"
+)
+
+const (
+	serviceIndex = "service"
+)
+
+type ServicePortItem struct {
+	Name       string `json:"name,omitempty"`
+	Protocol   string `json:"protocol"`
+	Port       int    `json:"port"`
+	TargetPort string `json:"target_port"`
+}
+
+// ServiceItem is a service with the number of endpoints its
+// EndpointSlices list, each counted once across address families.
+// Endpoints whose readiness is unknown count as ready, as for kube-proxy.
+type ServiceItem struct {
+	Name              string            `json:"service"`
+	Type              string            `json:"type"`
+	ClusterIP         string            `json:"cluster_ip,omitempty"`
+	Ports             []ServicePortItem `json:"ports"`
+	Selector          map[string]string `json:"selector,omitempty"`
+	ReadyEndpoints    int               `json:"ready_endpoints"`
+	NotReadyEndpoints int               `json:"not_ready_endpoints"`
+}
+
+// serviceIndexFunc indexes EndpointSlices by the namespace and name of the
+// service they belong to.
+func serviceIndexFunc(obj interface{}) ([]string, error) {
+	slice, ok := obj.(*discoveryv1.EndpointSlice)
+	if !ok {
+		return []string{}, nil
+	}
+	sName, ok := slice.Labels[discoveryv1.LabelServiceName]
+	if !ok {
+		return []string{}, nil
+	}
+	return []string{slice.Namespace + "/" + sName}, nil
+}
+
+func serviceInformersAdd(App *AppX, nsName string, factory informers.SharedInformerFactory) error {
+	self := "serviceInformersAdd"
+	klog.Infof("%s: entry", self)
+	err := auxInformerAdd(App, informerName("services", nsName), factory.Core().V1().Services().Informer(),
+		metadataTrimTransform, nil)
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "auxInformerAdd", err)
+	}
+	err = auxInformerAdd(App, informerName("endpointslices", nsName), factory.Discovery().V1().EndpointSlices().Informer(),
+		endpointSliceTransform, cache.Indexers{serviceIndex: serviceIndexFunc})
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "auxInformerAdd", err)
+	}
+	return nil
+}
+
+// ServiceListGet returns the services of namespace nsName.  With
+// podLabels, only services whose selector matches them are returned;
+// services without a selector match nothing.
+func ServiceListGet(nsName string, podLabels map[string]string) ([]ServiceItem, error) {
+	self := "ServiceListGet"
+	klog.Infof("%s: entry", self)
+	serviceInformer, sliceInformer := informerGet("services", nsName), informerGet("endpointslices", nsName)
+	if serviceInformer == nil || sliceInformer == nil {
+		return nil, fmt.Errorf("%s: informers not available for namespace: %q", self, nsName)
+	}
+	objs, err := serviceInformer.GetIndexer().ByIndex(cache.NamespaceIndex, nsName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Indexer).ByIndex", err)
+	}
+	serviceList := make([]ServiceItem, 0)
+	for _, obj := range objs {
+		service := obj.(*corev1.Service)
+		if podLabels != nil &&
+			(len(service.Spec.Selector) == 0 || !labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(podLabels))) {
+			continue
+		}
+		slices, err := sliceInformer.GetIndexer().ByIndex(serviceIndex, nsName+"/"+service.Name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Indexer).ByIndex", err)
+		}
+		serviceList = append(serviceList, serviceItemFrom(service, slices))
+	}
+	sort.Slice(serviceList, func(i, j int) bool { return serviceList[i].Name < serviceList[j].Name })
+	return serviceList, nil
+}
+
+// DeploymentServiceListGet returns the services whose selector matches
+// the pod template labels of deployment nsName/dName.
+func DeploymentServiceListGet(nsName string, dName string) ([]ServiceItem, error) {
+	self := "DeploymentServiceListGet"
+	klog.Infof("%s: entry", self)
+	deploymentInformer := informerGet("deployments", nsName)
+	if deploymentInformer == nil {
+		return nil, fmt.Errorf("%s: informers not available for namespace: %q", self, nsName)
+	}
+	obj, exists, err := deploymentInformer.GetStore().GetByKey(nsName + "/" + dName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: call to %q failed: %#v", self, "(Store).GetByKey", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("unknown %q arg value: %q", "dName", dName)
+	}
+	podLabels := obj.(*appsv1.Deployment).Spec.Template.Labels
+	if podLabels == nil {
+		podLabels = map[string]string{}
+	}
+	return ServiceListGet(nsName, podLabels)
+}
+
+func serviceItemFrom(service *corev1.Service, slices []interface{}) ServiceItem {
+	si := ServiceItem{Name: service.Name, Type: string(service.Spec.Type), ClusterIP: service.Spec.ClusterIP,
+		Ports: []ServicePortItem{}, Selector: service.Spec.Selector}
+	for _, port := range service.Spec.Ports {
+		si.Ports = append(si.Ports, ServicePortItem{Name: port.Name, Protocol: string(port.Protocol), Port: int(port.Port),
+			TargetPort: port.TargetPort.String()})
+	}
+	ready := make(map[string]bool)
+	for _, obj := range slices {
+		for _, endpoint := range obj.(*discoveryv1.EndpointSlice).Endpoints {
+			key := ""
+			if endpoint.TargetRef != nil && endpoint.TargetRef.UID != "" {
+				key = string(endpoint.TargetRef.UID)
+			} else if len(endpoint.Addresses) > 0 {
+				key = endpoint.Addresses[0]
+			}
+			ready[key] = ready[key] || endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready
+		}
+	}
+	for _, isReady := range ready {
+		if isReady {
+			si.ReadyEndpoints++
+		} else {
+			si.NotReadyEndpoints++
+		}
+	}
+	return si
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	klog "k8s.io/klog/v2"
+)
+
+// Snapshot is the on-disk form of the cache.  ResourceVersions holds the
+// last resourceVersion each informer had synced to when it was saved.
+type Snapshot struct {
+	SavedAt          time.Time         `json:"saved_at"`
+	ResourceVersions map[string]string `json:"resource_versions"`
+	Namespaces       NamespaceMap      `json:"namespaces"`
+}
+
+var (
+	SnapshotLoaded *Snapshot
+)
+
+func snapshotLoad(App *AppX) error {
+	self := "snapshotLoad"
+	data, err := os.ReadFile(App.SnapshotFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		klog.Infof("%s: no snapshot at %q", self, App.SnapshotFile)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "os.ReadFile", err)
+	}
+	snapshot := new(Snapshot)
+	err = json.Unmarshal(data, snapshot)
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "json.Unmarshal", err)
+	}
+	if snapshot.Namespaces == nil {
+		snapshot.Namespaces = make(NamespaceMap)
+	}
+	for _, namespace := range snapshot.Namespaces {
+		if namespace.Deployments == nil {
+			namespace.Deployments = make(DeploymentMap)
+		}
+	}
+	NamespacesLock.Lock()
+	StaleCache = newMemoryCacheFrom(snapshot.Namespaces)
+	NamespacesLock.Unlock()
+	SnapshotLoaded = snapshot
+	klog.Infof("%s: loaded snapshot saved at %s: namespaces=%d;  resourceVersions=%v",
+		self, snapshot.SavedAt.Format(time.RFC3339), len(snapshot.Namespaces), snapshot.ResourceVersions)
+	return nil
+}
+
+// snapshotSave writes the live cache to a temporary file and renames it over
+// App.SnapshotFile, so that a crash mid-write never leaves a torn snapshot.
+func snapshotSave(App *AppX) error {
+	self := "snapshotSave"
+	snapshot := Snapshot{SavedAt: time.Now().UTC(), ResourceVersions: make(map[string]string)}
+	for name, informer := range informersCopy(App) {
+		snapshot.ResourceVersions[name] = informer.LastSyncResourceVersion()
+	}
+	NamespacesLock.Lock()
+	namespaces, err := cacheNamespaceMap(LiveCache)
+	if err == nil {
+		snapshot.Namespaces = namespaces
+	}
+	NamespacesLock.Unlock()
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "cacheNamespaceMap", err)
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "json.Marshal", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(App.SnapshotFile), filepath.Base(App.SnapshotFile)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "os.CreateTemp", err)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("%s: error while writing %q: %#v", self, tmp.Name(), err)
+	}
+	err = os.Rename(tmp.Name(), App.SnapshotFile)
+	if err != nil {
+		return fmt.Errorf("%s: call to %q failed: %#v", self, "os.Rename", err)
+	}
+	klog.Infof("%s: saved snapshot to %q: bytes=%d", self, App.SnapshotFile, len(data))
+	return nil
+}
+
+func runSnapshotWriter(App *AppX) {
+	self := "runSnapshotWriter"
+	ticker := time.NewTicker(App.SnapshotInterval)
+	defer ticker.Stop()
+	for {
+		if err := snapshotSave(App); err != nil {
+			klog.Errorf("%s: %v", self, err)
+		}
+		select {
+		case <-App.Stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// initSnapshot loads the snapshot, if any, so that reads can be served
+// before the informers have synced.  A snapshot that can't be read is
+// logged and ignored.
+func initSnapshot(App *AppX) error {
+	self := "initSnapshot"
+	klog.Infof("%s: entry", self)
+	if App.SnapshotFile == "" {
+		return nil
+	}
+	if App.CacheBackend != CacheBackendMemory {
+		klog.Infof("%s: cache backend %q keeps its own state; ignoring --snapshot-file", self, App.CacheBackend)
+		return nil
+	}
+	if err := snapshotLoad(App); err != nil {
+		klog.Errorf("%s: ignoring snapshot: %v", self, err)
+	}
+	return nil
+}
+
+func initSnapshotWriter(App *AppX) error {
+	self := "initSnapshotWriter"
+	klog.Infof("%s: entry", self)
+	if App.SnapshotFile == "" || App.SnapshotInterval <= 0 || App.CacheBackend != CacheBackendMemory {
+		return nil
+	}
+	if SnapshotLoaded != nil {
+		for name, informer := range informersCopy(App) {
+			klog.Infof("%s: informer %q: snapshot resourceVersion=%q;  live resourceVersion=%q",
+				self, name, SnapshotLoaded.ResourceVersions[name], informer.LastSyncResourceVersion())
+		}
+	}
+	go runSnapshotWriter(App)
+	return nil
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Informer stores hold every object they watch for the life of the
+// process, so the transforms below cut objects down to the fields that
+// the handlers and endpoints read before they are stored.  Anything else
+// read from an informer store must be added here.
+
+// deploymentTransform keeps a deployment's identity, labels, replica
+// count, selector and pod template labels.  The pod template spec,
+// annotations, managed fields and status are dropped.
+func deploymentTransform(obj interface{}) (interface{}, error) {
+	deployment, ok := obj.(*appsv1.Deployment)
+	if !ok {
+		return obj, nil
+	}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            deployment.Name,
+			Namespace:       deployment.Namespace,
+			UID:             deployment.UID,
+			ResourceVersion: deployment.ResourceVersion,
+			Generation:      deployment.Generation,
+			Labels:          deployment.Labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: deployment.Spec.Replicas,
+			Selector: deployment.Spec.Selector,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: deployment.Spec.Template.Labels},
+			},
+		},
+	}, nil
+}
+
+// statefulSetTransform keeps a statefulset's identity, labels, replica
+// count, selector and the status fields served by the statefulset
+// endpoints.
+func statefulSetTransform(obj interface{}) (interface{}, error) {
+	statefulSet, ok := obj.(*appsv1.StatefulSet)
+	if !ok {
+		return obj, nil
+	}
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            statefulSet.Name,
+			Namespace:       statefulSet.Namespace,
+			UID:             statefulSet.UID,
+			ResourceVersion: statefulSet.ResourceVersion,
+			Generation:      statefulSet.Generation,
+			Labels:          statefulSet.Labels,
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: statefulSet.Spec.Replicas,
+			Selector: statefulSet.Spec.Selector,
+		},
+		Status: appsv1.StatefulSetStatus{
+			ReadyReplicas:   statefulSet.Status.ReadyReplicas,
+			CurrentRevision: statefulSet.Status.CurrentRevision,
+			UpdateRevision:  statefulSet.Status.UpdateRevision,
+		},
+	}, nil
+}
+
+// daemonSetTransform keeps a daemonset's identity, labels, selector and
+// the node counts served by the daemonset endpoints.
+func daemonSetTransform(obj interface{}) (interface{}, error) {
+	daemonSet, ok := obj.(*appsv1.DaemonSet)
+	if !ok {
+		return obj, nil
+	}
+	return &appsv1.DaemonSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            daemonSet.Name,
+			Namespace:       daemonSet.Namespace,
+			UID:             daemonSet.UID,
+			ResourceVersion: daemonSet.ResourceVersion,
+			Generation:      daemonSet.Generation,
+			Labels:          daemonSet.Labels,
+		},
+		Spec: appsv1.DaemonSetSpec{
+			Selector: daemonSet.Spec.Selector,
+		},
+		Status: appsv1.DaemonSetStatus{
+			DesiredNumberScheduled: daemonSet.Status.DesiredNumberScheduled,
+			CurrentNumberScheduled: daemonSet.Status.CurrentNumberScheduled,
+			NumberReady:            daemonSet.Status.NumberReady,
+			UpdatedNumberScheduled: daemonSet.Status.UpdatedNumberScheduled,
+			NumberMisscheduled:     daemonSet.Status.NumberMisscheduled,
+		},
+	}, nil
+}
+
+// replicaSetTransform keeps only what is needed to find a replicaset's
+// owner and pods.
+func replicaSetTransform(obj interface{}) (interface{}, error) {
+	replicaSet, ok := obj.(*appsv1.ReplicaSet)
+	if !ok {
+		return obj, nil
+	}
+	return &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            replicaSet.Name,
+			Namespace:       replicaSet.Namespace,
+			UID:             replicaSet.UID,
+			ResourceVersion: replicaSet.ResourceVersion,
+			OwnerReferences: replicaSet.OwnerReferences,
+		},
+	}, nil
+}
+
+// podTransform keeps a pod's identity, labels, owners, deletion time, node
+// and the status fields served by the pods endpoint.  Container state messages,
+// images and the rest of the spec are dropped.
+func podTransform(obj interface{}) (interface{}, error) {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return obj, nil
+	}
+	transformed := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              pod.Name,
+			Namespace:         pod.Namespace,
+			UID:               pod.UID,
+			ResourceVersion:   pod.ResourceVersion,
+			CreationTimestamp: pod.CreationTimestamp,
+			DeletionTimestamp: pod.DeletionTimestamp,
+			Labels:            pod.Labels,
+			OwnerReferences:   pod.OwnerReferences,
+		},
+		Spec: corev1.PodSpec{NodeName: pod.Spec.NodeName},
+		Status: corev1.PodStatus{
+			Phase:                 pod.Status.Phase,
+			PodIP:                 pod.Status.PodIP,
+			InitContainerStatuses: containerStatusesTransform(pod.Status.InitContainerStatuses),
+			ContainerStatuses:     containerStatusesTransform(pod.Status.ContainerStatuses),
+		},
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			transformed.Status.Conditions = []corev1.PodCondition{{Type: condition.Type, Status: condition.Status}}
+		}
+	}
+	return transformed, nil
+}
+
+func containerStatusesTransform(statuses []corev1.ContainerStatus) []corev1.ContainerStatus {
+	if statuses == nil {
+		return nil
+	}
+	transformed := make([]corev1.ContainerStatus, 0, len(statuses))
+	for _, status := range statuses {
+		state, lastState := corev1.ContainerState{}, corev1.ContainerState{}
+		switch {
+		case status.State.Waiting != nil:
+			state.Waiting = &corev1.ContainerStateWaiting{Reason: status.State.Waiting.Reason}
+		case status.State.Terminated != nil:
+			state.Terminated = &corev1.ContainerStateTerminated{Reason: status.State.Terminated.Reason,
+				ExitCode: status.State.Terminated.ExitCode}
+		case status.State.Running != nil:
+			state.Running = &corev1.ContainerStateRunning{}
+		}
+		if status.LastTerminationState.Terminated != nil {
+			lastState.Terminated = &corev1.ContainerStateTerminated{Reason: status.LastTerminationState.Terminated.Reason,
+				ExitCode: status.LastTerminationState.Terminated.ExitCode}
+		}
+		transformed = append(transformed, corev1.ContainerStatus{Name: status.Name, Ready: status.Ready,
+			RestartCount: status.RestartCount, State: state, LastTerminationState: lastState})
+	}
+	return transformed
+}
+
+// endpointSliceTransform keeps an EndpointSlice's identity, labels (which
+// name its service) and, per endpoint, the addresses, target and
+// readiness.
+func endpointSliceTransform(obj interface{}) (interface{}, error) {
+	slice, ok := obj.(*discoveryv1.EndpointSlice)
+	if !ok {
+		return obj, nil
+	}
+	transformed := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            slice.Name,
+			Namespace:       slice.Namespace,
+			UID:             slice.UID,
+			ResourceVersion: slice.ResourceVersion,
+			Labels:          slice.Labels,
+		},
+		AddressType: slice.AddressType,
+		Endpoints:   make([]discoveryv1.Endpoint, 0, len(slice.Endpoints)),
+	}
+	for _, endpoint := range slice.Endpoints {
+		var targetRef *corev1.ObjectReference
+		if endpoint.TargetRef != nil {
+			targetRef = &corev1.ObjectReference{Kind: endpoint.TargetRef.Kind, Name: endpoint.TargetRef.Name, UID: endpoint.TargetRef.UID}
+		}
+		transformed.Endpoints = append(transformed.Endpoints, discoveryv1.Endpoint{Addresses: endpoint.Addresses,
+			Conditions: endpoint.Conditions, TargetRef: targetRef})
+	}
+	return transformed, nil
+}
+
+// jobTransform keeps a job's identity, owners, completions and the status
+// counts, times and condition types served by the jobs endpoint.
+func jobTransform(obj interface{}) (interface{}, error) {
+	job, ok := obj.(*batchv1.Job)
+	if !ok {
+		return obj, nil
+	}
+	transformed := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            job.Name,
+			Namespace:       job.Namespace,
+			UID:             job.UID,
+			ResourceVersion: job.ResourceVersion,
+			Labels:          job.Labels,
+			OwnerReferences: job.OwnerReferences,
+		},
+		Spec: batchv1.JobSpec{Completions: job.Spec.Completions, Suspend: job.Spec.Suspend},
+		Status: batchv1.JobStatus{
+			Active:         job.Status.Active,
+			Succeeded:      job.Status.Succeeded,
+			Failed:         job.Status.Failed,
+			StartTime:      job.Status.StartTime,
+			CompletionTime: job.Status.CompletionTime,
+		},
+	}
+	for _, condition := range job.Status.Conditions {
+		transformed.Status.Conditions = append(transformed.Status.Conditions,
+			batchv1.JobCondition{Type: condition.Type, Status: condition.Status})
+	}
+	return transformed, nil
+}
+
+// cronJobTransform keeps a cronjob's schedule, suspension and status.  The
+// job template is dropped; running a cronjob reads it from the API
+// server.
+func cronJobTransform(obj interface{}) (interface{}, error) {
+	cronJob, ok := obj.(*batchv1.CronJob)
+	if !ok {
+		return obj, nil
+	}
+	return &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            cronJob.Name,
+			Namespace:       cronJob.Namespace,
+			UID:             cronJob.UID,
+			ResourceVersion: cronJob.ResourceVersion,
+			Labels:          cronJob.Labels,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule: cronJob.Spec.Schedule,
+			TimeZone: cronJob.Spec.TimeZone,
+			Suspend:  cronJob.Spec.Suspend,
+		},
+		Status: cronJob.Status,
+	}, nil
+}
+
+// nodeTransform keeps a node's identity, labels, unschedulable flag,
+// taints, capacity and allocatable, and the type, status and reason of
+// its conditions.  Images, addresses, node info and volumes are dropped.
+func nodeTransform(obj interface{}) (interface{}, error) {
+	node, ok := obj.(*corev1.Node)
+	if !ok {
+		return obj, nil
+	}
+	transformed := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            node.Name,
+			UID:             node.UID,
+			ResourceVersion: node.ResourceVersion,
+			Labels:          node.Labels,
+		},
+		Spec: corev1.NodeSpec{Unschedulable: node.Spec.Unschedulable, Taints: node.Spec.Taints},
+		Status: corev1.NodeStatus{
+			Capacity:    node.Status.Capacity,
+			Allocatable: node.Status.Allocatable,
+			Conditions:  make([]corev1.NodeCondition, 0, len(node.Status.Conditions)),
+		},
+	}
+	for _, condition := range node.Status.Conditions {
+		transformed.Status.Conditions = append(transformed.Status.Conditions,
+			corev1.NodeCondition{Type: condition.Type, Status: condition.Status, Reason: condition.Reason})
+	}
+	return transformed, nil
+}
+
+// eventTransform keeps what the events endpoint serves: the involved
+// object's kind, name and UID, the type, reason and message, and the
+// times, count and reporter.
+func eventTransform(obj interface{}) (interface{}, error) {
+	event, ok := obj.(*corev1.Event)
+	if !ok {
+		return obj, nil
+	}
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              event.Name,
+			Namespace:         event.Namespace,
+			UID:               event.UID,
+			ResourceVersion:   event.ResourceVersion,
+			CreationTimestamp: event.CreationTimestamp,
+		},
+		InvolvedObject: corev1.ObjectReference{Kind: event.InvolvedObject.Kind, Name: event.InvolvedObject.Name,
+			UID: event.InvolvedObject.UID},
+		Type:                event.Type,
+		Reason:              event.Reason,
+		Message:             event.Message,
+		Count:               event.Count,
+		FirstTimestamp:      event.FirstTimestamp,
+		LastTimestamp:       event.LastTimestamp,
+		EventTime:           event.EventTime,
+		Series:              event.Series,
+		Source:              corev1.EventSource{Component: event.Source.Component},
+		ReportingController: event.ReportingController,
+	}, nil
+}
+
+// ingressTransform keeps an ingress's identity, class, rules, default
+// backend, TLS hosts and load balancer addresses.  Of its annotations
+// only the legacy class annotation is kept.
+func ingressTransform(obj interface{}) (interface{}, error) {
+	ingress, ok := obj.(*networkingv1.Ingress)
+	if !ok {
+		return obj, nil
+	}
+	var annotations map[string]string
+	if class, ok := ingress.Annotations[ingressClassAnnotation]; ok {
+		annotations = map[string]string{ingressClassAnnotation: class}
+	}
+	transformed := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            ingress.Name,
+			Namespace:       ingress.Namespace,
+			UID:             ingress.UID,
+			ResourceVersion: ingress.ResourceVersion,
+			Annotations:     annotations,
+		},
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: ingress.Spec.IngressClassName,
+			DefaultBackend:   ingress.Spec.DefaultBackend,
+			Rules:            ingress.Spec.Rules,
+		},
+		Status: ingress.Status,
+	}
+	for _, tls := range ingress.Spec.TLS {
+		transformed.Spec.TLS = append(transformed.Spec.TLS, networkingv1.IngressTLS{Hosts: tls.Hosts})
+	}
+	return transformed, nil
+}
+
+// metadataTransform drops the managed fields of metadata-only objects,
+// which are often larger than the rest of the metadata.
+func metadataTransform(obj interface{}) (interface{}, error) {
+	object, ok := obj.(*metav1.PartialObjectMetadata)
+	if !ok {
+		return obj, nil
+	}
+	object.ManagedFields = nil
+	return object, nil
+}
+
+// metadataTrimTransform drops the managed fields and annotations of small
+// objects that are otherwise stored whole.  Annotations often hold a copy
+// of the object as last applied.
+func metadataTrimTransform(obj interface{}) (interface{}, error) {
+	object, err := meta.Accessor(obj)
+	if err != nil {
+		return obj, nil
+	}
+	object.SetManagedFields(nil)
+	object.SetAnnotations(nil)
+	return obj, nil
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+	klog "k8s.io/klog/v2"
+)
+
+const (
+	benchNamespaces  = 20
+	benchDeployments = 2000
+)
+
+// benchDeployment builds a deployment with a pod template about the size
+// of a typical production one.
+func benchDeployment(nsName string, dName string) *appsv1.Deployment {
+	replicas := int32(3)
+	labels := map[string]string{"app": dName, "team": "platform", "tier": "web"}
+	env := make([]corev1.EnvVar, 0, 30)
+	for i := 0; i < 30; i++ {
+		env = append(env, corev1.EnvVar{Name: fmt.Sprintf("SETTING_%02d", i), Value: fmt.Sprintf("value-for-setting-%02d", i)})
+	}
+	container := corev1.Container{
+		Name:  dName,
+		Image: "registry.example.com/platform/" + dName + ":1.2.3",
+		Args:  []string{"--listen=:8080", "--log-level=info", "--metrics-port=9090"},
+		Env:   env,
+		Ports: []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}, {Name: "metrics", ContainerPort: 9090}},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("250m"), corev1.ResourceMemory: resource.MustParse("256Mi")},
+			Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1"), corev1.ResourceMemory: resource.MustParse("1Gi")},
+		},
+		ReadinessProbe: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{Path: "/readyz", Port: intstr.FromString("http")}}},
+		LivenessProbe: &corev1.Probe{ProbeHandler: corev1.ProbeHandler{
+			HTTPGet: &corev1.HTTPGetAction{Path: "/livez", Port: intstr.FromString("http")}}},
+	}
+	sidecar := container
+	sidecar.Name, sidecar.Image = "proxy", "registry.example.com/platform/proxy:4.5.6"
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: dName, Namespace: nsName, Labels: labels,
+			Annotations: map[string]string{"deployment.kubernetes.io/revision": "7", "owner": "platform@example.com"},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{container, sidecar}},
+			},
+		},
+		Status: appsv1.DeploymentStatus{Replicas: 3, ReadyReplicas: 3, AvailableReplicas: 3, UpdatedReplicas: 3},
+	}
+}
+
+func benchCluster() *fake.Clientset {
+	objects := make([]k8sruntime.Object, 0, benchDeployments)
+	for i := 0; i < benchDeployments; i++ {
+		objects = append(objects, benchDeployment(fmt.Sprintf("ns-%02d", i%benchNamespaces), fmt.Sprintf("deployment-%05d", i)))
+	}
+	return fake.NewSimpleClientset(objects...)
+}
+
+// benchHeapInUse measures the live heap held by whatever build returns
+// once it has been built.
+func benchHeapInUse(b *testing.B, build func() cache.SharedIndexInformer) float64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	informer := build()
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	if len(informer.GetStore().ListKeys()) != benchDeployments {
+		b.Fatalf("informer holds %d deployments, want %d", len(informer.GetStore().ListKeys()), benchDeployments)
+	}
+	return float64(after.HeapAlloc) - float64(before.HeapAlloc)
+}
+
+// BenchmarkDeploymentInformerMemory compares the heap held by a plain
+// deployment informer with that held by DeploymentLoggingController's
+// transformed one, cache entries included, for a synthetic cluster.  Run
+// with
+//
+//	go test -run=NONE -bench=DeploymentInformerMemory ./cmd/server
+func BenchmarkDeploymentInformerMemory(b *testing.B) {
+	klog.SetOutput(io.Discard)
+	klog.LogToStderr(false)
+	b.Run("full", func(b *testing.B) {
+		var total float64
+		for i := 0; i < b.N; i++ {
+			clientset := benchCluster()
+			stop := make(chan struct{})
+			total += benchHeapInUse(b, func() cache.SharedIndexInformer {
+				factory := informers.NewSharedInformerFactory(clientset, 0)
+				informer := factory.Apps().V1().Deployments().Informer()
+				factory.Start(stop)
+				factory.WaitForCacheSync(stop)
+				return informer
+			})
+			close(stop)
+		}
+		b.ReportMetric(total/float64(b.N)/benchDeployments, "heap-B/deployment")
+	})
+	b.Run("transformed", func(b *testing.B) {
+		var total float64
+		for i := 0; i < b.N; i++ {
+			clientset := benchCluster()
+			stop := make(chan struct{})
+			LiveCache, CacheSynced = newMemoryCache(), false
+			for n := 0; n < benchNamespaces; n++ {
+				LiveCache.NamespacePut(&NamespaceItem{Name: fmt.Sprintf("ns-%02d", n)})
+			}
+			total += benchHeapInUse(b, func() cache.SharedIndexInformer {
+				factory := informers.NewSharedInformerFactory(clientset, 0)
+				c, err := NewDeploymentLoggingController("deployments", factory)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err = c.Run(stop, 0); err != nil {
+					b.Fatal(err)
+				}
+				return c.deploymentInformer.Informer()
+			})
+			close(stop)
+		}
+		b.ReportMetric(total/float64(b.N)/benchDeployments, "heap-B/deployment")
+	})
+}